@@ -0,0 +1,15 @@
+package v1alpha1
+
+// condition types shared by all CR kinds, every transition of them is recorded as event of CR
+const (
+	// ConditionReady all pods or members of CR are ready to serve
+	ConditionReady = "Ready"
+	// ConditionBootstrapped cluster is bootstrapped and masters are elected
+	ConditionBootstrapped = "Bootstrapped"
+	// ConditionDegraded CR is serving, but some pods or members are not healthy
+	ConditionDegraded = "Degraded"
+	// ConditionFailoverInProgress recorded masters are lost and new masters are not elected yet
+	ConditionFailoverInProgress = "FailoverInProgress"
+	// ConditionBackupSucceeded last finished backup job is succeeded
+	ConditionBackupSucceeded = "BackupSucceeded"
+)
@@ -49,6 +49,9 @@ type MysqlBackupSpec struct {
 	Address []MysqlHost `json:"address,omitempty"`
 	// ClusterMode mysql cluster mode
 	ClusterMode ClusterMode `json:"clusterMode"`
+	// MysqlVersion mysql server version, must be X.X.X number, default is 5.7.34.
+	// mysql 8.0 servers are backup by mysqldump, mysql 5.7 servers are backup by mysqlpump
+	MysqlVersion string `json:"mysqlVersion,omitempty"`
 	// PVCName if pvc name is empty, a emptydir will be used as tmp storage for mysql backup files
 	PVCName *string `json:"pvcName,omitempty"`
 	// StorageSize mysql backup files tmp storage dir max size
@@ -59,8 +62,9 @@ type MysqlBackupSpec struct {
 	Password string `json:"password"`
 	// Schedule k8s/linux cronjob schedule
 	Schedule string `json:"schedule"`
-	// UseZlibCompress use zlib compress for mysqlpump command
-	// how to extra zlib compressed mysql backup file, see ???
+	// UseZlibCompress use zlib compress for backup file
+	// mysql 5.7 backup file is compressed by mysqlpump --compress-output=zlib, extract it with zlib_decompress of mysql distribution.
+	// mysql 8.0 backup file is plain zlib stream, extract it with a zlib inflater, such as openssl zlib -d or pigz -dz
 	UseZlibCompress *bool `json:"useZlibCompress,omitempty"`
 	// Webhook send backup file info POST to webhook url
 	Webhook *Webhook `json:"webhook,omitempty"`
@@ -72,6 +76,14 @@ type MysqlBackupSpec struct {
 type MysqlBackupStatus struct {
 	LastErrMsg string `json:"lastErrMsg,omitempty"`
 	Phase      string `json:"phase,omitempty"`
+	// LastJob name of last finished backup job
+	LastJob string `json:"lastJob,omitempty"`
+	// LastSuccessfulTime completion time of last succeeded backup job
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	// ObservedGeneration generation of CR which is applied by last reconcile
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions latest observations of backup state
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+genclient
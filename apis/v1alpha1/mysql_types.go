@@ -11,6 +11,18 @@ type ClusterMode string
 // ClusterPhase mysql cluster status
 type ClusterPhase string
 
+// SwitchoverPhase mysql planned primary switchover result
+type SwitchoverPhase string
+
+// MemberRole mysql member replication role
+type MemberRole string
+
+// UpgradePhase mysql rolling upgrade state
+type UpgradePhase string
+
+// DriftPolicy how runtime state drifted from desired state is handled
+type DriftPolicy string
+
 const (
 	// ModeMGRMP cluster mode is mysql group replication multi primary
 	ModeMGRMP ClusterMode = "MGRMP"
@@ -18,6 +30,41 @@ const (
 	ModeMGRSP ClusterMode = "MGRSP"
 	// ModeSemiSync cluster mode is  mysql semi sync
 	ModeSemiSync ClusterMode = "SemiSync"
+	// ModeAsync cluster mode is mysql asynchronous replication, one master and many replicas
+	ModeAsync ClusterMode = "Async"
+
+	// SwitchoverPhaseSucceeded planned primary switchover is succeeded
+	SwitchoverPhaseSucceeded SwitchoverPhase = "Succeeded"
+	// SwitchoverPhaseFailed planned primary switchover is failed, it will be retried after one minute
+	SwitchoverPhaseFailed SwitchoverPhase = "Failed"
+
+	// UpgradePhaseRunning outdated pods are restarted one at a time, primary is restarted last
+	UpgradePhaseRunning UpgradePhase = "Running"
+	// UpgradePhaseSucceeded all pods are running with latest statefulset revision
+	UpgradePhaseSucceeded UpgradePhase = "Succeeded"
+
+	// DriftPolicyEnforce drifted runtime state is corrected by operator
+	DriftPolicyEnforce DriftPolicy = "Enforce"
+	// DriftPolicyReport drifted runtime state is reported by status and events, drifted members are not changed by operator
+	DriftPolicyReport DriftPolicy = "Report"
+
+	// MemberRolePrimary member accepts writes, it is MGR primary member or semi sync master
+	MemberRolePrimary MemberRole = "primary"
+	// MemberRoleSecondary member replicates from primary
+	MemberRoleSecondary MemberRole = "secondary"
+	// MemberRoleUnknown member can not be connected
+	MemberRoleUnknown MemberRole = "unknown"
+
+	// MysqlConditionErrantTransactions condition type of members which executed transactions that masters do not have, status is True when any member is quarantined
+	MysqlConditionErrantTransactions = "ErrantTransactions"
+	// MysqlConditionQuorumLost condition type of group replication partition, status is True when majority of members are UNREACHABLE and group is blocked, only for MGRSP and MGRMP cluster mode
+	MysqlConditionQuorumLost = "QuorumLost"
+	// MysqlConditionPaused condition type of pause annotation, status is True when operator stops changing cluster topology
+	MysqlConditionPaused = "Paused"
+	// MysqlConditionDrifted condition type of runtime state drift, status is True when any drift is not corrected
+	MysqlConditionDrifted = "Drifted"
+	// MysqlConditionScaleInRefused condition type of scale in, status is True when spec.replicas is not applied because current primary would be removed
+	MysqlConditionScaleInRefused = "ScaleInRefused"
 
 	MysqlPhaseNotReady    ClusterPhase = "NotReady"
 	MysqlPhaseRunning     ClusterPhase = "Running"
@@ -26,12 +73,37 @@ const (
 
 // MysqlMGRSinglePrimaryOptions mysql multi group replication single primary mode options
 type MysqlMGRSinglePrimaryOptions struct {
-	// ApplierThreshold mysql mgr variable: loose-group_replication_flow_control_applier_threshold
+	// ApplierThreshold mysql mgr variable: loose-group_replication_flow_control_applier_threshold.
+	// deprecated, use spec.mgr.flowControlApplierThreshold, it is used when spec.mgr.flowControlApplierThreshold is nil
 	ApplierThreshold int `json:"applierThreshold,omitempty"`
-	// MGRRtries mysql mgr variable: loose-group_replication_recovery_retry_count
+	// MGRRtries mysql mgr variable: loose-group_replication_recovery_retry_count.
+	// deprecated, use spec.mgr.recoveryRetryCount, it is used when spec.mgr.recoveryRetryCount is nil
 	MGRRetries int `json:"mgrRetries,omitempty"`
 }
 
+// MysqlMGROptions group replication variables of MGRSP and MGRMP cluster mode, nil field keeps mysql default.
+// they are written into my.cnf and applied to running members without restart, variables not supported by spec.version are ignored
+type MysqlMGROptions struct {
+	// FlowControlMode mysql mgr variable: group_replication_flow_control_mode, values are [ QUOTA DISABLED ]
+	FlowControlMode *string `json:"flowControlMode,omitempty"`
+	// FlowControlApplierThreshold mysql mgr variable: group_replication_flow_control_applier_threshold, transactions waiting in applier queue that trigger flow control
+	FlowControlApplierThreshold *int `json:"flowControlApplierThreshold,omitempty"`
+	// FlowControlCertifierThreshold mysql mgr variable: group_replication_flow_control_certifier_threshold, transactions waiting in certifier queue that trigger flow control
+	FlowControlCertifierThreshold *int `json:"flowControlCertifierThreshold,omitempty"`
+	// Consistency mysql mgr variable: group_replication_consistency, values are [ EVENTUAL BEFORE_ON_PRIMARY_FAILOVER BEFORE AFTER BEFORE_AND_AFTER ], mysql 8.0.14+
+	Consistency *string `json:"consistency,omitempty"`
+	// MemberWeight mysql mgr variable: group_replication_member_weight, 0 to 100, member of higher weight is elected as primary first, mysql 5.7.20+
+	MemberWeight *int `json:"memberWeight,omitempty"`
+	// ExpelTimeout mysql mgr variable: group_replication_member_expel_timeout, seconds to wait before suspected member is expelled, mysql 8.0.13+
+	ExpelTimeout *int `json:"expelTimeout,omitempty"`
+	// AutoRejoinTries mysql mgr variable: group_replication_autorejoin_tries, times of expelled member tries to rejoin group, mysql 8.0.16+
+	AutoRejoinTries *int `json:"autoRejoinTries,omitempty"`
+	// UnreachableMajorityTimeout mysql mgr variable: group_replication_unreachable_majority_timeout, seconds to wait before member in minority partition leaves group, 0 is wait forever
+	UnreachableMajorityTimeout *int `json:"unreachableMajorityTimeout,omitempty"`
+	// RecoveryRetryCount mysql mgr variable: group_replication_recovery_retry_count, times of distributed recovery tries to connect donors, default is 100
+	RecoveryRetryCount *int `json:"recoveryRetryCount,omitempty"`
+}
+
 type MysqlSemiSyncOptions struct {
 	// DoubleMasterHA if true , mysql-0 and mysql-1 will be cluster masters,they copy data from each other
 	DoubleMasterHA bool `json:"doubleMasterHA,omitempty"`
@@ -44,8 +116,8 @@ type MysqlSimpleUserInfo struct {
 	Password string `json:"password"`
 }
 
-// MysqlUser mysql user settings
-type MysqlUser struct {
+// MysqlClusterUser mysql user settings of cluster user
+type MysqlClusterUser struct {
 	MysqlSimpleUserInfo `json:",inline"`
 	// Privileges mysql grant sql privileges, for example : []stirng{ "SELECT" ,"REPLICATION CLIENT"} or []string{"ALL PRIVILEGES"}
 	Privileges []string `json:"privileges"`
@@ -91,11 +163,63 @@ type MysqlMonitor struct {
 	// if this field value is zero, pvc will alive forever
 }
 
+// MysqlClone seed new or rebuilt member from a healthy donor before it joins cluster.
+// mysql 8.0.17+ use clone plugin when member misses transactions purged on donor, cluster user needs BACKUP_ADMIN and CLONE_ADMIN privileges.
+// mysql 5.7 member with empty datadir copy data from donor sidecar with xtrabackup stream, configImage must have xtrabackup installed
+type MysqlClone struct {
+	// Port sidecar xtrabackup stream server port, only for mysql 5.7, default is 3307
+	Port *int `json:"port,omitempty"`
+	// Compute Resources required by sidecar xtrabackup stream server container, only for mysql 5.7
+	// +optional
+	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// MysqlReplicaOf source cluster of disaster recovery standby cluster.
+// source master must allow standby members to connect, replication user needs REPLICATION SLAVE privilege
+type MysqlReplicaOf struct {
+	// CRD source Mysql CR, master recorded in its status is followed
+	CRD *CRDMysql `json:"crd,omitempty"`
+	// Remote source mysql hosts, the one which read_only is OFF is followed. if field CRD is not nil, this field will not working
+	Remote []MysqlHost `json:"remote,omitempty"`
+	// User replication user of source cluster, password is base64 encoded.
+	// default is clusterUser of source Mysql CR for CRD, or clusterUser of this CR for Remote
+	User *MysqlSimpleUserInfo `json:"user,omitempty"`
+	// ServerIDOffset server-id of standby members are pod ordinal + 1 + ServerIDOffset, so they do not conflict with source members, default is 1000
+	ServerIDOffset *int `json:"serverIDOffset,omitempty"`
+}
+
+// MysqlDelayedReplica replicas which apply transactions later than master, data deleted by mistake can be recovered from them
+type MysqlDelayedReplica struct {
+	// Members delayed replicas count, they are members of highest ordinals which are not masters, default is 1
+	Members *int32 `json:"members,omitempty"`
+	// DelaySeconds MASTER_DELAY of delayed replicas, default is 3600
+	DelaySeconds *int `json:"delaySeconds,omitempty"`
+}
+
+// MysqlConnection options of connections from operator to mysql members, connections of sidecar and proxysql are not changed
+type MysqlConnection struct {
+	// TLSMode tls mode of connections, values are [ false preferred skip-verify true ], default is false.
+	// preferred and skip-verify work with certificates auto generated by mysql, true verifies server certificate against ca.crt of TLSSecret
+	TLSMode string `json:"tlsMode,omitempty"`
+	// TLSSecret secret in namespace of CR which has ca.crt, tls.crt and tls.key are optional client certificate for x509 authentication.
+	// it is required when TLSMode is true, server certificate must be issued by ca.crt for pod host name ${name}-mysql-${ordinal}.${namespace}
+	TLSSecret *string `json:"tlsSecret,omitempty"`
+	// DialTimeoutSeconds dial timeout of connections, default is 5
+	DialTimeoutSeconds *int `json:"dialTimeoutSeconds,omitempty"`
+	// ReadTimeoutSeconds I/O read timeout of each statement, default is no timeout. clone of new member may take longer than it
+	ReadTimeoutSeconds *int `json:"readTimeoutSeconds,omitempty"`
+	// WriteTimeoutSeconds I/O write timeout of each statement, default is no timeout
+	WriteTimeoutSeconds *int `json:"writeTimeoutSeconds,omitempty"`
+}
+
 // MysqlSpec defines the desired state of Mysql
 type MysqlSpec struct {
 	CommonField `json:",inline"`
-	// ClusterMode mysql cluster mode,values are [ MGRMP MGRSP SemiSync ]
+	// ClusterMode mysql cluster mode,values are [ MGRMP MGRSP SemiSync Async ]
 	ClusterMode ClusterMode `json:"clusterMode"`
+	// Version mysql server version of Image, must be X.X.X number, for example 5.7.34 or 8.0.27. default is 5.7.34
+	// sql statements and config variables are generated by this version
+	Version string `json:"version,omitempty"`
 	// RootPassword mysql root password, if empty, an will allow empty password login
 	RootPassword *string `json:"rootPassword,omitempty"`
 	// StorageClassName kuberentes storage class name of this mysql pod
@@ -110,6 +234,8 @@ type MysqlSpec struct {
 	Whitelist []string `json:"whitelist"`
 	// MGRSP mysql multi group replication single primary mode options
 	MGRSP *MysqlMGRSinglePrimaryOptions `json:"mgrsp,omitempty"`
+	// MGR mysql group replication variables of MGRSP and MGRMP cluster mode
+	MGR *MysqlMGROptions `json:"mgr,omitempty"`
 	// SemiSync mysql semi sync replication options
 	SemiSync *MysqlSemiSyncOptions `json:"semiSync,omitempty"`
 	// ExtraConfig write your own mysql config to override operator nested mysql config.
@@ -120,8 +246,80 @@ type MysqlSpec struct {
 	// Monitor mysql cluster monitor settings, if this field is not nil, will add mysql-exporter to mysql pod, add add prometheus operator kind:ServiceMonitor resource to mysql pod's namespace
 	Monitor *MysqlMonitor `json:"monitor,omitempty"`
 	// ClusterUser mysql cluster replication user
-	ClusterUser *MysqlUser `json:"clusterUser,omitempty"`
-	MaxConn     *int       `json:"maxConn,omitempty"`
+	ClusterUser *MysqlClusterUser `json:"clusterUser,omitempty"`
+	MaxConn     *int              `json:"maxConn,omitempty"`
+	// Primary desired primary pod name, such as yuxing-mysql-1, only for MGRSP, SemiSync and Async cluster mode.
+	// when it is not current primary and it is healthy, a planned switchover will be executed
+	Primary *string `json:"primary,omitempty"`
+	// Clone seed new or rebuilt member from a healthy donor before it joins cluster, if this field is nil, clone is disabled
+	Clone *MysqlClone `json:"clone,omitempty"`
+	// ReplicaOf run cluster as disaster recovery standby of source cluster, only for SemiSync and Async cluster mode.
+	// master of this cluster replicates asynchronously from source master and follows source failover.
+	// set annotation promote.mysql.hakurei.cn=true to promote it as independent cluster, then this field is removed
+	ReplicaOf *MysqlReplicaOf `json:"replicaOf,omitempty"`
+	// DelayedReplica delayed replicas are never promoted as master and not added to proxysql, they are exposed by service ${name}-mysql-delayed.
+	// only for SemiSync and Async cluster mode, delayed replicas are included in spec.replicas
+	DelayedReplica *MysqlDelayedReplica `json:"delayedReplica,omitempty"`
+	// DriftPolicy how read only, semi sync variables, replication source and cluster user grants changed by hand are handled, Enforce or Report, default is Enforce
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+	// Connection tls and timeouts of connections from operator to mysql members
+	Connection *MysqlConnection `json:"connection,omitempty"`
+}
+
+// MysqlDrift runtime state of member which differs from desired state
+type MysqlDrift struct {
+	// Name member pod name
+	Name string `json:"name"`
+	// Setting name of drifted setting, such as super_read_only, replication source or grants
+	Setting string `json:"setting"`
+	// Desired value derived from CR
+	Desired string `json:"desired,omitempty"`
+	// Actual runtime value of member
+	Actual string `json:"actual,omitempty"`
+	// Corrected drift is corrected by operator
+	Corrected bool `json:"corrected,omitempty"`
+	// Message reason of drift is not corrected
+	Message string `json:"message,omitempty"`
+}
+
+// MysqlBootstrapStatus group replication bootstrap decision, which member group is bootstrapped from
+type MysqlBootstrapStatus struct {
+	// Host bootstrap member host
+	Host string `json:"host,omitempty"`
+	// GTIDExecuted transactions of bootstrap member when bootstrap
+	GTIDExecuted string `json:"gtidExecuted,omitempty"`
+	// ReachableMembers reachable members count when bootstrap
+	ReachableMembers int `json:"reachableMembers,omitempty"`
+	// Forced group is bootstrapped without quorum of members reachable, by annotation force-bootstrap.mysql.hakurei.cn=true
+	Forced bool `json:"forced,omitempty"`
+	// Refused bootstrap is refused, because quorum of members are not reachable
+	Refused bool `json:"refused,omitempty"`
+	// Message reason of bootstrap decision
+	Message string `json:"message,omitempty"`
+	// Time bootstrap decision time
+	Time metav1.Time `json:"time,omitempty"`
+}
+
+// MysqlForceMembersStatus group membership forced after loss of quorum
+type MysqlForceMembersStatus struct {
+	// Members online members of surviving partition which group membership is forced to
+	Members []string `json:"members,omitempty"`
+	// Unreachable members expelled by forced membership, they rejoin group when they come back
+	Unreachable []string `json:"unreachable,omitempty"`
+	// Time force members time
+	Time metav1.Time `json:"time,omitempty"`
+}
+
+// MysqlReplicaOfStatus disaster recovery standby state
+type MysqlReplicaOfStatus struct {
+	// Source source master host:port followed by standby master
+	Source string `json:"source,omitempty"`
+	// Promoted standby cluster is promoted as independent cluster
+	Promoted bool `json:"promoted,omitempty"`
+	// Message reason of source master not found or promotion failed
+	Message string `json:"message,omitempty"`
+	// PromoteTime standby cluster promotion time
+	PromoteTime metav1.Time `json:"promoteTime,omitempty"`
 }
 
 // MysqlStatus defines the observed state of Mysql
@@ -131,6 +329,114 @@ type MysqlStatus struct {
 	Members        []string     `json:"members,omitempty"`
 	HealthyMembers []string     `json:"healthyMembers,omitempty"`
 	Phase          ClusterPhase `json:"phase,omitempty"`
+	// Bootstrap last group replication bootstrap decision, only for MGRSP and MGRMP cluster mode
+	Bootstrap *MysqlBootstrapStatus `json:"bootstrap,omitempty"`
+	// ForceMembers last forced group membership by annotation force-members.mysql.hakurei.cn=true, only for MGRSP and MGRMP cluster mode
+	ForceMembers *MysqlForceMembersStatus `json:"forceMembers,omitempty"`
+	// Switchover last planned primary switchover result
+	Switchover *MysqlSwitchoverStatus `json:"switchover,omitempty"`
+	// Upgrade last rolling upgrade of pods, pods are restarted by operator when statefulset template changed
+	Upgrade *MysqlUpgradeStatus `json:"upgrade,omitempty"`
+	// Config spec.extraConfig applied to running members, dynamic variables are applied without restart
+	Config *MysqlConfigStatus `json:"config,omitempty"`
+	// VolumeExpansion last online expansion of pvc when spec.storageSize grows
+	VolumeExpansion *VolumeExpansionStatus `json:"volumeExpansion,omitempty"`
+	// Drifts runtime state drift found by last check
+	Drifts []MysqlDrift `json:"drifts,omitempty"`
+	// MemberStatuses replication detail of every member
+	MemberStatuses []MysqlMemberStatus `json:"memberStatuses,omitempty"`
+	// ObservedGeneration generation of CR which is checked by last reconcile
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions latest observations of cluster state
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ReplicaOf disaster recovery standby state, only for cluster with spec.replicaOf
+	ReplicaOf *MysqlReplicaOfStatus `json:"replicaOf,omitempty"`
+}
+
+// MysqlMemberStatus replication detail of mysql member
+type MysqlMemberStatus struct {
+	// Name member pod name
+	Name string `json:"name"`
+	// Role values are [ primary secondary unknown ]
+	Role MemberRole `json:"role"`
+	// MemberState group replication member state, such as ONLINE RECOVERING ERROR, only for MGRSP and MGRMP cluster mode
+	MemberState string `json:"memberState,omitempty"`
+	// GTIDExecuted value of gtid_executed
+	GTIDExecuted string `json:"gtidExecuted,omitempty"`
+	// SecondsBehindSource replication lag of secondary member, only for SemiSync cluster mode
+	SecondsBehindSource *int64 `json:"secondsBehindSource,omitempty"`
+	// IOThread replication io thread state, such as Yes No Connecting
+	IOThread string `json:"ioThread,omitempty"`
+	// SQLThread replication sql thread state, such as Yes No
+	SQLThread    string `json:"sqlThread,omitempty"`
+	LastIOError  string `json:"lastIOError,omitempty"`
+	LastSQLError string `json:"lastSQLError,omitempty"`
+	// SemiSyncSourceStatus semi sync master status, OFF means master fall back to async replication because of no replica ack
+	SemiSyncSourceStatus string `json:"semiSyncSourceStatus,omitempty"`
+	// SemiSyncReplicaStatus semi sync replica ack status
+	SemiSyncReplicaStatus string `json:"semiSyncReplicaStatus,omitempty"`
+	// ErrantGTIDs transactions executed on member but not on masters, only for SemiSync and Async cluster mode
+	ErrantGTIDs string `json:"errantGTIDs,omitempty"`
+	// AcknowledgedErrantGTIDs errant transactions acknowledged by annotation ack-errant-transactions.mysql.hakurei.cn
+	AcknowledgedErrantGTIDs string `json:"acknowledgedErrantGTIDs,omitempty"`
+	// Quarantined member has errant transactions which are not acknowledged, it is never promoted as master and removed from proxysql
+	Quarantined bool `json:"quarantined,omitempty"`
+	// Delayed member is delayed replica of spec.delayedReplica
+	Delayed bool `json:"delayed,omitempty"`
+	// Maintenance member is under maintenance by annotation, it is removed from proxysql and never promoted as master
+	Maintenance bool `json:"maintenance,omitempty"`
+	// ProbeError error of last probe
+	ProbeError string `json:"probeError,omitempty"`
+	// LastProbeTime time of last probe which changed probed state of member
+	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty"`
+}
+
+// MysqlUpgradeStatus rolling upgrade progress of mysql pods
+type MysqlUpgradeStatus struct {
+	// Revision statefulset update revision which pods are upgraded to
+	Revision string `json:"revision"`
+	// Version mysql server version of spec.version when upgrade started
+	Version string `json:"version,omitempty"`
+	// Phase values are [ Running Succeeded ]
+	Phase UpgradePhase `json:"phase"`
+	// Pending pods which are not restarted with update revision
+	Pending []string `json:"pending,omitempty"`
+	// Restarting last pod deleted by operator
+	Restarting string `json:"restarting,omitempty"`
+	// Message reason of upgrade is waiting
+	Message string `json:"message,omitempty"`
+	// StartTime upgrade start time
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime upgrade completion time
+	CompletionTime metav1.Time `json:"completionTime,omitempty"`
+}
+
+// MysqlConfigStatus spec.extraConfig applied to running members
+type MysqlConfigStatus struct {
+	// Applied mysqld variables of spec.extraConfig handled by operator, variables of PendingRestart take effect after pods restarted
+	Applied map[string]string `json:"applied,omitempty"`
+	// PendingRestart variables which are read only, startup options or removed from spec.extraConfig, they take effect after pods are restarted
+	PendingRestart []string `json:"pendingRestart,omitempty"`
+	// RestartHash hash of spec.extraConfig which pods are restarted for, it is set to pod template annotation to trigger rolling restart
+	RestartHash string `json:"restartHash,omitempty"`
+	// Message reason of variable failed to apply
+	Message string `json:"message,omitempty"`
+}
+
+// MysqlSwitchoverStatus planned primary switchover result
+type MysqlSwitchoverStatus struct {
+	// From old primary pod name
+	From string `json:"from,omitempty"`
+	// To new primary pod name
+	To string `json:"to"`
+	// Phase values are [ Succeeded Failed ]
+	Phase SwitchoverPhase `json:"phase"`
+	// Message failed reason
+	Message string `json:"message,omitempty"`
+	// StartTime switchover start time
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime switchover completion time
+	CompletionTime metav1.Time `json:"completionTime,omitempty"`
 }
 
 //+genclient
@@ -0,0 +1,109 @@
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// MysqlUserConditionProxySQLRegistered condition type of account registered in ProxySQL CR, status is False when account of proxysql is kept unchanged, such as password is not read
+	MysqlUserConditionProxySQLRegistered = "ProxySQLRegistered"
+)
+
+// CRReference reference of CR in namespace of referencing CR
+type CRReference struct {
+	Name string `json:"name"`
+	// Namespace must be empty or namespace of referencing CR, reference to CR of other namespace is refused
+	Namespace *string `json:"namespace,omitempty"`
+}
+
+// MysqlUserGrant privileges of account on database target
+type MysqlUserGrant struct {
+	// Privileges mysql grant sql privileges, for example : []stirng{ "SELECT" ,"INSERT"} or []string{"ALL PRIVILEGES"}
+	Privileges []string `json:"privileges"`
+	// DatabaseTarget which database or tables will granted privileges to this user, for example : 'app.*'
+	DatabaseTarget string `json:"databaseTarget"`
+}
+
+// MysqlUserResourceLimits account resource limits, zero or empty value means no limit
+type MysqlUserResourceLimits struct {
+	// MaxQueriesPerHour mysql account option: MAX_QUERIES_PER_HOUR
+	MaxQueriesPerHour int `json:"maxQueriesPerHour,omitempty"`
+	// MaxUpdatesPerHour mysql account option: MAX_UPDATES_PER_HOUR
+	MaxUpdatesPerHour int `json:"maxUpdatesPerHour,omitempty"`
+	// MaxConnectionsPerHour mysql account option: MAX_CONNECTIONS_PER_HOUR
+	MaxConnectionsPerHour int `json:"maxConnectionsPerHour,omitempty"`
+	// MaxUserConnections mysql account option: MAX_USER_CONNECTIONS
+	MaxUserConnections int `json:"maxUserConnections,omitempty"`
+}
+
+// MysqlUserProxySQL register account in mysql_users of ProxySQL CR, it is used as frontend and backend user
+type MysqlUserProxySQL struct {
+	CRReference `json:",inline"`
+	// DefaultHostGroup proxysql hostgroup of queries which are not matched by query rules, default is 0
+	DefaultHostGroup int `json:"defaultHostGroup,omitempty"`
+	// MaxConnections max connections of account from proxysql to mysql servers, default is 200
+	MaxConnections int `json:"maxConnections,omitempty"`
+}
+
+// MysqlUserSpec defines the desired state of MysqlUser
+type MysqlUserSpec struct {
+	// Mysql Mysql CR which account is created in
+	Mysql CRReference `json:"mysql"`
+	// Username mysql login account name, name of MysqlUser CR is used when it is empty
+	Username string `json:"username,omitempty"`
+	// PasswordSecret key of secret in namespace of MysqlUser CR, value is plain text password
+	PasswordSecret corev1.SecretKeySelector `json:"passwordSecret"`
+	// Hosts user login domains, for example : []string{"%"} or []string{"10.0.0.%", "localhost"}
+	Hosts []string `json:"hosts"`
+	// Grants privileges of account, privileges removed from this list are revoked
+	Grants []MysqlUserGrant `json:"grants,omitempty"`
+	// ResourceLimits account resource limits
+	ResourceLimits *MysqlUserResourceLimits `json:"resourceLimits,omitempty"`
+	// ProxySQL register account in mysql_users of ProxySQL CR
+	ProxySQL *MysqlUserProxySQL `json:"proxySQL,omitempty"`
+}
+
+// MysqlUserStatus defines the observed state of MysqlUser
+type MysqlUserStatus struct {
+	// Username account name which is created in mysql
+	Username string `json:"username,omitempty"`
+	// Hosts login domains of account which are created in mysql, removed domains are dropped by next reconcile
+	Hosts []string `json:"hosts,omitempty"`
+	// Grants privileges which are granted, removed privileges are revoked by next reconcile
+	Grants []MysqlUserGrant `json:"grants,omitempty"`
+	// PasswordVersion resource version of password secret which is applied
+	PasswordVersion string `json:"passwordVersion,omitempty"`
+	// Master mysql member which account is applied on
+	Master string `json:"master,omitempty"`
+	// ObservedGeneration generation of CR which is applied by last reconcile
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions latest observations of account state
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+//+genclient
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:resource:shortName=myu
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+//+kubebuilder:printcolumn:JSONPath=".spec.mysql.name",name=mysql,type=string
+//+kubebuilder:printcolumn:JSONPath=".status.username",name=username,type=string
+
+// MysqlUser is the Schema for the mysqlusers API
+type MysqlUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MysqlUserSpec   `json:"spec,omitempty"`
+	Status MysqlUserStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// MysqlUserList contains a list of MysqlUser
+type MysqlUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []MysqlUser `json:"items"`
+}
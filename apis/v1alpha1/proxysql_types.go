@@ -58,10 +58,19 @@ type ProxySQLSpec struct {
 	ClusterMode ClusterMode `json:"clusterMode"`
 	// MysqlMaxConn max connections per mysql instance
 	MysqlMaxConn int `json:"mysqlMaxConn"`
+	// MaxWriters max mysql instances in writer hostgroup when ClusterMode is MGRMP, default is 3.
+	// other cluster modes always have one writer
+	MaxWriters *int `json:"maxWriters,omitempty"`
 }
 
 // ProxySQLStatus defines the observed state of ProxySQL
 type ProxySQLStatus struct {
+	// VolumeExpansion last online expansion of pvc when spec.storageSize grows
+	VolumeExpansion *VolumeExpansionStatus `json:"volumeExpansion,omitempty"`
+	// ObservedGeneration generation of CR which is applied by last reconcile
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions latest observations of proxysql state
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+genclient
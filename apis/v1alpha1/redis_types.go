@@ -182,7 +182,13 @@ type RedisSpec struct {
 // RedisStatus bootstrap process status
 type RedisStatus struct {
 	// Masters current redis cluster masters
-	Masters []string `json:"masters"`
+	Masters []string `json:"masters,omitempty"`
+	// VolumeExpansion last online expansion of pvc when spec.redis.storageSize grows
+	VolumeExpansion *VolumeExpansionStatus `json:"volumeExpansion,omitempty"`
+	// ObservedGeneration generation of CR which is applied by last reconcile
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Conditions latest observations of redis state
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -17,5 +17,6 @@ func init() {
 		&Redis{}, &RedisList{},
 		&MysqlBackup{}, &MysqlBackupList{},
 		&ProxySQL{}, &ProxySQLList{},
+		&MysqlUser{}, &MysqlUserList{},
 	)
 }
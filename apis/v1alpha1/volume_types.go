@@ -0,0 +1,32 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VolumeExpansionPhase online pvc expansion state
+type VolumeExpansionPhase string
+
+const (
+	// VolumeExpansionPhaseResizing pvc of pods are patched, waiting volumes and file systems are resized
+	VolumeExpansionPhaseResizing VolumeExpansionPhase = "Resizing"
+	// VolumeExpansionPhaseSucceeded capacity of all pvc is desired storage size
+	VolumeExpansionPhaseSucceeded VolumeExpansionPhase = "Succeeded"
+	// VolumeExpansionPhaseFailed storage class does not allow volume expansion, pvc and statefulset are not changed
+	VolumeExpansionPhaseFailed VolumeExpansionPhase = "Failed"
+)
+
+// VolumeExpansionStatus online expansion of pvc when spec.storageSize grows, statefulset is recreated without deleting pods
+type VolumeExpansionStatus struct {
+	// Size desired storage size
+	Size string `json:"size"`
+	// Phase values are [ Resizing Succeeded Failed ]
+	Phase VolumeExpansionPhase `json:"phase"`
+	// Pending pvc names whose capacity is less than desired storage size
+	Pending []string `json:"pending,omitempty"`
+	Message string   `json:"message,omitempty"`
+	// StartTime pvc expansion start time
+	StartTime metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime all pvc are resized or expansion failed
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
@@ -35,6 +35,7 @@ package v1alpha1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -78,6 +79,26 @@ func (in *CRDMysql) DeepCopy() *CRDMysql {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CRReference) DeepCopyInto(out *CRReference) {
+	*out = *in
+	if in.Namespace != nil {
+		in, out := &in.Namespace, &out.Namespace
+		*out = new(string)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CRReference.
+func (in *CRReference) DeepCopy() *CRReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CRReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CommonField) DeepCopyInto(out *CommonField) {
 	*out = *in
@@ -164,7 +185,7 @@ func (in *MysqlBackup) DeepCopyInto(out *MysqlBackup) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlBackup.
@@ -261,6 +282,17 @@ func (in *MysqlBackupSpec) DeepCopy() *MysqlBackupSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MysqlBackupStatus) DeepCopyInto(out *MysqlBackupStatus) {
 	*out = *in
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlBackupStatus.
@@ -273,6 +305,192 @@ func (in *MysqlBackupStatus) DeepCopy() *MysqlBackupStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlBootstrapStatus) DeepCopyInto(out *MysqlBootstrapStatus) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlBootstrapStatus.
+func (in *MysqlBootstrapStatus) DeepCopy() *MysqlBootstrapStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlBootstrapStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlClone) DeepCopyInto(out *MysqlClone) {
+	*out = *in
+	if in.Port != nil {
+		in, out := &in.Port, &out.Port
+		*out = new(int)
+		**out = **in
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlClone.
+func (in *MysqlClone) DeepCopy() *MysqlClone {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlClone)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlClusterUser) DeepCopyInto(out *MysqlClusterUser) {
+	*out = *in
+	out.MysqlSimpleUserInfo = in.MysqlSimpleUserInfo
+	if in.Privileges != nil {
+		in, out := &in.Privileges, &out.Privileges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlClusterUser.
+func (in *MysqlClusterUser) DeepCopy() *MysqlClusterUser {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlClusterUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlConfigStatus) DeepCopyInto(out *MysqlConfigStatus) {
+	*out = *in
+	if in.Applied != nil {
+		in, out := &in.Applied, &out.Applied
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.PendingRestart != nil {
+		in, out := &in.PendingRestart, &out.PendingRestart
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlConfigStatus.
+func (in *MysqlConfigStatus) DeepCopy() *MysqlConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlConnection) DeepCopyInto(out *MysqlConnection) {
+	*out = *in
+	if in.TLSSecret != nil {
+		in, out := &in.TLSSecret, &out.TLSSecret
+		*out = new(string)
+		**out = **in
+	}
+	if in.DialTimeoutSeconds != nil {
+		in, out := &in.DialTimeoutSeconds, &out.DialTimeoutSeconds
+		*out = new(int)
+		**out = **in
+	}
+	if in.ReadTimeoutSeconds != nil {
+		in, out := &in.ReadTimeoutSeconds, &out.ReadTimeoutSeconds
+		*out = new(int)
+		**out = **in
+	}
+	if in.WriteTimeoutSeconds != nil {
+		in, out := &in.WriteTimeoutSeconds, &out.WriteTimeoutSeconds
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlConnection.
+func (in *MysqlConnection) DeepCopy() *MysqlConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlDelayedReplica) DeepCopyInto(out *MysqlDelayedReplica) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = new(int32)
+		**out = **in
+	}
+	if in.DelaySeconds != nil {
+		in, out := &in.DelaySeconds, &out.DelaySeconds
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlDelayedReplica.
+func (in *MysqlDelayedReplica) DeepCopy() *MysqlDelayedReplica {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlDelayedReplica)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlDrift) DeepCopyInto(out *MysqlDrift) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlDrift.
+func (in *MysqlDrift) DeepCopy() *MysqlDrift {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlDrift)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlForceMembersStatus) DeepCopyInto(out *MysqlForceMembersStatus) {
+	*out = *in
+	if in.Members != nil {
+		in, out := &in.Members, &out.Members
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Unreachable != nil {
+		in, out := &in.Unreachable, &out.Unreachable
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlForceMembersStatus.
+func (in *MysqlForceMembersStatus) DeepCopy() *MysqlForceMembersStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlForceMembersStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MysqlHost) DeepCopyInto(out *MysqlHost) {
 	*out = *in
@@ -320,6 +538,66 @@ func (in *MysqlList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlMGROptions) DeepCopyInto(out *MysqlMGROptions) {
+	*out = *in
+	if in.FlowControlMode != nil {
+		in, out := &in.FlowControlMode, &out.FlowControlMode
+		*out = new(string)
+		**out = **in
+	}
+	if in.FlowControlApplierThreshold != nil {
+		in, out := &in.FlowControlApplierThreshold, &out.FlowControlApplierThreshold
+		*out = new(int)
+		**out = **in
+	}
+	if in.FlowControlCertifierThreshold != nil {
+		in, out := &in.FlowControlCertifierThreshold, &out.FlowControlCertifierThreshold
+		*out = new(int)
+		**out = **in
+	}
+	if in.Consistency != nil {
+		in, out := &in.Consistency, &out.Consistency
+		*out = new(string)
+		**out = **in
+	}
+	if in.MemberWeight != nil {
+		in, out := &in.MemberWeight, &out.MemberWeight
+		*out = new(int)
+		**out = **in
+	}
+	if in.ExpelTimeout != nil {
+		in, out := &in.ExpelTimeout, &out.ExpelTimeout
+		*out = new(int)
+		**out = **in
+	}
+	if in.AutoRejoinTries != nil {
+		in, out := &in.AutoRejoinTries, &out.AutoRejoinTries
+		*out = new(int)
+		**out = **in
+	}
+	if in.UnreachableMajorityTimeout != nil {
+		in, out := &in.UnreachableMajorityTimeout, &out.UnreachableMajorityTimeout
+		*out = new(int)
+		**out = **in
+	}
+	if in.RecoveryRetryCount != nil {
+		in, out := &in.RecoveryRetryCount, &out.RecoveryRetryCount
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlMGROptions.
+func (in *MysqlMGROptions) DeepCopy() *MysqlMGROptions {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlMGROptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MysqlMGRSinglePrimaryOptions) DeepCopyInto(out *MysqlMGRSinglePrimaryOptions) {
 	*out = *in
@@ -335,6 +613,27 @@ func (in *MysqlMGRSinglePrimaryOptions) DeepCopy() *MysqlMGRSinglePrimaryOptions
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlMemberStatus) DeepCopyInto(out *MysqlMemberStatus) {
+	*out = *in
+	if in.SecondsBehindSource != nil {
+		in, out := &in.SecondsBehindSource, &out.SecondsBehindSource
+		*out = new(int64)
+		**out = **in
+	}
+	in.LastProbeTime.DeepCopyInto(&out.LastProbeTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlMemberStatus.
+func (in *MysqlMemberStatus) DeepCopy() *MysqlMemberStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlMemberStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MysqlMonitor) DeepCopyInto(out *MysqlMonitor) {
 	*out = *in
@@ -371,6 +670,57 @@ func (in *MysqlMonitor) DeepCopy() *MysqlMonitor {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlReplicaOf) DeepCopyInto(out *MysqlReplicaOf) {
+	*out = *in
+	if in.CRD != nil {
+		in, out := &in.CRD, &out.CRD
+		*out = new(CRDMysql)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Remote != nil {
+		in, out := &in.Remote, &out.Remote
+		*out = make([]MysqlHost, len(*in))
+		copy(*out, *in)
+	}
+	if in.User != nil {
+		in, out := &in.User, &out.User
+		*out = new(MysqlSimpleUserInfo)
+		**out = **in
+	}
+	if in.ServerIDOffset != nil {
+		in, out := &in.ServerIDOffset, &out.ServerIDOffset
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlReplicaOf.
+func (in *MysqlReplicaOf) DeepCopy() *MysqlReplicaOf {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlReplicaOf)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlReplicaOfStatus) DeepCopyInto(out *MysqlReplicaOfStatus) {
+	*out = *in
+	in.PromoteTime.DeepCopyInto(&out.PromoteTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlReplicaOfStatus.
+func (in *MysqlReplicaOfStatus) DeepCopy() *MysqlReplicaOfStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlReplicaOfStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MysqlSemiSyncOptions) DeepCopyInto(out *MysqlSemiSyncOptions) {
 	*out = *in
@@ -425,6 +775,11 @@ func (in *MysqlSpec) DeepCopyInto(out *MysqlSpec) {
 		*out = new(MysqlMGRSinglePrimaryOptions)
 		**out = **in
 	}
+	if in.MGR != nil {
+		in, out := &in.MGR, &out.MGR
+		*out = new(MysqlMGROptions)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.SemiSync != nil {
 		in, out := &in.SemiSync, &out.SemiSync
 		*out = new(MysqlSemiSyncOptions)
@@ -442,7 +797,7 @@ func (in *MysqlSpec) DeepCopyInto(out *MysqlSpec) {
 	}
 	if in.ClusterUser != nil {
 		in, out := &in.ClusterUser, &out.ClusterUser
-		*out = new(MysqlUser)
+		*out = new(MysqlClusterUser)
 		(*in).DeepCopyInto(*out)
 	}
 	if in.MaxConn != nil {
@@ -450,6 +805,31 @@ func (in *MysqlSpec) DeepCopyInto(out *MysqlSpec) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.Primary != nil {
+		in, out := &in.Primary, &out.Primary
+		*out = new(string)
+		**out = **in
+	}
+	if in.Clone != nil {
+		in, out := &in.Clone, &out.Clone
+		*out = new(MysqlClone)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicaOf != nil {
+		in, out := &in.ReplicaOf, &out.ReplicaOf
+		*out = new(MysqlReplicaOf)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.DelayedReplica != nil {
+		in, out := &in.DelayedReplica, &out.DelayedReplica
+		*out = new(MysqlDelayedReplica)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Connection != nil {
+		in, out := &in.Connection, &out.Connection
+		*out = new(MysqlConnection)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlSpec.
@@ -480,6 +860,60 @@ func (in *MysqlStatus) DeepCopyInto(out *MysqlStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Bootstrap != nil {
+		in, out := &in.Bootstrap, &out.Bootstrap
+		*out = new(MysqlBootstrapStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ForceMembers != nil {
+		in, out := &in.ForceMembers, &out.ForceMembers
+		*out = new(MysqlForceMembersStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Switchover != nil {
+		in, out := &in.Switchover, &out.Switchover
+		*out = new(MysqlSwitchoverStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(MysqlUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = new(MysqlConfigStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.VolumeExpansion != nil {
+		in, out := &in.VolumeExpansion, &out.VolumeExpansion
+		*out = new(VolumeExpansionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Drifts != nil {
+		in, out := &in.Drifts, &out.Drifts
+		*out = make([]MysqlDrift, len(*in))
+		copy(*out, *in)
+	}
+	if in.MemberStatuses != nil {
+		in, out := &in.MemberStatuses, &out.MemberStatuses
+		*out = make([]MysqlMemberStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReplicaOf != nil {
+		in, out := &in.ReplicaOf, &out.ReplicaOf
+		*out = new(MysqlReplicaOfStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlStatus.
@@ -493,14 +927,51 @@ func (in *MysqlStatus) DeepCopy() *MysqlStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MysqlUser) DeepCopyInto(out *MysqlUser) {
+func (in *MysqlSwitchoverStatus) DeepCopyInto(out *MysqlSwitchoverStatus) {
 	*out = *in
-	out.MysqlSimpleUserInfo = in.MysqlSimpleUserInfo
-	if in.Privileges != nil {
-		in, out := &in.Privileges, &out.Privileges
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlSwitchoverStatus.
+func (in *MysqlSwitchoverStatus) DeepCopy() *MysqlSwitchoverStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlSwitchoverStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlUpgradeStatus) DeepCopyInto(out *MysqlUpgradeStatus) {
+	*out = *in
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	in.CompletionTime.DeepCopyInto(&out.CompletionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUpgradeStatus.
+func (in *MysqlUpgradeStatus) DeepCopy() *MysqlUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlUser) DeepCopyInto(out *MysqlUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUser.
@@ -513,6 +984,170 @@ func (in *MysqlUser) DeepCopy() *MysqlUser {
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MysqlUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlUserGrant) DeepCopyInto(out *MysqlUserGrant) {
+	*out = *in
+	if in.Privileges != nil {
+		in, out := &in.Privileges, &out.Privileges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUserGrant.
+func (in *MysqlUserGrant) DeepCopy() *MysqlUserGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlUserGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlUserList) DeepCopyInto(out *MysqlUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]MysqlUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUserList.
+func (in *MysqlUserList) DeepCopy() *MysqlUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *MysqlUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlUserProxySQL) DeepCopyInto(out *MysqlUserProxySQL) {
+	*out = *in
+	in.CRReference.DeepCopyInto(&out.CRReference)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUserProxySQL.
+func (in *MysqlUserProxySQL) DeepCopy() *MysqlUserProxySQL {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlUserProxySQL)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlUserResourceLimits) DeepCopyInto(out *MysqlUserResourceLimits) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUserResourceLimits.
+func (in *MysqlUserResourceLimits) DeepCopy() *MysqlUserResourceLimits {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlUserResourceLimits)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlUserSpec) DeepCopyInto(out *MysqlUserSpec) {
+	*out = *in
+	in.Mysql.DeepCopyInto(&out.Mysql)
+	in.PasswordSecret.DeepCopyInto(&out.PasswordSecret)
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]MysqlUserGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ResourceLimits != nil {
+		in, out := &in.ResourceLimits, &out.ResourceLimits
+		*out = new(MysqlUserResourceLimits)
+		**out = **in
+	}
+	if in.ProxySQL != nil {
+		in, out := &in.ProxySQL, &out.ProxySQL
+		*out = new(MysqlUserProxySQL)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUserSpec.
+func (in *MysqlUserSpec) DeepCopy() *MysqlUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MysqlUserStatus) DeepCopyInto(out *MysqlUserStatus) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Grants != nil {
+		in, out := &in.Grants, &out.Grants
+		*out = make([]MysqlUserGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MysqlUserStatus.
+func (in *MysqlUserStatus) DeepCopy() *MysqlUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MysqlUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PodSettings) DeepCopyInto(out *PodSettings) {
 	*out = *in
@@ -594,7 +1229,7 @@ func (in *ProxySQL) DeepCopyInto(out *ProxySQL) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxySQL.
@@ -720,6 +1355,11 @@ func (in *ProxySQLSpec) DeepCopyInto(out *ProxySQLSpec) {
 	}
 	out.ClusterUser = in.ClusterUser
 	out.MonitorUser = in.MonitorUser
+	if in.MaxWriters != nil {
+		in, out := &in.MaxWriters, &out.MaxWriters
+		*out = new(int)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxySQLSpec.
@@ -735,6 +1375,18 @@ func (in *ProxySQLSpec) DeepCopy() *ProxySQLSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxySQLStatus) DeepCopyInto(out *ProxySQLStatus) {
 	*out = *in
+	if in.VolumeExpansion != nil {
+		in, out := &in.VolumeExpansion, &out.VolumeExpansion
+		*out = new(VolumeExpansionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxySQLStatus.
@@ -975,6 +1627,18 @@ func (in *RedisStatus) DeepCopyInto(out *RedisStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.VolumeExpansion != nil {
+		in, out := &in.VolumeExpansion, &out.VolumeExpansion
+		*out = new(VolumeExpansionStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RedisStatus.
@@ -1002,6 +1666,31 @@ func (in *S3Config) DeepCopy() *S3Config {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VolumeExpansionStatus) DeepCopyInto(out *VolumeExpansionStatus) {
+	*out = *in
+	if in.Pending != nil {
+		in, out := &in.Pending, &out.Pending
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StartTime.DeepCopyInto(&out.StartTime)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VolumeExpansionStatus.
+func (in *VolumeExpansionStatus) DeepCopy() *VolumeExpansionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VolumeExpansionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Webhook) DeepCopyInto(out *Webhook) {
 	*out = *in
@@ -41,6 +41,7 @@ type ApisV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	MysqlsGetter
 	MysqlBackupsGetter
+	MysqlUsersGetter
 	ProxySQLsGetter
 	RedisesGetter
 }
@@ -58,6 +59,10 @@ func (c *ApisV1alpha1Client) MysqlBackups(namespace string) MysqlBackupInterface
 	return newMysqlBackups(c, namespace)
 }
 
+func (c *ApisV1alpha1Client) MysqlUsers(namespace string) MysqlUserInterface {
+	return newMysqlUsers(c, namespace)
+}
+
 func (c *ApisV1alpha1Client) ProxySQLs(namespace string) ProxySQLInterface {
 	return newProxySQLs(c, namespace)
 }
@@ -47,6 +47,10 @@ func (c *FakeApisV1alpha1) MysqlBackups(namespace string) v1alpha1.MysqlBackupIn
 	return &FakeMysqlBackups{c, namespace}
 }
 
+func (c *FakeApisV1alpha1) MysqlUsers(namespace string) v1alpha1.MysqlUserInterface {
+	return &FakeMysqlUsers{c, namespace}
+}
+
 func (c *FakeApisV1alpha1) ProxySQLs(namespace string) v1alpha1.ProxySQLInterface {
 	return &FakeProxySQLs{c, namespace}
 }
@@ -0,0 +1,153 @@
+/*
+MIT License
+
+Copyright (c) 2021 Software Authors
+
+Software Authors are:
+    Xing Yu, email: yuxing951@gmail.com,yuxing951@hotmail.com
+    Yi Zhou, email: 6098550@qq.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+
+	v1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeMysqlUsers implements MysqlUserInterface
+type FakeMysqlUsers struct {
+	Fake *FakeApisV1alpha1
+	ns   string
+}
+
+var mysqlusersResource = schema.GroupVersionResource{Group: "apis", Version: "v1alpha1", Resource: "mysqlusers"}
+
+var mysqlusersKind = schema.GroupVersionKind{Group: "apis", Version: "v1alpha1", Kind: "MysqlUser"}
+
+// Get takes name of the mysqlUser, and returns the corresponding mysqlUser object, and an error if there is any.
+func (c *FakeMysqlUsers) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha1.MysqlUser, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewGetAction(mysqlusersResource, c.ns, name), &v1alpha1.MysqlUser{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MysqlUser), err
+}
+
+// List takes label and field selectors, and returns the list of MysqlUsers that match those selectors.
+func (c *FakeMysqlUsers) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha1.MysqlUserList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewListAction(mysqlusersResource, mysqlusersKind, c.ns, opts), &v1alpha1.MysqlUserList{})
+
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha1.MysqlUserList{ListMeta: obj.(*v1alpha1.MysqlUserList).ListMeta}
+	for _, item := range obj.(*v1alpha1.MysqlUserList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested mysqlUsers.
+func (c *FakeMysqlUsers) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewWatchAction(mysqlusersResource, c.ns, opts))
+
+}
+
+// Create takes the representation of a mysqlUser and creates it.  Returns the server's representation of the mysqlUser, and an error, if there is any.
+func (c *FakeMysqlUsers) Create(ctx context.Context, mysqlUser *v1alpha1.MysqlUser, opts v1.CreateOptions) (result *v1alpha1.MysqlUser, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewCreateAction(mysqlusersResource, c.ns, mysqlUser), &v1alpha1.MysqlUser{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MysqlUser), err
+}
+
+// Update takes the representation of a mysqlUser and updates it. Returns the server's representation of the mysqlUser, and an error, if there is any.
+func (c *FakeMysqlUsers) Update(ctx context.Context, mysqlUser *v1alpha1.MysqlUser, opts v1.UpdateOptions) (result *v1alpha1.MysqlUser, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateAction(mysqlusersResource, c.ns, mysqlUser), &v1alpha1.MysqlUser{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MysqlUser), err
+}
+
+// UpdateStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating UpdateStatus().
+func (c *FakeMysqlUsers) UpdateStatus(ctx context.Context, mysqlUser *v1alpha1.MysqlUser, opts v1.UpdateOptions) (*v1alpha1.MysqlUser, error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewUpdateSubresourceAction(mysqlusersResource, "status", c.ns, mysqlUser), &v1alpha1.MysqlUser{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MysqlUser), err
+}
+
+// Delete takes name of the mysqlUser and deletes it. Returns an error if one occurs.
+func (c *FakeMysqlUsers) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewDeleteActionWithOptions(mysqlusersResource, c.ns, name, opts), &v1alpha1.MysqlUser{})
+
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeMysqlUsers) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewDeleteCollectionAction(mysqlusersResource, c.ns, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha1.MysqlUserList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched mysqlUser.
+func (c *FakeMysqlUsers) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha1.MysqlUser, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewPatchSubresourceAction(mysqlusersResource, c.ns, name, pt, data, subresources...), &v1alpha1.MysqlUser{})
+
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha1.MysqlUser), err
+}
@@ -33,6 +33,8 @@ type MysqlExpansion interface{}
 
 type MysqlBackupExpansion interface{}
 
+type MysqlUserExpansion interface{}
+
 type ProxySQLExpansion interface{}
 
 type RedisExpansion interface{}
@@ -18,6 +18,7 @@ import (
 	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
 	mysqlcontrollers "github.com/hakur/rds-operator/controllers/mysql"
 	mysqlbackups "github.com/hakur/rds-operator/controllers/mysql_backup"
+	mysqlusers "github.com/hakur/rds-operator/controllers/mysql_user"
 	proxysqlcontrollers "github.com/hakur/rds-operator/controllers/proxysql"
 	rediscontrollers "github.com/hakur/rds-operator/controllers/redis"
 	"github.com/hakur/rds-operator/util"
@@ -36,7 +37,7 @@ var (
 	enableLeaderElection = kingpin.Flag("leader-elect", "is enable multi operators leader election ，only one operator pod work if enabled leader election").Default("false").Bool()
 	namespaceFilter      = kingpin.Flag("namespace", "namespace for crd watching,watch all namespaces if value is empty").Default(util.EnvOrDefault("NAMESPACE", "")).String()
 	logLevel             = kingpin.Flag("log-level", "log level this application").Default(util.EnvOrDefault("LOG_LEVEL", "info")).String()
-	runController        = kingpin.Flag("run-controller", "run specific operator controller").Default("all").Enum("all", "mysql", "mysqlBackup", "proxysql", "redis")
+	runController        = kingpin.Flag("run-controller", "run specific operator controller").Default("all").Enum("all", "mysql", "mysqlBackup", "mysqlUser", "proxysql", "redis")
 )
 
 func init() {
@@ -49,7 +50,7 @@ func init() {
 	customFormatter.TimestampFormat = "2006-01-02 15:04:05"
 	parsedLevel, err := logrus.ParseLevel(*logLevel)
 	if err != nil {
-		logrus.Fatal("log level=[%s] is invalid", logLevel)
+		logrus.Fatalf("log level=[%s] is invalid", *logLevel)
 	}
 
 	logrus.SetFormatter(customFormatter)
@@ -81,8 +82,9 @@ func main() {
 
 	if *runController == "all" || *runController == "redis" {
 		if err = (&rediscontrollers.RedisReconciler{
-			Client: mgr.GetClient(),
-			Scheme: mgr.GetScheme(),
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("redis-controller"),
 		}).SetupWithManager(mgr); err != nil {
 			logrus.WithField("err", err.Error()).WithField("controller", "Redis").Fatal("could not set up redis.rds.hakurei.cn controller with manager")
 		}
@@ -90,8 +92,9 @@ func main() {
 
 	if *runController == "all" || *runController == "mysql" {
 		if err = (&mysqlcontrollers.MysqlReconciler{
-			Client: mgr.GetClient(),
-			Scheme: mgr.GetScheme(),
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("mysql-controller"),
 		}).SetupWithManager(mgr); err != nil {
 			logrus.WithField("err", err.Error()).WithField("controller", "Mysql").Fatal("could not set up mysqls.rds.hakurei.cn controller with manager")
 		}
@@ -102,6 +105,7 @@ func main() {
 			Client:     mgr.GetClient(),
 			Scheme:     mgr.GetScheme(),
 			RestConfig: mgr.GetConfig(),
+			Recorder:   mgr.GetEventRecorderFor("proxysql-controller"),
 		}).SetupWithManager(mgr); err != nil {
 			logrus.WithField("err", err.Error()).WithField("controller", "ProxySQL").Fatal("could not set up proxysqls.rds.hakurei.cn controller with manager")
 		}
@@ -112,11 +116,22 @@ func main() {
 			Client:     mgr.GetClient(),
 			Scheme:     mgr.GetScheme(),
 			RestConfig: mgr.GetConfig(),
+			Recorder:   mgr.GetEventRecorderFor("mysqlbackup-controller"),
 		}).SetupWithManager(mgr); err != nil {
 			logrus.WithField("err", err.Error()).WithField("controller", " MysqlBackup").Fatal("could not set up mysqlbackups.rds.hakurei.cn controller with manager")
 		}
 	}
 
+	if *runController == "all" || *runController == "mysqlUser" {
+		if err = (&mysqlusers.MysqlUserReconciler{
+			Client:   mgr.GetClient(),
+			Scheme:   mgr.GetScheme(),
+			Recorder: mgr.GetEventRecorderFor("mysqluser-controller"),
+		}).SetupWithManager(mgr); err != nil {
+			logrus.WithField("err", err.Error()).WithField("controller", "MysqlUser").Fatal("could not set up mysqlusers.rds.hakurei.cn controller with manager")
+		}
+	}
+
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
@@ -141,10 +156,14 @@ func main() {
 //+kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;delete
 //+kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;delete
 
+//+kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups=storage.k8s.io,resources=storageclasses,verbs=get;list;watch
+
 //+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;update;delete
 //+kubebuilder:rbac:groups="",resources=services,verbs=get;list;watch;create;update;delete
 
-//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;delete;post
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;create;update;delete;post;patch
 //+kubebuilder:rbac:groups="",resources=pods/logs,verbs=get;post;create;list
 //+kubebuilder:rbac:groups="",resources=pods/exec,verbs=get;post;create
 
@@ -29,13 +29,20 @@ func (t *MysqlCommand) Register() {
 
 	mysqlCmd := kingpin.Command("mysql", "mysql tools")
 
-	mysqlCmd.Flag("cluster-mode", "mysql cluster mode").Default(util.EnvOrDefault("MYSQL_CLUSTER_MODE", "MGRSP")).EnumVar(&t.GlobalVar.Mode, "MGRSP", "MGRMP", "SemiSync")
+	mysqlCmd.Flag("cluster-mode", "mysql cluster mode").Default(util.EnvOrDefault("MYSQL_CLUSTER_MODE", "MGRSP")).EnumVar(&t.GlobalVar.Mode, "MGRSP", "MGRMP", "SemiSync", "Async")
 	mysqlCmd.Flag("addresses", "mysql address ,(host|ip):port string, use '--addresses=127.0.0.1:3306,127.0.0.1:3307,127.0.0.1:3308' for multiple addresses").Default(util.EnvOrDefault("MYSQL_ADDRESSES", "127.0.0.1:3306,127.0.0.1:3307,127.0.0.1:3308")).StringVar(&t.GlobalVar.Addresses)
 	mysqlCmd.Flag("semi-sync-double-master-ha", "is cluster under semi sync replication mode, and there need two master node join each other").Default(util.EnvOrDefault("SEMI_SYNC_DOUBLE_MASTER_HA", "false")).BoolVar(&t.GlobalVar.SemiSyncDoubleMasterHA)
-	mysqlCmd.Flag("version", "mysql server version").Default(util.EnvOrDefault("MYSQL_VERSION", "5.7.34")).StringVar(&t.GlobalVar.MysqlVersion)
+	mysqlCmd.Flag("version", "mysql server version").Default(util.EnvOrDefault("MYSQL_VERSION", mysql.DefaultVersion)).StringVar(&t.GlobalVar.MysqlVersion)
 
 	(&MysqlBackupCommand{GlobalVar: t.GlobalVar}).Register(mysqlCmd.Command("backup", "mysql backup"))
 	(&MysqlConfigCommand{GlobalVar: t.GlobalVar}).Register(mysqlCmd.Command("cfg", "generate mysql config"))
+	(&MysqlCloneServerCommand{GlobalVar: t.GlobalVar}).Register(mysqlCmd.Command("clone-server", "stream xtrabackup physical copy of local mysql to new members, only for mysql 5.7"))
+	(&MysqlCloneReceiveCommand{GlobalVar: t.GlobalVar}).Register(mysqlCmd.Command("clone-receive", "copy data from donor clone server when datadir is empty, only for mysql 5.7"))
+}
+
+// Dialect mysql server version specific sql statements and config variables of MysqlVersion
+func (t *MysqlGlobalFlagValues) Dialect() (*mysql.Dialect, error) {
+	return mysql.NewDialect(t.MysqlVersion)
 }
 
 // AddressesToDSN convert host/ip:port to dsn list
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"compress/zlib"
 	"context"
+	"errors"
+	"io"
 	"os/exec"
 	"strconv"
+	"strings"
 	"time"
 
 	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
@@ -84,11 +88,22 @@ func (t *MysqlBackupCommand) Action(ctx *kingpin.ParseContext) (err error) {
 	execCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
 	defer cancel()
 
+	dialect, err := t.GlobalVar.Dialect()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
 	switch rdsv1alpha1.ClusterMode(t.GlobalVar.Mode) {
 	case rdsv1alpha1.ModeMGRSP:
-		clusterManager = &mysql.MGRSP{DataSrouces: dataSources}
+		clusterManager = &mysql.MGRSP{DataSrouces: dataSources, Dialect: dialect}
+	case rdsv1alpha1.ModeMGRMP:
+		clusterManager = &mysql.MGRMP{DataSrouces: dataSources, Dialect: dialect}
 	case rdsv1alpha1.ModeSemiSync:
-		clusterManager = &mysql.SemiSync{DataSrouces: dataSources, DoubleMasterHA: t.GlobalVar.SemiSyncDoubleMasterHA}
+		clusterManager = &mysql.Replication{DataSrouces: dataSources, SemiSync: true, DoubleMasterHA: t.GlobalVar.SemiSyncDoubleMasterHA, Dialect: dialect}
+	case rdsv1alpha1.ModeAsync:
+		clusterManager = &mysql.Replication{DataSrouces: dataSources, Dialect: dialect}
+	default:
+		logrus.Fatalf("mysql cluster mode=[%s] is not supported", t.GlobalVar.Mode)
 	}
 
 	masters, err := clusterManager.FindMaster(execCtx)
@@ -97,6 +112,59 @@ func (t *MysqlBackupCommand) Action(ctx *kingpin.ParseContext) (err error) {
 	}
 
 	master := masters[0]
+
+	var cmd *exec.Cmd
+	if dialect.IsMysql8() { // mysqlpump is deprecated since 8.0.34
+		cmd, err = t.mysqldumpCommand(execCtx, master)
+	} else {
+		cmd = t.mysqlpumpCommand(master)
+	}
+
+	if err != nil {
+		logrus.WithField("err", err.Error()).Fatal("backup failed")
+	}
+
+	cmd.Env = append(cmd.Env, "MYSQL_PWD="+t.Password)
+
+	if t.DumpCmd {
+		logrus.Info("exec command:", cmd.String())
+	}
+
+	pipe, _ := cmd.StdoutPipe()
+
+	if err = cmd.Start(); err != nil {
+		logrus.WithField("err", err.Error()).Fatal("backup failed")
+	}
+
+	var backupContent io.Reader = pipe
+	if t.Zlib && dialect.IsMysql8() { // mysqldump has no compress output option
+		backupContent = zlibCompress(pipe)
+	}
+
+	// upload to s3 server
+	minioClient, err := minio.New(t.S3.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(t.S3.AccessKey, t.S3.SecretAccessKey, ""),
+		Secure: t.S3.SSL,
+	})
+
+	if err != nil {
+		logrus.Fatal(err)
+	}
+
+	logrus.Info("uploading ", t.S3.Path+"/"+backupFileName, " to s3 server ...")
+	if _, err = minioClient.PutObject(execCtx, t.S3.Bucket, t.S3.Path+"/"+backupFileName, backupContent, -1, minio.PutObjectOptions{}); err != nil {
+		logrus.WithField("err", err.Error()).Fatal("backup failed")
+	}
+	if err = cmd.Wait(); err != nil {
+		logrus.WithField("err", err.Error()).Fatal("backup failed")
+	}
+	logrus.Info("upload ", t.S3.Path+"/"+backupFileName, " to s3 server success")
+
+	return err
+}
+
+// mysqlpumpCommand generate mysqlpump backup command, mysql 5.7 use mysqlpump
+func (t *MysqlBackupCommand) mysqlpumpCommand(master *mysql.DSN) *exec.Cmd {
 	// list none system databases, only none system databases is needed to backup
 	// mysql system databases are [ mysql information_schema performance_schema sys ]
 
@@ -106,7 +174,7 @@ func (t *MysqlBackupCommand) Action(ctx *kingpin.ParseContext) (err error) {
 		"-u" + master.Username,
 		"--add-drop-database",
 		"--default-character-set=" + t.Charset,
-		"--exclude-databases=mysql,sys,information_schema,performance_schema",
+		"--exclude-databases=" + strings.Join(mysqlSystemDatabases, ","),
 		"--skip-watch-progress",
 		"--set-gtid-purged=OFF",
 	}
@@ -128,37 +196,91 @@ func (t *MysqlBackupCommand) Action(ctx *kingpin.ParseContext) (err error) {
 		cmdArgs = append(cmdArgs, "--skip-dump-rows")
 	}
 
-	cmd := exec.Command("mysqlpump", cmdArgs...)
-	cmd.Env = append(cmd.Env, "MYSQL_PWD="+t.Password)
+	return exec.Command("mysqlpump", cmdArgs...)
+}
 
-	if t.DumpCmd {
-		logrus.Info("exec command:", cmd.String())
+// mysqldumpCommand generate mysqldump backup command, mysql 8.0 use mysqldump.
+// mysqldump can not exclude databases, so none system databases are listed from master
+func (t *MysqlBackupCommand) mysqldumpCommand(ctx context.Context, master *mysql.DSN) (cmd *exec.Cmd, err error) {
+	databases, err := listUserDatabases(ctx, master)
+	if err != nil {
+		return nil, err
 	}
 
-	pipe, _ := cmd.StdoutPipe()
+	if len(databases) < 1 {
+		return nil, errors.New("none system database not found")
+	}
 
-	if err = cmd.Start(); err != nil {
-		logrus.WithField("err", err.Error()).Fatal("backup failed")
+	var cmdArgs = []string{
+		"-h" + master.Host,
+		"-P" + strconv.Itoa(master.Port),
+		"-u" + master.Username,
+		"--add-drop-database",
+		"--default-character-set=" + t.Charset,
+		"--set-gtid-purged=OFF",
+		"--routines",
+		"--events",
+		"--triggers",
 	}
 
-	// upload to s3 server
-	minioClient, err := minio.New(t.S3.Endpoint, &minio.Options{
-		Creds:  credentials.NewStaticV4(t.S3.AccessKey, t.S3.SecretAccessKey, ""),
-		Secure: t.S3.SSL,
-	})
+	if t.LockTable {
+		cmdArgs = append(cmdArgs, "--lock-tables")
+	} else {
+		cmdArgs = append(cmdArgs, "--single-transaction")
+	}
+
+	if t.StructureOnly {
+		cmdArgs = append(cmdArgs, "--no-data")
+	}
 
+	cmdArgs = append(cmdArgs, "--databases")
+	cmdArgs = append(cmdArgs, databases...)
+
+	return exec.Command("mysqldump", cmdArgs...), nil
+}
+
+// mysqlSystemDatabases mysql system databases, they are not needed to backup
+var mysqlSystemDatabases = []string{"mysql", "sys", "information_schema", "performance_schema"}
+
+// listUserDatabases list none system databases of mysql server
+func listUserDatabases(ctx context.Context, dsn *mysql.DSN) (databases []string, err error) {
+	dbConn, err := mysql.NewDBFromDSN(dsn)
 	if err != nil {
-		logrus.Fatal(err)
+		return nil, err
 	}
+	defer dbConn.Close()
 
-	logrus.Info("uploading ", t.S3.Path+"/"+backupFileName, " to s3 server ...")
-	if _, err = minioClient.PutObject(execCtx, t.S3.Bucket, t.S3.Path+"/"+backupFileName, pipe, -1, minio.PutObjectOptions{}); err != nil {
-		logrus.WithField("err", err.Error()).Fatal("backup failed")
+	rows, err := dbConn.QueryContext(ctx, "SHOW DATABASES")
+	if err != nil {
+		return nil, err
 	}
-	if err = cmd.Wait(); err != nil {
-		logrus.WithField("err", err.Error()).Fatal("backup failed")
+	defer rows.Close()
+
+	for rows.Next() {
+		var database string
+		if err = rows.Scan(&database); err != nil {
+			return nil, err
+		}
+
+		if !util.InArray(mysqlSystemDatabases, database) {
+			databases = append(databases, database)
+		}
 	}
-	logrus.Info("upload ", t.S3.Path+"/"+backupFileName, " to s3 server success")
 
-	return err
+	return databases, rows.Err()
+}
+
+// zlibCompress compress content as plain zlib stream, it is not the framed format of mysqlpump --compress-output=zlib, zlib_decompress can not read it.
+// restore it with a zlib inflater, such as: openssl zlib -d < backup.sql.zlib | mysql or pigz -dz < backup.sql.zlib | mysql
+func zlibCompress(r io.Reader) io.Reader {
+	pr, pw := io.Pipe()
+	go func() {
+		zw := zlib.NewWriter(pw)
+		if _, err := io.Copy(zw, r); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		pw.CloseWithError(zw.Close())
+	}()
+	return pr
 }
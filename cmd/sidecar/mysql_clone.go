@@ -0,0 +1,337 @@
+package main
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hakur/rds-operator/pkg/mysql"
+	"github.com/hakur/rds-operator/util"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+// cloneInitSQLFile sql file executed by mysqld init_file after data is copied from donor, it set gtid_purged from xtrabackup_binlog_info
+const cloneInitSQLFile = "clone_init.sql"
+
+// MysqlCloneServerCommand stream physical copy of local mysql datadir with xtrabackup to new members, only for mysql 5.7.
+// stream has password hashes of mysql.user, it is only served to receiver which sends clone token of cluster secret, mutual tls is used when TLSDir is set.
+// mysql 8.0.17+ use clone plugin instead
+type MysqlCloneServerCommand struct {
+	GlobalVar *MysqlGlobalFlagValues
+	// Port http server listen port
+	Port int
+	// DataDir mysql datadir
+	DataDir string
+	// Username mysql user for xtrabackup
+	Username string
+	// Password mysql password for xtrabackup
+	Password string
+	// Token shared secret which receiver must send, every request is refused when it is empty
+	Token string
+	// TLSDir directory of ca.crt, tls.crt and tls.key, stream is served with mutual tls when it is set
+	TLSDir string
+}
+
+func (t *MysqlCloneServerCommand) Register(cmd *kingpin.CmdClause) {
+	cmd.Action(t.Action)
+	cmd.Flag("port", "xtrabackup stream server listen port, env MYSQL_CLONE_PORT").Default(util.EnvOrDefault("MYSQL_CLONE_PORT", "3307")).IntVar(&t.Port)
+	cmd.Flag("data-dir", "mysql datadir, env MYSQL_DATA_DIR").Default(util.EnvOrDefault("MYSQL_DATA_DIR", "/var/lib/mysql")).StringVar(&t.DataDir)
+	cmd.Flag("username", "mysql username used for xtrabackup").Default("root").StringVar(&t.Username)
+	cmd.Flag("password", "mysql password used for xtrabackup, env MYSQL_ROOT_PASSWORD").Default(util.EnvOrDefault("MYSQL_ROOT_PASSWORD", "")).StringVar(&t.Password)
+	cmd.Flag("token", "shared secret which receiver must send, env MYSQL_CLONE_TOKEN").Default(util.EnvOrDefault("MYSQL_CLONE_TOKEN", "")).StringVar(&t.Token)
+	cmd.Flag("tls-dir", "directory of ca.crt, tls.crt and tls.key, stream is served with mutual tls when it is set, env MYSQL_CLONE_TLS_DIR").Default(util.EnvOrDefault("MYSQL_CLONE_TLS_DIR", "")).StringVar(&t.TLSDir)
+}
+
+func (t *MysqlCloneServerCommand) Action(ctx *kingpin.ParseContext) (err error) {
+	// clone init sql is truncated even if stream can not be served
+	go t.finishClone()
+
+	if t.Token == "" {
+		logrus.Warn("mysql clone token is not set, every clone request is refused")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/xbstream", t.serveXbstream)
+	server := &http.Server{Addr: ":" + strconv.Itoa(t.Port), Handler: mux}
+	if t.TLSDir == "" {
+		logrus.Warn("mysql clone server listen on port ", t.Port, " without tls, set spec.connection tls mode to encrypt clone stream")
+		return server.ListenAndServe()
+	}
+
+	if server.TLSConfig, err = cloneTLSConfig(t.TLSDir, true); err != nil {
+		return err
+	}
+	logrus.Info("mysql clone server listen on port ", t.Port, " with tls")
+	return server.ListenAndServeTLS("", "")
+}
+
+// serveXbstream write xtrabackup xbstream of local mysql to response body, request without valid clone token is refused
+func (t *MysqlCloneServerCommand) serveXbstream(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if t.Token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(t.Token)) != 1 {
+		logrus.WithField("client", r.RemoteAddr).Warn("mysql clone stream refused, clone token is not valid")
+		http.Error(w, "clone token is not valid", http.StatusForbidden)
+		return
+	}
+
+	cmd := exec.CommandContext(r.Context(), "xtrabackup",
+		"--backup",
+		"--stream=xbstream",
+		"--host=127.0.0.1",
+		"--port=3306",
+		"--user="+t.Username,
+		"--datadir="+t.DataDir,
+		"--target-dir=/tmp",
+	)
+	cmd.Env = append(os.Environ(), "MYSQL_PWD="+t.Password)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+
+	logrus.WithField("client", r.RemoteAddr).Info("mysql clone stream started")
+	if err := cmd.Run(); err != nil {
+		logrus.WithFields(map[string]interface{}{"err": err.Error(), "client": r.RemoteAddr}).Warn("mysql clone stream failed")
+		return
+	}
+	logrus.WithField("client", r.RemoteAddr).Info("mysql clone stream done")
+}
+
+// finishClone clone init sql must be executed only once, truncate it after mysqld is started
+func (t *MysqlCloneServerCommand) finishClone() {
+	initSQLFile := filepath.Join(t.DataDir, cloneInitSQLFile)
+	dsn := &mysql.DSN{Host: "127.0.0.1", Port: 3306, Username: t.Username, Password: t.Password, DBName: "mysql"}
+	for {
+		if info, err := os.Stat(initSQLFile); err == nil && info.Size() > 0 {
+			if dbConn, err := mysql.NewDBFromDSN(dsn); err == nil {
+				if err = dbConn.Ping(); err == nil {
+					if err = os.Truncate(initSQLFile, 0); err != nil {
+						logrus.WithField("err", err.Error()).Warn("mysql truncate clone init sql failed")
+					}
+				}
+				dbConn.Close()
+			}
+		}
+		time.Sleep(time.Second)
+	}
+}
+
+// MysqlCloneReceiveCommand copy data from donor clone server when local datadir is empty, only for mysql 5.7
+type MysqlCloneReceiveCommand struct {
+	GlobalVar *MysqlGlobalFlagValues
+	// Port donor clone server port
+	Port int
+	// DataDir mysql datadir
+	DataDir string
+	// Owner uid:gid of mysql user in mysql image
+	Owner string
+	// Token shared secret sent to donor clone server
+	Token string
+	// TLSDir directory of ca.crt, tls.crt and tls.key, donor is connected with mutual tls when it is set
+	TLSDir string
+}
+
+func (t *MysqlCloneReceiveCommand) Register(cmd *kingpin.CmdClause) {
+	cmd.Action(t.Action)
+	cmd.Flag("port", "donor xtrabackup stream server port, env MYSQL_CLONE_PORT").Default(util.EnvOrDefault("MYSQL_CLONE_PORT", "3307")).IntVar(&t.Port)
+	cmd.Flag("data-dir", "mysql datadir, env MYSQL_DATA_DIR").Default(util.EnvOrDefault("MYSQL_DATA_DIR", "/var/lib/mysql")).StringVar(&t.DataDir)
+	cmd.Flag("owner", "uid:gid of mysql user in mysql image").Default("999:999").StringVar(&t.Owner)
+	cmd.Flag("token", "shared secret sent to donor clone server, env MYSQL_CLONE_TOKEN").Default(util.EnvOrDefault("MYSQL_CLONE_TOKEN", "")).StringVar(&t.Token)
+	cmd.Flag("tls-dir", "directory of ca.crt, tls.crt and tls.key, donor is connected with mutual tls when it is set, env MYSQL_CLONE_TLS_DIR").Default(util.EnvOrDefault("MYSQL_CLONE_TLS_DIR", "")).StringVar(&t.TLSDir)
+}
+
+func (t *MysqlCloneReceiveCommand) Action(ctx *kingpin.ParseContext) (err error) {
+	if _, err = os.Stat(filepath.Join(t.DataDir, "mysql")); err == nil {
+		logrus.Info("mysql datadir is already initialized, skip clone")
+		return t.removeFinishedInitSQL()
+	}
+
+	hostname, _ := os.Hostname()
+	for _, donor := range AddressesToDSN(t.GlobalVar.Addresses) {
+		if donor.Host == hostname {
+			continue
+		}
+
+		if err = t.receive(donor.Host); err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "donor": donor.Host}).Warn("mysql clone from donor failed")
+			t.cleanDataDir()
+			continue
+		}
+
+		logrus.WithField("donor", donor.Host).Info("mysql clone from donor done")
+		return t.removeFinishedInitSQL()
+	}
+
+	// cluster first boot or no donor is reachable, datadir will be initialized by mysql image entrypoint
+	logrus.Info("mysql clone donor not found, skip clone")
+	return nil
+}
+
+// receive extract xbstream of donor to datadir, then prepare it
+func (t *MysqlCloneReceiveCommand) receive(donor string) (err error) {
+	scheme, httpClient := "http", http.DefaultClient
+	if t.TLSDir != "" {
+		cfg, err := cloneTLSConfig(t.TLSDir, false)
+		if err != nil {
+			return err
+		}
+		scheme, httpClient = "https", &http.Client{Transport: &http.Transport{TLSClientConfig: cfg}}
+	}
+
+	req, err := http.NewRequest(http.MethodGet, scheme+"://"+donor+":"+strconv.Itoa(t.Port)+"/xbstream", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.Token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("donor response status %s", resp.Status)
+	}
+
+	extract := exec.Command("xbstream", "-x", "-C", t.DataDir)
+	extract.Stdin = resp.Body
+	extract.Stderr = os.Stderr
+	if err = extract.Run(); err != nil {
+		return fmt.Errorf("xbstream extract err -> %s", err.Error())
+	}
+
+	prepare := exec.Command("xtrabackup", "--prepare", "--target-dir="+t.DataDir)
+	prepare.Stdout = os.Stdout
+	prepare.Stderr = os.Stderr
+	if err = prepare.Run(); err != nil {
+		return fmt.Errorf("xtrabackup prepare err -> %s", err.Error())
+	}
+
+	// server_uuid must be generated by this member
+	os.Remove(filepath.Join(t.DataDir, "auto.cnf"))
+
+	gtidPurged, err := readXtrabackupGTID(filepath.Join(t.DataDir, "xtrabackup_binlog_info"))
+	if err != nil {
+		return err
+	}
+
+	initSQL := "RESET MASTER;\nSET GLOBAL gtid_purged=" + mysql.QuoteString(gtidPurged) + ";\n"
+	if err = os.WriteFile(filepath.Join(t.DataDir, cloneInitSQLFile), []byte(initSQL), 0644); err != nil {
+		return err
+	}
+
+	return exec.Command("chown", "-R", t.Owner, t.DataDir).Run()
+}
+
+// removeFinishedInitSQL remove executed clone init sql, so mysql config will not load it again
+func (t *MysqlCloneReceiveCommand) removeFinishedInitSQL() error {
+	initSQLFile := filepath.Join(t.DataDir, cloneInitSQLFile)
+	if info, err := os.Stat(initSQLFile); err == nil && info.Size() == 0 {
+		return os.Remove(initSQLFile)
+	}
+	return nil
+}
+
+// cleanDataDir remove partially copied data, lost+found of volume is kept
+func (t *MysqlCloneReceiveCommand) cleanDataDir() {
+	entries, _ := os.ReadDir(t.DataDir)
+	for _, entry := range entries {
+		if entry.Name() != "lost+found" {
+			os.RemoveAll(filepath.Join(t.DataDir, entry.Name()))
+		}
+	}
+}
+
+// readXtrabackupGTID read gtid set from xtrabackup_binlog_info, content is binlog file, position and gtid set splited by tab.
+// gtid set of multiple source uuid is splited by comma and new line
+func readXtrabackupGTID(file string) (gtidSet string, err error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.SplitN(string(content), "\t", 3)
+	if len(fields) < 3 {
+		return "", fmt.Errorf("xtrabackup_binlog_info has no gtid set")
+	}
+
+	set, err := mysql.ParseGTIDSet(fields[2])
+	if err != nil {
+		return "", err
+	}
+	return set.String(), nil
+}
+
+// cloneTLSConfig mutual tls config of clone stream from ca.crt, tls.crt and tls.key in dir. every member has same certificate of spec.connection.tlsSecret,
+// so certificate chain of peer is verified against ca.crt and host name is not checked
+func cloneTLSConfig(dir string, server bool) (cfg *tls.Config, err error) {
+	caPEM, err := os.ReadFile(filepath.Join(dir, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("ca.crt in %s is invalid", dir)
+	}
+
+	cert, err := tls.LoadX509KeyPair(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+	if err != nil {
+		return nil, err
+	}
+
+	cfg = &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyClonePeer(pool, rawCerts)
+		},
+	}
+	if server {
+		cfg.ClientAuth = tls.RequireAnyClientCert
+	} else {
+		cfg.InsecureSkipVerify = true // chain is verified by VerifyPeerCertificate, host name of donor is not in certificate
+	}
+	return cfg, nil
+}
+
+// verifyClonePeer verify certificate chain of clone stream peer against ca pool, key usage is not checked because certificate is used by both sides
+func verifyClonePeer(pool *x509.CertPool, rawCerts [][]byte) (err error) {
+	if len(rawCerts) == 0 {
+		return errors.New("peer certificate is not sent")
+	}
+
+	var certs []*x509.Certificate
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return err
+		}
+		certs = append(certs, cert)
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = certs[0].Verify(x509.VerifyOptions{Roots: pool, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	return err
+}
+
+// cloneInitFile mysqld init_file of data copied from donor, empty if there is no pending clone init sql
+func cloneInitFile(dataDir string) string {
+	initSQLFile := filepath.Join(dataDir, cloneInitSQLFile)
+	if info, err := os.Stat(initSQLFile); err == nil && info.Size() > 0 {
+		return initSQLFile
+	}
+	return ""
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -52,18 +53,27 @@ type MysqlConfigCommand struct {
 	ConfigFile string
 	// Whitelist mysql server whitelist
 	Whitelist []string
+	// MGROptions group replication variables formatted as name=value list splited by comma, only for MGRSP and MGRMP cluster mode
+	MGROptions string
+	// dialect mysql server version specific config variables
+	dialect *mysql.Dialect
 }
 
 func (t *MysqlConfigCommand) Register(cmd *kingpin.CmdClause) {
 	cmd.Action(t.Action)
 	cmd.Flag("config-file", "mysqld config file path").Default(util.EnvOrDefault("MYSQL_CFG_EXTRA_DIR", "/etc/my.cnf.d") + "/my.cnf").StringVar(&t.ConfigFile)
 	cmd.Flag("white-list", "mysql server white list").Default(util.EnvOrDefault("MYSQL_CFG_WHITE_LIST", "10.0.0.0/8,192.0.0.0/8")).StringsVar(&t.Whitelist)
+	cmd.Flag("mgr-options", "group replication variables, such as group_replication_member_weight=60,group_replication_consistency=BEFORE").Default(util.EnvOrDefault("MYSQL_CFG_MGR_OPTIONS", "")).StringVar(&t.MGROptions)
 	cmd.Flag("dump", "output generated mysqld config on stdout, to enable in format --dump without any argument").Default(util.EnvOrDefault("MYSQL_CFG_DUMP", "false")).BoolVar(&t.Dump)
 }
 
 func (t *MysqlConfigCommand) Action(ctx *kingpin.ParseContext) (err error) {
 	var mysqlConfigContent string
 
+	if t.dialect, err = t.GlobalVar.Dialect(); err != nil {
+		return err
+	}
+
 	switch t.GlobalVar.Mode {
 	case string(rdsv1alpha1.ModeMGRSP):
 		mysqlConfigContent, err = t.mgrspConfig()
@@ -71,6 +81,8 @@ func (t *MysqlConfigCommand) Action(ctx *kingpin.ParseContext) (err error) {
 		mysqlConfigContent, err = t.mgrmpConfig()
 	case string(rdsv1alpha1.ModeSemiSync):
 		mysqlConfigContent, err = t.semiSyncConfig()
+	case string(rdsv1alpha1.ModeAsync):
+		mysqlConfigContent, err = t.asyncConfig()
 	}
 
 	if t.Dump {
@@ -93,9 +105,9 @@ func (t *MysqlConfigCommand) mgrspConfig() (fileContent string, err error) {
 	}
 	seeds = strings.Trim(seeds, ",")
 
-	writer := mysql.NewConfigParser()
-	if err = writer.Parse(strings.NewReader(BasicConf)); err != nil {
-		return fileContent, fmt.Errorf("parse base mysql conf failed, err -> %s", err.Error())
+	writer, err := t.basicConfig()
+	if err != nil {
+		return fileContent, err
 	}
 	mysqld := mysql.NewConfigSection("mysqld")
 
@@ -109,14 +121,17 @@ func (t *MysqlConfigCommand) mgrspConfig() (fileContent string, err error) {
 	mysqld.Set("loose-group_replication_single_primary_mode", "on")
 	mysqld.Set("loose-group_replication_enforce_update_everywhere_checks", "off")
 
-	mysqld.Set("loose-group_replication_recovery_retry_count", "100")
+	t.setMGROptions(mysqld)
 	mysqld.Set("loose-group_replication_group_seeds", seeds)
-	mysqld.Set("loose_group_replication_ip_whitelist", strings.Join(t.Whitelist, ","))
+	mysqld.Set("loose_"+t.dialect.GroupReplicationAllowlistVariable(), strings.Join(t.Whitelist, ","))
+	if t.dialect.IsMysql8() { // distributed recovery user use caching_sha2_password
+		mysqld.Set("loose-group_replication_recovery_get_public_key", "ON")
+	}
 
 	mysqld.Set("loose_group_replication_local_address", os.Getenv("HOSTNAME")+":33061")
 
 	mysqld.Set("server-id", strconv.Itoa(getMysqlServerID()))
-	mysqld.Set("log_slave_updates", "ON")
+	mysqld.Set(t.dialect.ReplicaVariable("log_slave_updates"), "ON")
 
 	writer.MergeSection(mysqld)
 	// merge extra config
@@ -134,9 +149,9 @@ func (t *MysqlConfigCommand) mgrmpConfig() (fileContent string, err error) {
 	}
 	seeds = strings.Trim(seeds, ",")
 
-	writer := mysql.NewConfigParser()
-	if err = writer.Parse(strings.NewReader(BasicConf)); err != nil {
-		return fileContent, fmt.Errorf("parse base mysql conf failed, err -> %s", err.Error())
+	writer, err := t.basicConfig()
+	if err != nil {
+		return fileContent, err
 	}
 	mysqld := mysql.NewConfigSection("mysqld")
 
@@ -150,14 +165,17 @@ func (t *MysqlConfigCommand) mgrmpConfig() (fileContent string, err error) {
 	mysqld.Set("loose-group_replication_single_primary_mode", "off")
 	mysqld.Set("loose-group_replication_enforce_update_everywhere_checks", "on")
 
-	mysqld.Set("loose-group_replication_recovery_retry_count", "100")
+	t.setMGROptions(mysqld)
 	mysqld.Set("loose-group_replication_group_seeds", seeds)
-	mysqld.Set("loose_group_replication_ip_whitelist", strings.Join(t.Whitelist, ","))
+	mysqld.Set("loose_"+t.dialect.GroupReplicationAllowlistVariable(), strings.Join(t.Whitelist, ","))
+	if t.dialect.IsMysql8() { // distributed recovery user use caching_sha2_password
+		mysqld.Set("loose-group_replication_recovery_get_public_key", "ON")
+	}
 
 	mysqld.Set("loose_group_replication_local_address", os.Getenv("HOSTNAME")+":33061")
 
 	mysqld.Set("server-id", strconv.Itoa(getMysqlServerID()))
-	mysqld.Set("log_slave_updates", "ON")
+	mysqld.Set(t.dialect.ReplicaVariable("log_slave_updates"), "ON")
 
 	writer.MergeSection(mysqld)
 	// merge extra config
@@ -168,18 +186,23 @@ func (t *MysqlConfigCommand) mgrmpConfig() (fileContent string, err error) {
 	return fileContent, nil
 }
 
+// semiSyncConfig every member starts as read only, operator turns off read only of master after it is booted,
+// old master come back after failover is never a writer of proxysql
 func (t *MysqlConfigCommand) semiSyncConfig() (fileContent string, err error) {
-	writer := mysql.NewConfigParser()
-	if err = writer.Parse(strings.NewReader(BasicConf)); err != nil {
-		return fileContent, fmt.Errorf("parse base mysql conf failed, err -> %s", err.Error())
+	writer, err := t.basicConfig()
+	if err != nil {
+		return fileContent, err
 	}
 	mysqld := mysql.NewConfigSection("mysqld")
-	mysqld.Set("plugin_load_add", "semisync_master.so;semisync_slave.so")
-	mysqld.Set("master_info_repository", "TABLE")
-	mysqld.Set("rpl_semi_sync_master_wait_point", "AFTER_SYNC")
-	mysqld.Set("log-slave-updates", "ON")
-	mysqld.Set("slave-parallel-type", "LOGICAL_CLOCK")
-	mysqld.Set("slave_parallel_workers", "16")
+	mysqld.Set("read_only", "ON")
+	mysqld.Set("plugin_load_add", t.dialect.SemiSyncPlugins())
+	if !t.dialect.AtLeast(8, 0, 23) { // master_info_repository is deprecated and TABLE is the only choice since 8.0.23
+		mysqld.Set("master_info_repository", "TABLE")
+	}
+	mysqld.Set(t.dialect.SemiSyncSourceWaitPointVariable(), "AFTER_SYNC")
+	mysqld.Set(t.dialect.ReplicaVariable("log-slave-updates"), "ON")
+	mysqld.Set(t.dialect.ReplicaVariable("slave-parallel-type"), "LOGICAL_CLOCK")
+	mysqld.Set(t.dialect.ReplicaVariable("slave_parallel_workers"), "16")
 	mysqld.Set("server-id", strconv.Itoa(getMysqlServerID()))
 	if t.GlobalVar.SemiSyncDoubleMasterHA { // avoid auto increment id conflict
 		mysqld.Set("auto_increment_offset ", strconv.Itoa(getMysqlServerID()))
@@ -195,11 +218,85 @@ func (t *MysqlConfigCommand) semiSyncConfig() (fileContent string, err error) {
 	return fileContent, nil
 }
 
+// asyncConfig every member starts as read only, operator turns off read only of master after it is elected
+func (t *MysqlConfigCommand) asyncConfig() (fileContent string, err error) {
+	writer, err := t.basicConfig()
+	if err != nil {
+		return fileContent, err
+	}
+	mysqld := mysql.NewConfigSection("mysqld")
+	mysqld.Set("read_only", "ON")
+	mysqld.Set(t.dialect.ReplicaVariable("log-slave-updates"), "ON")
+	mysqld.Set(t.dialect.ReplicaVariable("slave-parallel-type"), "LOGICAL_CLOCK")
+	mysqld.Set(t.dialect.ReplicaVariable("slave_parallel_workers"), "16")
+	mysqld.Set(t.dialect.ReplicaVariable("slave_preserve_commit_order"), "ON")
+	mysqld.Set("server-id", strconv.Itoa(getMysqlServerID()))
+
+	writer.MergeSection(mysqld)
+	// merge extra config
+	if err := writer.ParseFile("/etc/my.cnf.d/extra_config"); err != nil && !os.IsNotExist(err) {
+		return fileContent, err
+	}
+	fileContent = writer.String()
+	return fileContent, nil
+}
+
+// setMGROptions write group replication variables with loose- prefix, recovery retry count is 100 if it is not set
+func (t *MysqlConfigCommand) setMGROptions(mysqld *mysql.ConfigSection) {
+	options := mysql.ParseMGROptions(t.MGROptions)
+	if _, ok := options["group_replication_recovery_retry_count"]; !ok {
+		options["group_replication_recovery_retry_count"] = "100"
+	}
+
+	// sorted names keep generated config stable
+	options = options.Supported(t.dialect)
+	var names []string
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		mysqld.Set("loose-"+name, options[name])
+	}
+}
+
+// basicConfig parse BasicConf and rename or remove variables deprecated by mysql server version
+func (t *MysqlConfigCommand) basicConfig() (writer *mysql.ConfigParser, err error) {
+	writer = mysql.NewConfigParser()
+	if err = writer.Parse(strings.NewReader(BasicConf)); err != nil {
+		return nil, fmt.Errorf("parse base mysql conf failed, err -> %s", err.Error())
+	}
+
+	mysqld, err := writer.GetSection("mysqld")
+	if err != nil {
+		return nil, fmt.Errorf("parse base mysql conf failed, err -> %s", err.Error())
+	}
+
+	if t.dialect.AtLeast(8, 0, 23) { // TABLE is the only choice since 8.0.23
+		mysqld.Delete("master_info_repository")
+		mysqld.Delete("relay_log_info_repository")
+	}
+
+	if value, err := mysqld.Get("slave_skip_errors"); err == nil {
+		mysqld.Delete("slave_skip_errors")
+		mysqld.Set(t.dialect.ReplicaVariable("slave_skip_errors"), value)
+	}
+
+	// data copied from donor by clone-receive, gtid_purged must be set before member joins cluster
+	if initFile := cloneInitFile(util.EnvOrDefault("MYSQL_DATA_DIR", "/var/lib/mysql")); initFile != "" {
+		mysqld.Set("init_file", initFile)
+	}
+
+	return writer, nil
+}
+
+// getMysqlServerID server-id is pod ordinal + 1, plus env MYSQL_SERVER_ID_OFFSET of disaster recovery standby cluster
 func getMysqlServerID() int {
 	hostname := os.Getenv("HOSTNAME")
 	arr := strings.Split(hostname, "-")
 	idStr := arr[len(arr)-1]
 	id, _ := strconv.Atoi(idStr)
-	id += 1
+	offset, _ := strconv.Atoi(os.Getenv("MYSQL_SERVER_ID_OFFSET"))
+	id += 1 + offset
 	return id
 }
@@ -18,7 +18,7 @@ func (t *ProxySQLCommand) Register() {
 	t.GlobalVar = new(ProxySQLCommandFlagValues)
 
 	cmd := kingpin.Command("proxysql", "proxysql tools")
-	cmd.Flag("cluster-mode", "mysql cluster mode").Default(util.EnvOrDefault("MYSQL_CLUSTER_MODE", "MGRSP")).EnumVar(&t.GlobalVar.Mode, "MGRSP", "MGRMP", "SemiSync")
+	cmd.Flag("cluster-mode", "mysql cluster mode").Default(util.EnvOrDefault("MYSQL_CLUSTER_MODE", "MGRSP")).EnumVar(&t.GlobalVar.Mode, "MGRSP", "MGRMP", "SemiSync", "Async")
 
 	(&ProxySQLConfigCommand{GlobalVar: t.GlobalVar}).Register(cmd.Command("cfg", "proxysql config generator"))
 }
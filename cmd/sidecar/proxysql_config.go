@@ -108,6 +108,8 @@ func (t *ProxySQLConfigCommand) Action(ctx *kingpin.ParseContext) (err error) {
 		t.mgrmpConfig(&cfg)
 	case string(rdsv1alpha1.ModeSemiSync):
 		t.semiSyncConfig(&cfg)
+	case string(rdsv1alpha1.ModeAsync):
+		t.asyncConfig(&cfg)
 	}
 
 	content := cfg.String()
@@ -152,3 +154,12 @@ func (t *ProxySQLConfigCommand) semiSyncConfig(cfg *mysql.ProxySQLConfWriter) {
 		"check_type":       "read_only",
 	})
 }
+
+// asyncConfig proxysql moves mysql server which read_only is OFF to writer hostgroup, async master is the only member with read_only OFF
+func (t *ProxySQLConfigCommand) asyncConfig(cfg *mysql.ProxySQLConfWriter) {
+	cfg.MysqlReplicationHostgroups = append(cfg.MysqlReplicationHostgroups, map[string]string{
+		"writer_hostgroup": strconv.Itoa(types.ProxySQLWriterGroup),
+		"reader_hostgroup": strconv.Itoa(types.ProxySQLReaderGroup),
+		"check_type":       "read_only",
+	})
+}
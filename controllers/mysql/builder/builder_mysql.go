@@ -3,16 +3,60 @@ package builder
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	"github.com/hakur/rds-operator/pkg/mysql"
 	"github.com/hakur/rds-operator/pkg/reconciler"
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/hakur/rds-operator/util"
 	"github.com/jinzhu/copier"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
+// MysqlDelayedReplicaLabelName pod label of delayed replicas, selected by delayed replica service
+const MysqlDelayedReplicaLabelName = "delayed-replica"
+
+// MysqlRoleLabelName pod label of current member role, selected by primary and replicas services, it is maintained by status check
+const MysqlRoleLabelName = "role.mysql.hakurei.cn"
+
+const (
+	// MysqlRolePrimary role label value of masters
+	MysqlRolePrimary = "primary"
+	// MysqlRoleReplica role label value of healthy replicas which serve reads, delayed replicas and members under maintenance are excluded
+	MysqlRoleReplica = "replica"
+)
+
+// MysqlRestartConfigAnnotationName pod template annotation of status.config.restartHash, pods are restarted when static variables of spec.extraConfig changed
+const MysqlRestartConfigAnnotationName = "restart-config.mysql.hakurei.cn"
+
+// mysqlCloneTLSDir mount path of spec.connection.tlsSecret in clone containers, xtrabackup stream is served with mutual tls when it is mounted
+const mysqlCloneTLSDir = "/etc/mysql-clone-tls"
+
+// mysqlUpgradeScript run mysql_upgrade after server started when data is not upgraded to MYSQL_VERSION, pod is not ready until it is done.
+// datadir initialized by this container is not upgraded, MYSQL_VERSION is recorded in it. super_read_only set on replicas is lifted during upgrade and restored after it.
+// every step is bounded by timeout and failure never fails hook, otherwise container is killed and replicas restart forever
+var mysqlUpgradeScript = `
+export MYSQL_PWD="${MYSQL_ROOT_PASSWORD}"
+initialized=0
+[ -d ${MYSQL_DATA_DIR}/mysql ] || initialized=1
+grep -qs "^${MYSQL_VERSION}" ${MYSQL_DATA_DIR}/mysql_upgrade_info && exit 0
+for i in $(seq 1 150); do mysqladmin ping -h127.0.0.1 -uroot --silent && break; sleep 2; done
+mysqladmin ping -h127.0.0.1 -uroot --silent || exit 0
+if [ ${initialized} = 1 ]; then echo "${MYSQL_VERSION}" > ${MYSQL_DATA_DIR}/mysql_upgrade_info; exit 0; fi
+super_read_only=$(mysql -h127.0.0.1 -uroot -N -e "SELECT @@GLOBAL.super_read_only") || exit 0
+mysql -h127.0.0.1 -uroot -e "SET GLOBAL super_read_only=0" || exit 0
+timeout 600 mysql_upgrade -h127.0.0.1 -uroot || echo "mysql_upgrade failed" >&2
+mysql -h127.0.0.1 -uroot -e "SET GLOBAL super_read_only=${super_read_only}" || true
+exit 0
+`
+
 type MysqlBuilder struct {
 	CR *rdsv1alpha1.Mysql
 }
@@ -136,6 +180,11 @@ func (t *MysqlBuilder) buildMysqlContainer(cr *rdsv1alpha1.Mysql) (container cor
 	container.LivenessProbe = cr.Spec.LivenessProbe
 	container.ReadinessProbe = cr.Spec.ReadinessProbe
 
+	// mysql 8.0.16+ upgrades data dictionary and system tables when server starts
+	if dialect, _ := mysql.NewDialect(cr.Spec.Version); !dialect.AtLeast(8, 0, 16) {
+		container.Lifecycle = &corev1.Lifecycle{PostStart: &corev1.LifecycleHandler{Exec: &corev1.ExecAction{Command: []string{"/bin/bash", "-c", mysqlUpgradeScript}}}}
+	}
+
 	return container
 }
 
@@ -153,6 +202,145 @@ func (t *MysqlBuilder) buildMysqlInitContainer(cr *rdsv1alpha1.Mysql) (container
 	return container
 }
 
+// UseXtrabackupClone mysql 5.7 has no clone plugin, new member copy data from donor sidecar with xtrabackup stream
+func UseXtrabackupClone(cr *rdsv1alpha1.Mysql) bool {
+	if cr.Spec.Clone == nil {
+		return false
+	}
+	dialect, _ := mysql.NewDialect(cr.Spec.Version)
+	return !dialect.SupportClonePlugin()
+}
+
+// buildMysqlCloneReceiveContainer generate init container which copy data from donor when datadir is empty
+func (t *MysqlBuilder) buildMysqlCloneReceiveContainer(cr *rdsv1alpha1.Mysql) (container corev1.Container) {
+	container = t.buildMysqlInitContainer(cr)
+	container.Name = "clone"
+	container.Command = []string{"sidecar", "mysql", "clone-receive"}
+	addCloneTLS(cr, &container)
+	return container
+}
+
+// buildMysqlCloneServerContainer generate sidecar container which stream xtrabackup physical copy to new members
+func (t *MysqlBuilder) buildMysqlCloneServerContainer(cr *rdsv1alpha1.Mysql) (container corev1.Container) {
+	container = t.buildMysqlInitContainer(cr)
+	container.Name = "clone-server"
+	container.Command = []string{"sidecar", "mysql", "clone-server"}
+	container.Resources = cr.Spec.Clone.Resources
+	container.Ports = []corev1.ContainerPort{{Name: "clone", ContainerPort: int32(GetClonePort(cr))}}
+	addCloneTLS(cr, &container)
+	return container
+}
+
+// useCloneTLS xtrabackup stream is encrypted when connections of operator use tls with certificates of spec.connection.tlsSecret
+func useCloneTLS(cr *rdsv1alpha1.Mysql) bool {
+	return cr.Spec.Connection != nil && cr.Spec.Connection.TLSMode == "true" && cr.Spec.Connection.TLSSecret != nil
+}
+
+// addCloneTLS mount spec.connection.tlsSecret to clone container when clone stream uses tls
+func addCloneTLS(cr *rdsv1alpha1.Mysql, container *corev1.Container) {
+	if !useCloneTLS(cr) {
+		return
+	}
+	container.Env = append(container.Env, corev1.EnvVar{Name: "MYSQL_CLONE_TLS_DIR", Value: mysqlCloneTLSDir})
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{Name: "clone-tls", MountPath: mysqlCloneTLSDir, ReadOnly: true})
+}
+
+// GetClonePort sidecar xtrabackup stream server port, default is 3307
+func GetClonePort(cr *rdsv1alpha1.Mysql) int {
+	if cr.Spec.Clone != nil && cr.Spec.Clone.Port != nil {
+		return *cr.Spec.Clone.Port
+	}
+	return 3307
+}
+
+// GetDelayedReplicaHosts pod names of delayed replicas, they are members of highest ordinals which are not masters.
+// first boot masters mysql-0, and mysql-1 of semi sync DoubleMasterHA, are never delayed
+func GetDelayedReplicaHosts(cr *rdsv1alpha1.Mysql) (hosts []string) {
+	if cr.Spec.DelayedReplica == nil || cr.Spec.Replicas == nil {
+		return nil
+	}
+
+	members := 1
+	if cr.Spec.DelayedReplica.Members != nil {
+		members = int(*cr.Spec.DelayedReplica.Members)
+	}
+
+	minOrdinal := 1
+	if cr.Spec.SemiSync != nil && cr.Spec.SemiSync.DoubleMasterHA {
+		minOrdinal = 2
+	}
+
+	for i := int(*cr.Spec.Replicas) - 1; i >= minOrdinal && len(hosts) < members; i-- {
+		host := cr.Name + "-mysql-" + strconv.Itoa(i)
+		if !util.InArray(cr.Status.Masters, host) {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// GetMaintenanceHosts pod names of members under maintenance by annotation
+func GetMaintenanceHosts(cr *rdsv1alpha1.Mysql) (hosts []string) {
+	for _, host := range strings.Split(cr.Annotations[types.MysqlMaintenanceAnnotationName], ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// GetReplicaDelaySeconds MASTER_DELAY of delayed replicas, default is 3600
+func GetReplicaDelaySeconds(cr *rdsv1alpha1.Mysql) int {
+	if cr.Spec.DelayedReplica != nil && cr.Spec.DelayedReplica.DelaySeconds != nil {
+		return *cr.Spec.DelayedReplica.DelaySeconds
+	}
+	return 3600
+}
+
+// GetMGROptions group replication variables of spec.mgr, deprecated spec.mgrsp fields are used when spec.mgr fields are nil.
+// recovery retry count is 100 by default, other nil fields keep mysql default
+func GetMGROptions(cr *rdsv1alpha1.Mysql) (options mysql.MGROptions) {
+	options = mysql.MGROptions{"group_replication_recovery_retry_count": "100"}
+	if cr.Spec.MGRSP != nil {
+		if cr.Spec.MGRSP.ApplierThreshold > 0 {
+			options["group_replication_flow_control_applier_threshold"] = strconv.Itoa(cr.Spec.MGRSP.ApplierThreshold)
+		}
+		if cr.Spec.MGRSP.MGRRetries > 0 {
+			options["group_replication_recovery_retry_count"] = strconv.Itoa(cr.Spec.MGRSP.MGRRetries)
+		}
+	}
+
+	mgr := cr.Spec.MGR
+	if mgr == nil {
+		return options
+	}
+
+	for name, value := range map[string]*string{
+		"group_replication_flow_control_mode": mgr.FlowControlMode,
+		"group_replication_consistency":       mgr.Consistency,
+	} {
+		if value != nil {
+			options[name] = strings.ToUpper(*value)
+		}
+	}
+
+	for name, value := range map[string]*int{
+		"group_replication_flow_control_applier_threshold":   mgr.FlowControlApplierThreshold,
+		"group_replication_flow_control_certifier_threshold": mgr.FlowControlCertifierThreshold,
+		"group_replication_member_weight":                    mgr.MemberWeight,
+		"group_replication_member_expel_timeout":             mgr.ExpelTimeout,
+		"group_replication_autorejoin_tries":                 mgr.AutoRejoinTries,
+		"group_replication_unreachable_majority_timeout":     mgr.UnreachableMajorityTimeout,
+		"group_replication_recovery_retry_count":             mgr.RecoveryRetryCount,
+	} {
+		if value != nil {
+			options[name] = strconv.Itoa(*value)
+		}
+	}
+
+	return options
+}
+
 // BuildSts generate mysql statefulset
 func (t *MysqlBuilder) BuildSts() (sts *appsv1.StatefulSet, err error) {
 	var spec appsv1.StatefulSetSpec
@@ -160,7 +348,7 @@ func (t *MysqlBuilder) BuildSts() (sts *appsv1.StatefulSet, err error) {
 	var mysqlDataVolumeClaim corev1.PersistentVolumeClaim
 	var shareProcessNamespace = true
 
-	if t.CR.Spec.ClusterMode == rdsv1alpha1.ModeSemiSync && t.CR.Spec.SemiSync.DoubleMasterHA && t.CR.Spec.ClusterUser == nil {
+	if t.CR.Spec.ClusterMode == rdsv1alpha1.ModeSemiSync && t.CR.Spec.SemiSync != nil && t.CR.Spec.SemiSync.DoubleMasterHA && t.CR.Spec.ClusterUser == nil {
 		return nil, fmt.Errorf("cluster enabled but cluster user not configured. CR resource spec.clusterUser is nil")
 	}
 
@@ -181,20 +369,35 @@ func (t *MysqlBuilder) BuildSts() (sts *appsv1.StatefulSet, err error) {
 	spec.Replicas = t.CR.Spec.Replicas
 	spec.ServiceName = t.CR.Name + "-mysql"
 	spec.Selector = &metav1.LabelSelector{MatchLabels: BuildMysqlLabels(t.CR)}
+	// pods are restarted by operator, replicas first and primary last
+	spec.UpdateStrategy = appsv1.StatefulSetUpdateStrategy{Type: appsv1.OnDeleteStatefulSetStrategyType}
 
 	podTemplateSpec.ObjectMeta = metav1.ObjectMeta{Labels: BuildMysqlLabels(t.CR)}
+	if t.CR.Status.Config != nil && t.CR.Status.Config.RestartHash != "" {
+		podTemplateSpec.ObjectMeta.Annotations = map[string]string{MysqlRestartConfigAnnotationName: t.CR.Status.Config.RestartHash}
+	}
 	podTemplateSpec.Spec.Volumes = t.buildMysqlVolumes(t.CR)
 	podTemplateSpec.Spec.ShareProcessNamespace = &shareProcessNamespace
 	podTemplateSpec.Spec.InitContainers = []corev1.Container{t.buildMysqlInitContainer(t.CR)}
 	podTemplateSpec.Spec.Containers = []corev1.Container{t.buildMysqlContainer(t.CR)}
 	podTemplateSpec.Spec.PriorityClassName = t.CR.Spec.PriorityClassName
-	podTemplateSpec.Spec.Affinity = t.CR.Spec.Affinity
+	podTemplateSpec.Spec.Affinity = reconciler.DefaultAffinity(t.CR.Spec.Affinity, BuildMysqlLabels(t.CR))
+	podTemplateSpec.Spec.TopologySpreadConstraints = reconciler.DefaultTopologySpreadConstraints(BuildMysqlLabels(t.CR))
 	podTemplateSpec.Spec.Tolerations = t.CR.Spec.Tolerations
 
 	if t.CR.Spec.Monitor != nil {
 		podTemplateSpec.Spec.Containers = append(podTemplateSpec.Spec.Containers, buildMysqlExporter(t.CR))
 	}
 
+	if UseXtrabackupClone(t.CR) { // data of new member is copied from donor before config rendered
+		podTemplateSpec.Spec.InitContainers = append([]corev1.Container{t.buildMysqlCloneReceiveContainer(t.CR)}, podTemplateSpec.Spec.InitContainers...)
+		podTemplateSpec.Spec.Containers = append(podTemplateSpec.Spec.Containers, t.buildMysqlCloneServerContainer(t.CR))
+		if useCloneTLS(t.CR) {
+			podTemplateSpec.Spec.Volumes = append(podTemplateSpec.Spec.Volumes, corev1.Volume{Name: "clone-tls",
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: *t.CR.Spec.Connection.TLSSecret}}})
+		}
+	}
+
 	quantity, err := resource.ParseQuantity(t.CR.Spec.StorageSize)
 	if err != nil {
 		return nil, err
@@ -211,6 +414,47 @@ func (t *MysqlBuilder) BuildSts() (sts *appsv1.StatefulSet, err error) {
 	return sts, nil
 }
 
+// BuildPodDisruptionBudget generate pdb of mysql pods, group replication keeps majority of members online, other modes lose one member at most.
+// group replication of 2 members has no quorum tolerance, one member is still evictable, so node drain is not blocked forever
+func (t *MysqlBuilder) BuildPodDisruptionBudget() *policyv1.PodDisruptionBudget {
+	replicas := *t.CR.Spec.Replicas
+	maxUnavailable := int32(1)
+	if t.CR.Spec.ClusterMode == rdsv1alpha1.ModeMGRSP || t.CR.Spec.ClusterMode == rdsv1alpha1.ModeMGRMP {
+		if quorum := reconciler.QuorumMaxUnavailable(replicas); quorum < maxUnavailable {
+			maxUnavailable = quorum
+		}
+	}
+	return reconciler.BuildPodDisruptionBudget(t.CR.Name+"-mysql", t.CR.Namespace, BuildMysqlLabels(t.CR), replicas, maxUnavailable)
+}
+
+// BuildCloneNetworkPolicy generate network policy of mysql pods when xtrabackup clone is used, clone port is only reachable from pods of same CR.
+// a policy isolates selected pods, so mysql, group replication, galera and metrics ports stay reachable from everywhere
+func (t *MysqlBuilder) BuildCloneNetworkPolicy() *networkingv1.NetworkPolicy {
+	clonePort := intstr.FromInt(GetClonePort(t.CR))
+	var ports []networkingv1.NetworkPolicyPort
+	for _, port := range []int{3306, 33061, 9104, 4444, 4567, 4568} {
+		value := intstr.FromInt(port)
+		ports = append(ports, networkingv1.NetworkPolicyPort{Port: &value})
+	}
+
+	policy := new(networkingv1.NetworkPolicy)
+	policy.ObjectMeta = metav1.ObjectMeta{
+		Name:      t.CR.Name + "-mysql-clone",
+		Namespace: t.CR.Namespace,
+		Labels:    BuildMysqlLabels(t.CR),
+	}
+	policy.Spec.PodSelector = metav1.LabelSelector{MatchLabels: BuildMysqlLabels(t.CR)}
+	policy.Spec.PolicyTypes = []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}
+	policy.Spec.Ingress = []networkingv1.NetworkPolicyIngressRule{
+		{
+			Ports: []networkingv1.NetworkPolicyPort{{Port: &clonePort}},
+			From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: BuildMysqlLabels(t.CR)}}},
+		},
+		{Ports: ports},
+	}
+	return policy
+}
+
 // BuildService generate mysql services
 func (t *MysqlBuilder) BuildService(cr *rdsv1alpha1.Mysql) (svc *corev1.Service) {
 	var spec corev1.ServiceSpec
@@ -242,6 +486,46 @@ func (t *MysqlBuilder) BuildService(cr *rdsv1alpha1.Mysql) (svc *corev1.Service)
 	return svc
 }
 
+// BuildDelayedReplicaService generate service of delayed replicas, pods of delayed replicas are labeled by operator
+func (t *MysqlBuilder) BuildDelayedReplicaService(cr *rdsv1alpha1.Mysql) (svc *corev1.Service) {
+	svc = new(corev1.Service)
+
+	svc.ObjectMeta = metav1.ObjectMeta{
+		Name:        cr.Name + "-mysql-delayed",
+		Namespace:   cr.Namespace,
+		Labels:      BuildMysqlLabels(t.CR),
+		Annotations: BuildMysqlAnnotaions(t.CR),
+	}
+
+	svc.Spec.Selector = BuildMysqlLabels(t.CR)
+	svc.Spec.Selector[MysqlDelayedReplicaLabelName] = "true"
+	svc.Spec.Ports = []corev1.ServicePort{
+		{Name: "mysql", Port: 3306},
+	}
+
+	return svc
+}
+
+// BuildRoleService generate service of members with role label value, such as primary service for writes and replicas service for reads
+func (t *MysqlBuilder) BuildRoleService(cr *rdsv1alpha1.Mysql, name string, role string) (svc *corev1.Service) {
+	svc = new(corev1.Service)
+
+	svc.ObjectMeta = metav1.ObjectMeta{
+		Name:        cr.Name + "-mysql-" + name,
+		Namespace:   cr.Namespace,
+		Labels:      BuildMysqlLabels(t.CR),
+		Annotations: BuildMysqlAnnotaions(t.CR),
+	}
+
+	svc.Spec.Selector = BuildMysqlLabels(t.CR)
+	svc.Spec.Selector[MysqlRoleLabelName] = role
+	svc.Spec.Ports = []corev1.ServicePort{
+		{Name: "mysql", Port: 3306},
+	}
+
+	return svc
+}
+
 // BuildContainerServices generate mysql services for each mysql container
 func (t *MysqlBuilder) BuildContainerServices(cr *rdsv1alpha1.Mysql) (services []*corev1.Service) {
 	for i := 0; i < int(*cr.Spec.Replicas); i++ {
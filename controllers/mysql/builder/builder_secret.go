@@ -1,11 +1,14 @@
 package builder
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"strconv"
 	"strings"
 
 	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	"github.com/hakur/rds-operator/pkg/mysql"
 	"github.com/hakur/util"
 	corev1 "k8s.io/api/core/v1"
 )
@@ -129,6 +132,23 @@ CREATE VIEW gr_member_routing_candidate_status AS
 DELIMITER ;
 `
 
+// SetCloneToken set MYSQL_CLONE_TOKEN of secret, it is shared secret of xtrabackup clone stream. token of existing secret is kept, it is generated when empty
+func SetCloneToken(cr *rdsv1alpha1.Mysql, secret *corev1.Secret, token []byte) (err error) {
+	if !UseXtrabackupClone(cr) {
+		return nil
+	}
+
+	if len(token) == 0 {
+		buf := make([]byte, 32)
+		if _, err = rand.Read(buf); err != nil {
+			return err
+		}
+		token = []byte(hex.EncodeToString(buf))
+	}
+	secret.Data["MYSQL_CLONE_TOKEN"] = token
+	return nil
+}
+
 // BuildSecret generate secret environment variables for mysql pods
 func BuildSecret(cr *rdsv1alpha1.Mysql) (secret *corev1.Secret) {
 	var seeds string
@@ -136,6 +156,12 @@ func BuildSecret(cr *rdsv1alpha1.Mysql) (secret *corev1.Secret) {
 	var semiSyncDoubleMaster bool
 	var rootPassword []byte
 	var initSQL string
+	mysqlVersion := cr.Spec.Version
+	if mysqlVersion == "" {
+		mysqlVersion = mysql.DefaultVersion
+	}
+	// invalid version is treated as 5.7, a nil dialect is mysql 5.7
+	dialect, _ := mysql.NewDialect(mysqlVersion)
 	mysqlMaxConn := "300"
 	if cr.Spec.MaxConn != nil {
 		mysqlMaxConn = strconv.Itoa(*cr.Spec.MaxConn)
@@ -178,6 +204,7 @@ func BuildSecret(cr *rdsv1alpha1.Mysql) (secret *corev1.Secret) {
 		"MYSQL_CFG_WHITE_LIST": []byte(strings.Join(cr.Spec.Whitelist, ",")),
 		"MYSQL_ROOT_HOST":      []byte("%"),
 		"MYSQL_ADDRESSES":      []byte(seeds),
+		"MYSQL_VERSION":        []byte(mysqlVersion),
 	}
 
 	if cr.Spec.ClusterMode == rdsv1alpha1.ModeSemiSync {
@@ -185,30 +212,45 @@ func BuildSecret(cr *rdsv1alpha1.Mysql) (secret *corev1.Secret) {
 		secret.Data["SEMI_SYNC_FIXED_MASTERS"] = []byte(semiSyncMasters)
 	} else if cr.Spec.ClusterMode == rdsv1alpha1.ModeMGRSP {
 		initSQL += mgrMonitorView
+		secret.Data["MYSQL_CFG_MGR_OPTIONS"] = []byte(GetMGROptions(cr).Supported(dialect).String())
 	} else if cr.Spec.ClusterMode == rdsv1alpha1.ModeMGRMP {
 		initSQL += mgrMonitorView
+		secret.Data["MYSQL_CFG_MGR_OPTIONS"] = []byte(GetMGROptions(cr).Supported(dialect).String())
 	}
 
 	if cr.Spec.ClusterUser != nil {
 		mysqlPassword := []byte(util.Base64Decode(cr.Spec.ClusterUser.Password))
 		initSQL += fmt.Sprintf(`
 			USE mysql;
-			CREATE USER IF NOT EXISTS %s@'%s' IDENTIFIED WITH mysql_native_password BY '%s';
-			GRANT %s ON %s TO %s@'%s';
+			CREATE USER IF NOT EXISTS %s@%s IDENTIFIED WITH %s BY %s;
+			GRANT %s ON %s TO %s@%s;
 			FLUSH PRIVILEGES;
 		`,
-			cr.Spec.ClusterUser.Username,
-			cr.Spec.ClusterUser.Domain,
-			mysqlPassword,
+			mysql.QuoteString(cr.Spec.ClusterUser.Username),
+			mysql.QuoteString(cr.Spec.ClusterUser.Domain),
+			dialect.AuthPlugin(),
+			mysql.QuoteString(string(mysqlPassword)),
 			strings.Join(cr.Spec.ClusterUser.Privileges, ","),
 			cr.Spec.ClusterUser.DatabaseTarget,
-			cr.Spec.ClusterUser.Username,
-			cr.Spec.ClusterUser.Domain,
+			mysql.QuoteString(cr.Spec.ClusterUser.Username),
+			mysql.QuoteString(cr.Spec.ClusterUser.Domain),
 		)
 	}
 
 	secret.Data["init.sql"] = []byte(initSQL)
 
+	if cr.Spec.Clone != nil {
+		secret.Data["MYSQL_CLONE_PORT"] = []byte(strconv.Itoa(GetClonePort(cr)))
+	}
+
+	if cr.Spec.ReplicaOf != nil { // server-id of standby members must not conflict with source members
+		serverIDOffset := 1000
+		if cr.Spec.ReplicaOf.ServerIDOffset != nil {
+			serverIDOffset = *cr.Spec.ReplicaOf.ServerIDOffset
+		}
+		secret.Data["MYSQL_SERVER_ID_OFFSET"] = []byte(strconv.Itoa(serverIDOffset))
+	}
+
 	if cr.Spec.ExtraConfigDir != nil {
 		secret.Data["MYSQL_CFG_EXTRA_DIR"] = []byte(*cr.Spec.ExtraConfigDir)
 	}
@@ -0,0 +1,163 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	"github.com/hakur/rds-operator/controllers/mysql/builder"
+	"github.com/hakur/rds-operator/pkg/mysql"
+	"github.com/hakur/rds-operator/pkg/reconciler"
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/hakur/rds-operator/util"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkConfig diff mysqld variables of spec.extraConfig with applied variables, dynamic variables are set on running members,
+// read only variables and removed variables are recorded as pending restart, pods are restarted by rolling upgrade for them
+func (t *MysqlReconciler) checkConfig(ctx context.Context, cr *rdsv1alpha1.Mysql) (err error) {
+	desired, err := mysql.ParseMysqldVariables(cr.Spec.ExtraConfig)
+	if err != nil {
+		if cr.Status.Config != nil {
+			cr.Status.Config.Message = "parse extraConfig failed -> " + err.Error()
+		}
+		return nil
+	}
+
+	// pods are started with extraConfig rendered into my.cnf, nothing to apply
+	status := cr.Status.Config
+	if status == nil {
+		cr.Status.Config = &rdsv1alpha1.MysqlConfigStatus{Applied: desired}
+		return nil
+	}
+
+	if len(status.PendingRestart) > 0 {
+		if restarted, err := t.isConfigRestarted(ctx, cr); err != nil {
+			return err
+		} else if restarted {
+			logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "variables": status.PendingRestart}).Info("mysql pods restarted for config change")
+			reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeNormal, "ConfigRestarted", fmt.Sprintf("pods are restarted for variables [%s]", strings.Join(status.PendingRestart, ",")))
+			status.PendingRestart = nil
+		}
+	}
+
+	if cr.Status.Phase != rdsv1alpha1.MysqlPhaseRunning {
+		return nil
+	}
+
+	var names []string
+	for name := range desired {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	applied := make(map[string]string)
+	for name, value := range status.Applied {
+		applied[name] = value
+	}
+
+	dialect, err := mysql.NewDialect(cr.Spec.Version)
+	if err != nil {
+		return err
+	}
+
+	var restarts, dynamics []string
+	status.Message = ""
+	dataSources := GetMysqlDataSources(cr)
+	for _, name := range names {
+		if value, ok := applied[name]; ok && value == desired[name] {
+			continue
+		}
+
+		// variable which is not applied to every member is not recorded as applied, it is applied again by next reconcile.
+		// invalid variable does not restart pods, it is reported until it is fixed in spec.extraConfig
+		restart, err := mysql.ApplyVariable(ctx, dataSources, dialect, name, desired[name])
+		if err != nil {
+			t.setConfigApplyFailed(cr, name, err)
+			continue
+		}
+
+		if restart {
+			restarts = append(restarts, name)
+		} else {
+			dynamics = append(dynamics, name)
+		}
+		applied[name] = desired[name]
+	}
+
+	// removed variable keeps running value until member is restarted with default value, persisted value of mysql 8.0 is removed first
+	for name := range applied {
+		if _, ok := desired[name]; !ok {
+			if err = mysql.ResetVariable(ctx, dataSources, dialect, name); err != nil {
+				t.setConfigApplyFailed(cr, name, err)
+				continue
+			}
+			delete(applied, name)
+			restarts = append(restarts, name)
+		}
+	}
+
+	status.Applied = applied
+	if len(dynamics) > 0 {
+		reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeNormal, "ConfigApplied", fmt.Sprintf("variables [%s] are applied to running members", strings.Join(dynamics, ",")))
+	}
+
+	if len(restarts) > 0 {
+		for _, name := range restarts {
+			if !util.InArray(status.PendingRestart, name) {
+				status.PendingRestart = append(status.PendingRestart, name)
+			}
+		}
+		sort.Strings(status.PendingRestart)
+		status.RestartHash = configHash(cr.Spec.ExtraConfig)
+		logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "variables": restarts}).Info("mysql config change requires pods restart")
+		reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeNormal, "ConfigRestartRequired", fmt.Sprintf("variables [%s] require pods restart", strings.Join(restarts, ",")))
+	}
+
+	return nil
+}
+
+// setConfigApplyFailed record error of variable in config status message and emit warning event, reason is ConfigInvalid for invalid or unknown variable
+func (t *MysqlReconciler) setConfigApplyFailed(cr *rdsv1alpha1.Mysql, name string, err error) {
+	reason := "ConfigApplyFailed"
+	if errors.Is(err, types.ErrMysqlConfigInvalid) {
+		reason = "ConfigInvalid"
+	}
+
+	cr.Status.Config.Message = err.Error()
+	logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "name": name}).Warn(err.Error())
+	reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeWarning, reason, err.Error())
+}
+
+// isConfigRestarted all pods are ready and started with restart hash of config status
+func (t *MysqlReconciler) isConfigRestarted(ctx context.Context, cr *rdsv1alpha1.Mysql) (restarted bool, err error) {
+	var pods corev1.PodList
+	if err = t.List(ctx, &pods, client.InNamespace(cr.Namespace), client.MatchingLabels(builder.BuildMysqlLabels(cr))); err != nil {
+		return false, err
+	}
+
+	if cr.Spec.Replicas == nil || len(pods.Items) != int(*cr.Spec.Replicas) {
+		return false, nil
+	}
+
+	for _, pod := range pods.Items {
+		if !pod.DeletionTimestamp.IsZero() || !isPodReady(&pod) || pod.Annotations[builder.MysqlRestartConfigAnnotationName] != cr.Status.Config.RestartHash {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// configHash hash of extra config, it changes pod template to restart pods
+func configHash(content string) string {
+	h := fnv.New32a()
+	h.Write([]byte(content))
+	return strconv.FormatUint(uint64(h.Sum32()), 16)
+}
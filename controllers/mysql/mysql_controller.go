@@ -4,20 +4,32 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlbuilder "sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
 	"github.com/hakur/rds-operator/controllers/mysql/builder"
+	"github.com/hakur/rds-operator/pkg/mysql"
 	"github.com/hakur/rds-operator/pkg/reconciler"
 	"github.com/hakur/rds-operator/pkg/types"
 	"github.com/hakur/rds-operator/util"
 	monitorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/sirupsen/logrus"
 )
 
 const (
@@ -29,6 +41,8 @@ const (
 type MysqlReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// Recorder emit events of Mysql CR, such as bootstrap, failover, config apply and runtime state drift
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=rds.hakurei.cn,resources=mysqls,verbs=get;list;watch;create;update;patch;delete
@@ -42,45 +56,209 @@ func (t *MysqlReconciler) Reconcile(ctx context.Context, req ctrl.Request) (r ct
 		return r, client.IgnoreNotFound(err)
 	}
 
+	// tls config of data sources is registered before any member is connected
+	if cr.GetDeletionTimestamp().IsZero() {
+		if err = RegisterMysqlTLSConfig(ctx, t.Client, cr); err != nil {
+			reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeWarning, "TLSConfigInvalid", err.Error())
+			return r, err
+		}
+	}
+
 	if err = t.checkDeleteOrApply(ctx, cr); err != nil {
+		// statefulset deleted by volume expansion is created by later reconcile after it is gone, expansion status is kept meanwhile
+		if errors.Is(err, types.ErrStatefulSetRecreating) {
+			if updateErr := t.Status().Update(ctx, cr); updateErr != nil {
+				return r, fmt.Errorf("status update failed -> %w", updateErr)
+			}
+			return ctrl.Result{RequeueAfter: time.Second * 2}, nil
+		}
 		return r, client.IgnoreNotFound(err)
 	}
 
 	if cr.GetDeletionTimestamp().IsZero() {
-		// check for cluster status
+		// check for cluster status, every phase below has its own timeout, slow phase does not expire next phases
 		remoteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
 		defer cancel()
+		lastBootstrap := cr.Status.Bootstrap
+		lastForceMembers := cr.Status.ForceMembers
+		lastMasters := cr.Status.Masters
+		// status of failed check is partial, only conditions of last checked status are updated
+		checked := cr.DeepCopy()
 		if err = t.checkClusterStatus(remoteCtx, cr); err != nil {
 			r.Requeue = true
 			r.RequeueAfter = time.Second * 2
+			// writes are not routed to lost masters, primary service has no endpoint until new masters are elected
+			if errors.Is(err, types.ErrMasterNoutFound) && !reconciler.IsPaused(cr) {
+				roles := make(map[string]string)
+				for _, master := range checked.Status.Masters {
+					roles[master] = ""
+				}
+				if labelErr := t.applyRoleLabels(ctx, cr, roles); labelErr != nil {
+					return r, labelErr
+				}
+			}
+
+			if t.setClusterCheckFailed(checked, err) {
+				if updateErr := t.Status().Update(ctx, checked); updateErr != nil {
+					return r, fmt.Errorf("status update failed -> %w", updateErr)
+				}
+			}
+
 			if errors.Is(err, types.ErrPodNotRunning) || errors.Is(err, types.ErrMasterNoutFound) || errors.Is(err, types.ErrContainerNotFound) || errors.Is(err, types.ErrReplicasNotDesired) {
 				return r, nil
 			}
 
 			return r, err
 		}
+		t.setClusterConditions(cr, lastMasters)
+
+		// paused cluster only reports status, topology actions are suspended
+		var promoted bool
+		if !reconciler.IsPaused(cr) {
+			// planned switchover may wait replicas catch up, give it more time than status check
+			switchoverCtx, switchoverCancel := context.WithTimeout(ctx, time.Second*60)
+			defer switchoverCancel()
+			if err = t.checkSwitchover(switchoverCtx, cr); err != nil {
+				return r, err
+			}
+
+			// primary of upgrade is switched over before it is restarted, it has its own timeout after planned switchover
+			upgradeCtx, upgradeCancel := context.WithTimeout(ctx, time.Second*60)
+			defer upgradeCancel()
+			if err = t.checkRollingUpgrade(upgradeCtx, cr); err != nil {
+				return r, err
+			}
+
+			// dynamic variables are applied to running members, static variables restart pods by rolling upgrade
+			configCtx, configCancel := context.WithTimeout(ctx, time.Second*10)
+			defer configCancel()
+			if err = t.checkConfig(configCtx, cr); err != nil {
+				return r, err
+			}
+
+			promoteCtx, promoteCancel := context.WithTimeout(ctx, time.Second*10)
+			defer promoteCancel()
+			if promoted, err = t.checkPromote(promoteCtx, cr); err != nil {
+				return r, err
+			}
 
-		if err = t.Status().Update(remoteCtx, cr); err != nil {
+			// role services follow masters found by status check and planned switchover
+			labelCtx, labelCancel := context.WithTimeout(ctx, time.Second*10)
+			defer labelCancel()
+			if err = t.applyRoleLabels(labelCtx, cr, GetMemberRoles(cr)); err != nil {
+				return r, err
+			}
+		}
+
+		// force bootstrap mark is used only once, remove it after group is bootstrapped by force
+		forceBootstrapUsed := cr.Status.Bootstrap != lastBootstrap && cr.Status.Bootstrap.Forced
+		// force members mark is used only once as well, next loss of quorum must be forced again
+		forceMembersUsed := cr.Status.ForceMembers != lastForceMembers
+
+		// status is written with reconcile context, new master of slow switchover is always recorded
+		if err = t.Status().Update(ctx, cr); err != nil {
 			return r, fmt.Errorf("status update failed -> %w", err)
 		}
 
+		// errant transactions acknowledgement is used only once, new errant transactions must be acknowledged again
+		errantAcknowledged := cr.Annotations[types.MysqlAckErrantTransactionsAnnotationName] != ""
+		for _, host := range strings.Split(cr.Annotations[types.MysqlAckErrantTransactionsAnnotationName], ",") {
+			if util.InArray(GetQuarantinedHosts(cr), host) {
+				errantAcknowledged = false
+			}
+		}
+
+		var annotationsChanged bool
+		if forceBootstrapUsed && cr.Annotations[types.MysqlForceBootstrapAnnotationName] != "" {
+			delete(cr.Annotations, types.MysqlForceBootstrapAnnotationName)
+			annotationsChanged = true
+		}
+
+		if forceMembersUsed && cr.Annotations[types.MysqlForceMembersAnnotationName] != "" {
+			delete(cr.Annotations, types.MysqlForceMembersAnnotationName)
+			annotationsChanged = true
+		}
+
+		if errantAcknowledged {
+			delete(cr.Annotations, types.MysqlAckErrantTransactionsAnnotationName)
+			annotationsChanged = true
+		}
+
+		// promoted cluster is independent, it does not follow source cluster anymore
+		if promoted {
+			delete(cr.Annotations, types.MysqlPromoteAnnotationName)
+			cr.Spec.ReplicaOf = nil
+			annotationsChanged = true
+		}
+
+		if annotationsChanged {
+			if err = t.Update(ctx, cr); err != nil {
+				return r, err
+			}
+		}
+
 		if cr.Status.Phase != rdsv1alpha1.MysqlPhaseRunning {
 			r.Requeue = true
 			r.RequeueAfter = time.Second * 2
 			return r, nil
 		}
+
+		// replication lag of restarted member is not watched, upgrade checks it periodically
+		if cr.Status.Upgrade != nil && cr.Status.Upgrade.Phase == rdsv1alpha1.UpgradePhaseRunning {
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+
+		// capacity of expanded pvc is not watched, expansion progress is checked periodically
+		if cr.Status.VolumeExpansion != nil && cr.Status.VolumeExpansion.Phase == rdsv1alpha1.VolumeExpansionPhaseResizing {
+			return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+		}
+
+		// pod template change of pending restart is applied by next reconcile
+		if cr.Status.Config != nil && len(cr.Status.Config.PendingRestart) > 0 {
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+
+		// source cluster master change is not watched, standby checks it periodically
+		if cr.Spec.ReplicaOf != nil {
+			return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+		}
+
+		// status update does not trigger reconcile, member statuses and drift of running cluster are checked periodically
+		return ctrl.Result{RequeueAfter: time.Second * 30}, nil
 	}
 	return ctrl.Result{}, nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
+// status update of CR does not trigger reconcile, otherwise every probed status change reconciles CR again
 func (t *MysqlReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&rdsv1alpha1.Mysql{}).
+		For(&rdsv1alpha1.Mysql{}, ctrlbuilder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
 		Owns(&corev1.Service{}).Owns(&appsv1.StatefulSet{}).Owns(&corev1.ConfigMap{}).Owns(&corev1.Secret{}).Owns(&corev1.Pod{}).
 		Complete(t)
 }
 
+// TopologyChangedPredicate Mysql CR update events of spec, annotations, masters and quarantined members,
+// it is used by controllers which watch Mysql CR, probed member status changes such as gtid_executed are ignored
+func TopologyChangedPredicate() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldCR, ok := e.ObjectOld.(*rdsv1alpha1.Mysql)
+			if !ok {
+				return true
+			}
+			newCR, ok := e.ObjectNew.(*rdsv1alpha1.Mysql)
+			if !ok {
+				return true
+			}
+
+			return oldCR.Generation != newCR.Generation || !reflect.DeepEqual(oldCR.Annotations, newCR.Annotations) ||
+				!reflect.DeepEqual(oldCR.Status.Masters, newCR.Status.Masters) || !reflect.DeepEqual(GetQuarantinedHosts(oldCR), GetQuarantinedHosts(newCR)) ||
+				oldCR.GetDeletionTimestamp().IsZero() != newCR.GetDeletionTimestamp().IsZero()
+		},
+	}
+}
+
 func (t *MysqlReconciler) checkDeleteOrApply(ctx context.Context, cr *rdsv1alpha1.Mysql) (err error) {
 	if cr.GetDeletionTimestamp().IsZero() {
 		// add finalizer mark to CR,make sure CR clean is done by controller first
@@ -90,6 +268,10 @@ func (t *MysqlReconciler) checkDeleteOrApply(ctx context.Context, cr *rdsv1alpha
 				return err
 			}
 		}
+		// paused CR sub resources are kept as they are, DBA may change them by hand
+		if reconciler.IsPaused(cr) {
+			return nil
+		}
 		// apply create CR sub resources
 		return t.apply(ctx, cr)
 	} else {
@@ -121,10 +303,6 @@ func (t *MysqlReconciler) apply(ctx context.Context, cr *rdsv1alpha1.Mysql) (err
 		}
 	}
 
-	if err = reconciler.RemovePVCRetentionMark(t.Client, ctx, cr.Namespace, reconciler.BuildCRPVCLabels(cr, cr)); err != nil {
-		return err
-	}
-
 	return nil
 }
 
@@ -168,6 +346,14 @@ func (t *MysqlReconciler) applyMysql(ctx context.Context, cr *rdsv1alpha1.Mysql)
 	}
 
 	secret := builder.BuildSecret(cr)
+	// clone token is generated once, token of existing secret is kept
+	var oldSecret corev1.Secret
+	if err = t.Get(ctx, client.ObjectKeyFromObject(secret), &oldSecret); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	if err = builder.SetCloneToken(cr, secret, oldSecret.Data["MYSQL_CLONE_TOKEN"]); err != nil {
+		return err
+	}
 
 	if err = reconciler.ApplySecret(t.Client, ctx, secret, cr, t.Scheme); err != nil {
 		return err
@@ -183,17 +369,184 @@ func (t *MysqlReconciler) applyMysql(ctx context.Context, cr *rdsv1alpha1.Mysql)
 		return err
 	}
 
+	// members of highest ordinals leave cluster before statefulset is scaled down
+	replicas, removed, err := t.scaleIn(ctx, cr)
+	if err != nil {
+		return err
+	}
+	statefulset.Spec.Replicas = &replicas
+
+	// pvc of existing members are expanded when storage size grows, statefulset is recreated without deleting pods
+	if cr.Status.VolumeExpansion, err = reconciler.ExpandStatefulSetVolumes(t.Client, ctx, t.Recorder, cr, statefulset, cr.Status.VolumeExpansion); err != nil {
+		return err
+	}
+
 	if err = reconciler.ApplyStatefulSet(t.Client, ctx, statefulset, cr, t.Scheme); err != nil {
 		return err
 	}
 
+	// node drain never evicts majority of group replication members
+	if err = reconciler.ApplyPodDisruptionBudget(t.Client, ctx, mysqlBuilder.BuildPodDisruptionBudget(), cr, t.Scheme); err != nil {
+		return err
+	}
+
+	// clone port of xtrabackup stream is only reachable from pods of this CR
+	clonePolicy := mysqlBuilder.BuildCloneNetworkPolicy()
+	if builder.UseXtrabackupClone(cr) {
+		if err = reconciler.ApplyNetworkPolicy(t.Client, ctx, clonePolicy, cr, t.Scheme); err != nil {
+			return err
+		}
+	} else if err = t.Delete(ctx, clonePolicy); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	// pvc of scaled in pods are retained for types.PVCDeleteRetentionSeconds
+	if err = reconciler.AddPVCRetentionMarkByName(t.Client, ctx, cr.Namespace, getMysqlPVCNames(removed)); err != nil {
+		return err
+	}
+
+	var members []string
+	for i := 0; i < int(replicas); i++ {
+		members = append(members, cr.Name+"-mysql-"+strconv.Itoa(i))
+	}
+	if err = reconciler.RemovePVCRetentionMarkByName(t.Client, ctx, cr.Namespace, getMysqlPVCNames(members)); err != nil {
+		return err
+	}
+
 	if err = reconciler.ApplyService(t.Client, ctx, service, cr, t.Scheme); err != nil {
 		return err
 	}
 
+	// role services select pods by role label of status check, they follow failover
+	if err = reconciler.ApplyService(t.Client, ctx, mysqlBuilder.BuildRoleService(cr, "primary", builder.MysqlRolePrimary), cr, t.Scheme); err != nil {
+		return err
+	}
+
+	if err = reconciler.ApplyService(t.Client, ctx, mysqlBuilder.BuildRoleService(cr, "replicas", builder.MysqlRoleReplica), cr, t.Scheme); err != nil {
+		return err
+	}
+
+	if err = t.applyDelayedReplica(ctx, cr, mysqlBuilder.BuildDelayedReplicaService(cr)); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applyDelayedReplica label pods of delayed replicas and apply their service, service is deleted when spec.delayedReplica is removed
+func (t *MysqlReconciler) applyDelayedReplica(ctx context.Context, cr *rdsv1alpha1.Mysql, service *corev1.Service) (err error) {
+	if cr.Spec.DelayedReplica != nil {
+		if err = reconciler.ApplyService(t.Client, ctx, service, cr, t.Scheme); err != nil {
+			return err
+		}
+	} else if err = t.Delete(ctx, service); client.IgnoreNotFound(err) != nil {
+		return err
+	}
+
+	var pods corev1.PodList
+	if err = t.List(ctx, &pods, client.InNamespace(cr.Namespace), client.MatchingLabels(builder.BuildMysqlLabels(cr))); err != nil {
+		return err
+	}
+
+	delayed := builder.GetDelayedReplicaHosts(cr)
+	for k := range pods.Items {
+		pod := &pods.Items[k]
+		isDelayed := util.InArray(delayed, pod.Name)
+		if (pod.Labels[builder.MysqlDelayedReplicaLabelName] == "true") == isDelayed {
+			continue
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		if isDelayed {
+			pod.Labels[builder.MysqlDelayedReplicaLabelName] = "true"
+		} else {
+			delete(pod.Labels, builder.MysqlDelayedReplicaLabelName)
+		}
+
+		if err = t.Patch(ctx, pod, patch); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// scaleIn when spec.replicas is less than statefulset replicas, stop replication on members of highest ordinals first.
+// scale in is refused if current primary will be removed, except MGRMP mode which all members are primary.
+// returns replicas of statefulset and removed pod names
+func (t *MysqlReconciler) scaleIn(ctx context.Context, cr *rdsv1alpha1.Mysql) (replicas int32, removed []string, err error) {
+	replicas = *cr.Spec.Replicas
+
+	var sts appsv1.StatefulSet
+	if err = t.Get(ctx, client.ObjectKey{Namespace: cr.Namespace, Name: cr.Name + "-mysql"}, &sts); err != nil {
+		return replicas, nil, client.IgnoreNotFound(err)
+	}
+
+	if sts.Spec.Replicas == nil || *sts.Spec.Replicas <= replicas {
+		t.setScaleInRefused(cr, "")
+		return replicas, nil, nil
+	}
+
+	current := *sts.Spec.Replicas
+	for i := current - 1; i >= replicas; i-- {
+		host := cr.Name + "-mysql-" + strconv.Itoa(int(i))
+		if cr.Spec.ClusterMode != rdsv1alpha1.ModeMGRMP && util.InArray(cr.Status.Masters, host) {
+			logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "primary": host}).
+				Warn("mysql scale in refused, current primary will be removed, set spec.primary to switch primary first")
+			t.setScaleInRefused(cr, fmt.Sprintf("scale in from %d to %d replicas removes current primary %s, set spec.primary to switch primary first", current, replicas, host))
+			return current, nil, nil
+		}
+		removed = append(removed, host)
+	}
+	t.setScaleInRefused(cr, "")
+
+	dialect, err := mysql.NewDialect(cr.Spec.Version)
+	if err != nil {
+		return current, nil, err
+	}
+
+	dataSources := GetMysqlDataSources(cr)
+	clusterManager, err := newClusterManager(cr, dataSources, dialect, GetRecordedMasters(cr, cr.Status.Masters, dataSources), nil, nil)
+	if err != nil {
+		return current, nil, err
+	}
+
+	remover, ok := clusterManager.(mysql.MemberRemover)
+	if !ok {
+		return current, nil, fmt.Errorf("%w, mode=%s", types.ErrMysqlUnsupportedClusterMode, cr.Spec.ClusterMode)
+	}
+
+	removeCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+	for _, host := range removed {
+		if err = remover.RemoveMember(removeCtx, GetMysqlDataSource(cr, host)); err != nil {
+			return current, nil, err
+		}
+		logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "host": host}).Info("mysql member removed from cluster")
+	}
+
+	return replicas, removed, nil
+}
+
+// setScaleInRefused set ScaleInRefused condition, status is True with message when scale in is refused, a warning event is emitted when it is refused
+func (t *MysqlReconciler) setScaleInRefused(cr *rdsv1alpha1.Mysql, message string) {
+	condition := metav1.Condition{Type: rdsv1alpha1.MysqlConditionScaleInRefused, Status: metav1.ConditionFalse, Reason: "ReplicasApplied", Message: "spec.replicas is applied to statefulset"}
+	if message != "" {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ScaleInRefused"
+		condition.Message = message
+	}
+	reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, condition)
+}
+
+// getMysqlPVCNames pvc names of mysql pods, name format is volumeClaimTemplate-podName
+func getMysqlPVCNames(hosts []string) (names []string) {
+	for _, host := range hosts {
+		names = append(names, "data-"+host)
+	}
+	return names
+}
+
 // clean unreferenced sub resources
 func (t *MysqlReconciler) clean(ctx context.Context, cr *rdsv1alpha1.Mysql) (err error) {
 	// here write manual clean codes due to controller runtime SetOwnerRef is not stable
@@ -220,6 +573,28 @@ func (t *MysqlReconciler) clean(ctx context.Context, cr *rdsv1alpha1.Mysql) (err
 		return fmt.Errorf("delete sub resource failed,[namespace=%s] [api=%s] [kind=%s] [cr=%s] , err is -> %s", cr.Namespace, cr.APIVersion, cr.Kind, cr.Name, err.Error())
 	}
 
+	var mysqlPDBs policyv1.PodDisruptionBudgetList
+	if err = t.List(ctx, &mysqlPDBs, client.InNamespace(cr.Namespace), client.MatchingLabels(builder.BuildMysqlLabels(cr))); err == nil && client.IgnoreNotFound(err) == nil {
+		for _, v := range mysqlPDBs.Items {
+			if err = t.Delete(ctx, &v); err != nil && client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("delete resource in [namespace=%s] [api=%s] [kind=%s] [name=%s] failed -> %s", v.Namespace, v.APIVersion, v.Kind, v.Name, err.Error())
+			}
+		}
+	} else {
+		return fmt.Errorf("delete sub resource failed,[namespace=%s] [api=%s] [kind=%s] [cr=%s] , err is -> %s", cr.Namespace, cr.APIVersion, cr.Kind, cr.Name, err.Error())
+	}
+
+	var mysqlNetworkPolicies networkingv1.NetworkPolicyList
+	if err = t.List(ctx, &mysqlNetworkPolicies, client.InNamespace(cr.Namespace), client.MatchingLabels(builder.BuildMysqlLabels(cr))); err == nil && client.IgnoreNotFound(err) == nil {
+		for _, v := range mysqlNetworkPolicies.Items {
+			if err = t.Delete(ctx, &v); err != nil && client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("delete resource in [namespace=%s] [api=%s] [kind=%s] [name=%s] failed -> %s", v.Namespace, v.APIVersion, v.Kind, v.Name, err.Error())
+			}
+		}
+	} else {
+		return fmt.Errorf("delete sub resource failed,[namespace=%s] [api=%s] [kind=%s] [cr=%s] , err is -> %s", cr.Namespace, cr.APIVersion, cr.Kind, cr.Name, err.Error())
+	}
+
 	// clean common sub resources
 	var configMaps corev1.ConfigMapList
 	if err = t.List(ctx, &configMaps, client.InNamespace(cr.Namespace), client.MatchingLabels(builder.BuildMysqlLabels(cr))); err == nil && client.IgnoreNotFound(err) == nil {
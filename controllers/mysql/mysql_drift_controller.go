@@ -0,0 +1,124 @@
+package mysql
+
+import (
+	"fmt"
+	"strings"
+
+	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	"github.com/hakur/rds-operator/controllers/mysql/builder"
+	"github.com/hakur/rds-operator/pkg/mysql"
+	"github.com/hakur/rds-operator/pkg/reconciler"
+	"github.com/hakur/util"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newDriftDetector desired runtime state of members derived from cr, masters are recorded masters of last check.
+// missing privileges of cluster user are granted by root account when spec.rootPassword is set
+func newDriftDetector(cr *rdsv1alpha1.Mysql, dialect *mysql.Dialect, masters []*mysql.DSN, dataSources []*mysql.DSN) *mysql.DriftDetector {
+	detector := &mysql.DriftDetector{
+		Dialect:         dialect,
+		Masters:         masters,
+		Roles:           cr.Spec.ClusterMode == rdsv1alpha1.ModeSemiSync || cr.Spec.ClusterMode == rdsv1alpha1.ModeAsync,
+		SemiSync:        cr.Spec.ClusterMode == rdsv1alpha1.ModeSemiSync,
+		ReadOnlyMasters: cr.Spec.ReplicaOf != nil,
+		Skipped:         GetRecordedMasters(cr, builder.GetMaintenanceHosts(cr), dataSources),
+		Enforce:         cr.Spec.DriftPolicy != rdsv1alpha1.DriftPolicyReport && !reconciler.IsPaused(cr),
+	}
+
+	if cr.Spec.ClusterUser != nil {
+		detector.Grants = &mysql.UserGrants{
+			Username:   cr.Spec.ClusterUser.Username,
+			Domain:     cr.Spec.ClusterUser.Domain,
+			Privileges: cr.Spec.ClusterUser.Privileges,
+			Target:     cr.Spec.ClusterUser.DatabaseTarget,
+		}
+		if cr.Spec.RootPassword != nil {
+			detector.Grants.AdminUsername = "root"
+			detector.Grants.AdminPassword = util.Base64Decode(*cr.Spec.RootPassword)
+		}
+	}
+	return detector
+}
+
+// getDriftedMembers data sources of members which have drift not corrected
+func getDriftedMembers(drifts []*mysql.Drift, dataSources []*mysql.DSN) (members []*mysql.DSN) {
+	for _, dsn := range dataSources {
+		for _, drift := range drifts {
+			if drift.Host == dsn.Host && !drift.Corrected {
+				members = append(members, dsn)
+				break
+			}
+		}
+	}
+	return members
+}
+
+// SetDrifts record drifts in cr status and update Drifted condition
+func SetDrifts(cr *rdsv1alpha1.Mysql, drifts []*mysql.Drift) {
+	condition := metav1.Condition{
+		Type:               rdsv1alpha1.MysqlConditionDrifted,
+		Status:             metav1.ConditionFalse,
+		Reason:             "NoDrift",
+		Message:            "runtime state of members is desired state",
+		ObservedGeneration: cr.Generation,
+	}
+
+	var reported []string
+	cr.Status.Drifts = nil
+	for _, drift := range drifts {
+		status := rdsv1alpha1.MysqlDrift{
+			Name:      strings.ReplaceAll(drift.Host, "."+cr.Namespace, ""),
+			Setting:   drift.Setting,
+			Desired:   drift.Desired,
+			Actual:    drift.Actual,
+			Corrected: drift.Corrected,
+			Message:   drift.Message,
+		}
+		cr.Status.Drifts = append(cr.Status.Drifts, status)
+		if !drift.Corrected {
+			reported = append(reported, status.Name+" "+status.Setting)
+		}
+	}
+
+	if len(reported) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "DriftReported"
+		condition.Message = fmt.Sprintf("drift is not corrected [%s]", strings.Join(reported, ","))
+	} else if len(drifts) > 0 {
+		condition.Reason = "DriftCorrected"
+		condition.Message = fmt.Sprintf("%d drifted settings are corrected", len(drifts))
+	}
+
+	meta.SetStatusCondition(&cr.Status.Conditions, condition)
+}
+
+// recordDrifts emit event for corrected drift and drift which is not reported by last check
+func (t *MysqlReconciler) recordDrifts(cr *rdsv1alpha1.Mysql, previous []rdsv1alpha1.MysqlDrift) {
+	if t.Recorder == nil {
+		return
+	}
+
+	for _, drift := range cr.Status.Drifts {
+		if drift.Corrected {
+			t.Recorder.Eventf(cr, corev1.EventTypeWarning, "DriftCorrected", "member %s %s is %s, corrected to %s", drift.Name, drift.Setting, drift.Actual, drift.Desired)
+			continue
+		}
+
+		reported := false
+		for _, v := range previous {
+			if v.Name == drift.Name && v.Setting == drift.Setting && v.Actual == drift.Actual && !v.Corrected {
+				reported = true
+			}
+		}
+
+		if !reported {
+			message := fmt.Sprintf("member %s %s is %s, desired %s", drift.Name, drift.Setting, drift.Actual, drift.Desired)
+			if drift.Message != "" {
+				message += ", " + drift.Message
+			}
+			t.Recorder.Event(cr, corev1.EventTypeWarning, "DriftDetected", message)
+		}
+	}
+}
@@ -2,14 +2,25 @@ package mysql
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
+	"time"
 
 	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	"github.com/hakur/rds-operator/controllers/mysql/builder"
 	"github.com/hakur/rds-operator/pkg/mysql"
+	"github.com/hakur/rds-operator/pkg/reconciler"
+	"github.com/hakur/rds-operator/pkg/types"
+	rdsutil "github.com/hakur/rds-operator/util"
 	"github.com/hakur/util"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func GetMysqlHosts(cr *rdsv1alpha1.Mysql) (hosts []string) {
@@ -20,43 +31,515 @@ func GetMysqlHosts(cr *rdsv1alpha1.Mysql) (hosts []string) {
 }
 
 func GetMysqlDataSources(cr *rdsv1alpha1.Mysql) (ds []*mysql.DSN) {
-	mysqlPassword := util.Base64Decode(cr.Spec.ClusterUser.Password)
 	for _, v := range GetMysqlHosts(cr) {
-		ds = append(ds, &mysql.DSN{
-			Host:     v + "." + cr.Namespace,
-			Port:     3306,
-			Username: cr.Spec.ClusterUser.Username,
-			Password: string(mysqlPassword),
-			DBName:   "mysql",
-		})
+		ds = append(ds, GetMysqlDataSource(cr, v))
+	}
+	return
+}
+
+// GetMysqlDataSource data source of mysql pod, tls and timeouts of spec.connection are applied
+func GetMysqlDataSource(cr *rdsv1alpha1.Mysql, host string) *mysql.DSN {
+	dsn := &mysql.DSN{
+		Host:     host + "." + cr.Namespace,
+		Port:     3306,
+		Username: cr.Spec.ClusterUser.Username,
+		Password: string(util.Base64Decode(cr.Spec.ClusterUser.Password)),
+		DBName:   "mysql",
+	}
+
+	if conn := cr.Spec.Connection; conn != nil {
+		dsn.TLS = conn.TLSMode
+		if conn.TLSMode == "true" && conn.TLSSecret != nil {
+			dsn.TLS = getMysqlTLSConfigName(cr)
+		}
+		if conn.DialTimeoutSeconds != nil {
+			dsn.Timeout = time.Duration(*conn.DialTimeoutSeconds) * time.Second
+		}
+		if conn.ReadTimeoutSeconds != nil {
+			dsn.ReadTimeout = time.Duration(*conn.ReadTimeoutSeconds) * time.Second
+		}
+		if conn.WriteTimeoutSeconds != nil {
+			dsn.WriteTimeout = time.Duration(*conn.WriteTimeoutSeconds) * time.Second
+		}
+	}
+	return dsn
+}
+
+// RegisterMysqlTLSConfig register tls config of spec.connection.tlsSecret for data sources of cr, it must be called before data sources are connected.
+// nothing is done when tls mode is not true
+func RegisterMysqlTLSConfig(ctx context.Context, c client.Client, cr *rdsv1alpha1.Mysql) (err error) {
+	conn := cr.Spec.Connection
+	if conn == nil || conn.TLSMode != "true" || conn.TLSSecret == nil {
+		return nil
+	}
+
+	var secret corev1.Secret
+	if err = c.Get(ctx, client.ObjectKey{Namespace: cr.Namespace, Name: *conn.TLSSecret}, &secret); err != nil {
+		return fmt.Errorf("%w, [secret=%s] err -> %s", types.ErrMysqlTLSConfigInvalid, *conn.TLSSecret, err.Error())
+	}
+
+	if err = mysql.RegisterTLSConfig(getMysqlTLSConfigName(cr), secret.Data["ca.crt"], secret.Data["tls.crt"], secret.Data["tls.key"]); err != nil {
+		return fmt.Errorf("%w, [secret=%s] err -> %s", types.ErrMysqlTLSConfigInvalid, *conn.TLSSecret, err.Error())
+	}
+	return nil
+}
+
+// getMysqlTLSConfigName tls config name of cr registered in go-sql-driver
+func getMysqlTLSConfigName(cr *rdsv1alpha1.Mysql) string {
+	return "mysql-" + cr.Namespace + "-" + cr.Name
+}
+
+// GetRecordedMasters find data sources of master hosts recorded in cr status
+func GetRecordedMasters(cr *rdsv1alpha1.Mysql, masterHosts []string, dataSources []*mysql.DSN) (masters []*mysql.DSN) {
+	for _, host := range masterHosts {
+		for _, dsn := range dataSources {
+			if dsn.Host == host+"."+cr.Namespace {
+				masters = append(masters, dsn)
+			}
+		}
 	}
 	return
 }
 
+// GetMemberStatuses convert probed member statuses to cr status, role is decided by masters of cr status.
+// probe time of member is kept when its probed state is not changed, unchanged status is not written again
+func GetMemberStatuses(cr *rdsv1alpha1.Mysql, probed []*mysql.MemberStatus) (statuses []rdsv1alpha1.MysqlMemberStatus) {
+	now := metav1.Now()
+	delayed := builder.GetDelayedReplicaHosts(cr)
+	maintenance := builder.GetMaintenanceHosts(cr)
+	for _, v := range probed {
+		status := rdsv1alpha1.MysqlMemberStatus{
+			Name:                  strings.ReplaceAll(v.Host, "."+cr.Namespace, ""),
+			Role:                  rdsv1alpha1.MemberRoleSecondary,
+			MemberState:           v.MemberState,
+			GTIDExecuted:          v.GTIDExecuted,
+			SecondsBehindSource:   v.SecondsBehindSource,
+			IOThread:              v.IORunning,
+			SQLThread:             v.SQLRunning,
+			LastIOError:           v.LastIOError,
+			LastSQLError:          v.LastSQLError,
+			SemiSyncSourceStatus:  v.SemiSyncSourceStatus,
+			SemiSyncReplicaStatus: v.SemiSyncReplicaStatus,
+			ProbeError:            v.Error,
+			LastProbeTime:         now,
+		}
+		status.Delayed = rdsutil.InArray(delayed, status.Name)
+		status.Maintenance = rdsutil.InArray(maintenance, status.Name)
+
+		if !v.Reachable {
+			status.Role = rdsv1alpha1.MemberRoleUnknown
+		} else if rdsutil.InArray(cr.Status.Masters, status.Name) {
+			status.Role = rdsv1alpha1.MemberRolePrimary
+		}
+
+		for _, last := range cr.Status.MemberStatuses {
+			if last.Name == status.Name && !memberStatusProbeChanged(&last, &status) {
+				status.LastProbeTime = last.LastProbeTime
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// memberStatusProbeChanged compare probed fields of member status, probe time and fields computed later such as errant transactions are ignored
+func memberStatusProbeChanged(last, current *rdsv1alpha1.MysqlMemberStatus) bool {
+	return last.Role != current.Role || last.MemberState != current.MemberState || last.GTIDExecuted != current.GTIDExecuted ||
+		!reflect.DeepEqual(last.SecondsBehindSource, current.SecondsBehindSource) || last.IOThread != current.IOThread || last.SQLThread != current.SQLThread ||
+		last.LastIOError != current.LastIOError || last.LastSQLError != current.LastSQLError || last.SemiSyncSourceStatus != current.SemiSyncSourceStatus ||
+		last.SemiSyncReplicaStatus != current.SemiSyncReplicaStatus || last.ProbeError != current.ProbeError || last.Delayed != current.Delayed || last.Maintenance != current.Maintenance
+}
+
+// GetQuarantinedHosts return member pod names which are quarantined by errant transactions in cr status
+func GetQuarantinedHosts(cr *rdsv1alpha1.Mysql) (hosts []string) {
+	for _, status := range cr.Status.MemberStatuses {
+		if status.Quarantined {
+			hosts = append(hosts, status.Name)
+		}
+	}
+	return hosts
+}
+
+// SetErrantTransactions record errant transactions of members and update ErrantTransactions condition, only for SemiSync and Async cluster mode.
+// errant transactions of previous statuses are kept when they can not be computed, member is quarantined until its errant transactions are acknowledged
+func SetErrantTransactions(cr *rdsv1alpha1.Mysql, previous []rdsv1alpha1.MysqlMemberStatus, errant map[string]mysql.GTIDSet, errantErr error) {
+	acks := strings.Split(cr.Annotations[types.MysqlAckErrantTransactionsAnnotationName], ",")
+	var quarantined []string
+
+	for k := range cr.Status.MemberStatuses {
+		member := &cr.Status.MemberStatuses[k]
+		for _, v := range previous {
+			if v.Name == member.Name {
+				member.ErrantGTIDs = v.ErrantGTIDs
+				member.AcknowledgedErrantGTIDs = v.AcknowledgedErrantGTIDs
+			}
+		}
+
+		if errantErr == nil && member.Role != rdsv1alpha1.MemberRoleUnknown {
+			member.ErrantGTIDs = ""
+			if set, ok := errant[member.Name+"."+cr.Namespace]; ok {
+				member.ErrantGTIDs = set.String()
+			}
+
+			if member.ErrantGTIDs == "" || rdsutil.InArray(acks, member.Name) {
+				member.AcknowledgedErrantGTIDs = member.ErrantGTIDs
+			}
+		}
+
+		member.Quarantined = isQuarantined(member.ErrantGTIDs, member.AcknowledgedErrantGTIDs)
+		if member.Quarantined {
+			quarantined = append(quarantined, member.Name)
+		}
+	}
+
+	condition := metav1.Condition{
+		Type:               rdsv1alpha1.MysqlConditionErrantTransactions,
+		Status:             metav1.ConditionFalse,
+		Reason:             "NoErrantTransactions",
+		Message:            "no member has unacknowledged errant transactions",
+		ObservedGeneration: cr.Generation,
+	}
+
+	if len(quarantined) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "ErrantTransactionsFound"
+		condition.Message = fmt.Sprintf("members [%s] executed transactions which masters do not have, they are quarantined until acknowledged by annotation %s",
+			strings.Join(quarantined, ","), types.MysqlAckErrantTransactionsAnnotationName)
+		logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "members": quarantined}).Warn("mysql members have errant transactions")
+	}
+
+	meta.SetStatusCondition(&cr.Status.Conditions, condition)
+}
+
+// SetQuorumLost update QuorumLost condition by group partition status, forced group membership is recorded in cr status
+func SetQuorumLost(cr *rdsv1alpha1.Mysql, status *mysql.PartitionStatus) {
+	var online, unreachable []string
+	for _, host := range status.Online {
+		online = append(online, strings.ReplaceAll(host, "."+cr.Namespace, ""))
+	}
+	for _, host := range status.Unreachable {
+		unreachable = append(unreachable, strings.ReplaceAll(host, "."+cr.Namespace, ""))
+	}
+
+	condition := metav1.Condition{
+		Type:               rdsv1alpha1.MysqlConditionQuorumLost,
+		Status:             metav1.ConditionFalse,
+		Reason:             "QuorumPresent",
+		Message:            "majority of group members are online",
+		ObservedGeneration: cr.Generation,
+	}
+
+	if status.Forced {
+		cr.Status.ForceMembers = &rdsv1alpha1.MysqlForceMembersStatus{Members: online, Unreachable: unreachable, Time: metav1.Now()}
+		condition.Reason = "MembersForced"
+		condition.Message = status.Message
+	} else if status.QuorumLost {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "MajorityUnreachable"
+		condition.Message = fmt.Sprintf("members [%s] are unreachable, %s", strings.Join(unreachable, ","), status.Message)
+	} else if len(online) < 1 {
+		condition.Status = metav1.ConditionUnknown
+		condition.Reason = "NoMemberOnline"
+		condition.Message = status.Message
+	}
+
+	meta.SetStatusCondition(&cr.Status.Conditions, condition)
+}
+
+// SetPaused update Paused condition by pause annotation
+func SetPaused(cr *rdsv1alpha1.Mysql, paused bool) {
+	condition := metav1.Condition{
+		Type:               rdsv1alpha1.MysqlConditionPaused,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Reconciling",
+		Message:            "operator manages cluster topology",
+		ObservedGeneration: cr.Generation,
+	}
+
+	if paused {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "PauseAnnotation"
+		condition.Message = fmt.Sprintf("annotation %s=true is set, cluster topology is not changed by operator", types.PauseAnnotationName)
+	}
+
+	meta.SetStatusCondition(&cr.Status.Conditions, condition)
+}
+
+// GetMemberRoles role label value of every member by masters and healthy members of cr status, empty value means member is not selected by role services.
+// delayed replicas, quarantined members and replicas under maintenance do not serve reads
+func GetMemberRoles(cr *rdsv1alpha1.Mysql) (roles map[string]string) {
+	roles = make(map[string]string)
+	excluded := append(append(builder.GetDelayedReplicaHosts(cr), builder.GetMaintenanceHosts(cr)...), GetQuarantinedHosts(cr)...)
+	for _, member := range cr.Status.Members {
+		if rdsutil.InArray(cr.Status.Masters, member) {
+			roles[member] = builder.MysqlRolePrimary
+		} else if rdsutil.InArray(cr.Status.HealthyMembers, member) && !rdsutil.InArray(excluded, member) {
+			roles[member] = builder.MysqlRoleReplica
+		} else {
+			roles[member] = ""
+		}
+	}
+	return roles
+}
+
+// applyRoleLabels patch role label of pods in roles, label is removed when role is empty, pods not in roles are not changed
+func (t *MysqlReconciler) applyRoleLabels(ctx context.Context, cr *rdsv1alpha1.Mysql, roles map[string]string) (err error) {
+	var pods corev1.PodList
+	if err = t.List(ctx, &pods, client.InNamespace(cr.Namespace), client.MatchingLabels(builder.BuildMysqlLabels(cr))); err != nil {
+		return err
+	}
+
+	for k := range pods.Items {
+		pod := &pods.Items[k]
+		role, ok := roles[pod.Name]
+		if !ok || pod.Labels[builder.MysqlRoleLabelName] == role {
+			continue
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		if role != "" {
+			pod.Labels[builder.MysqlRoleLabelName] = role
+		} else {
+			delete(pod.Labels, builder.MysqlRoleLabelName)
+		}
+
+		if err = t.Patch(ctx, pod, patch); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setClusterConditions update Ready, Bootstrapped, Degraded and FailoverInProgress conditions by checked cluster status, previousMasters are masters of last check.
+// condition transitions and master change are recorded as events
+func (t *MysqlReconciler) setClusterConditions(cr *rdsv1alpha1.Mysql, previousMasters []string) {
+	var unhealthy []string
+	for _, member := range cr.Status.Members {
+		if !rdsutil.InArray(cr.Status.HealthyMembers, member) {
+			unhealthy = append(unhealthy, member)
+		}
+	}
+
+	if cr.Status.Phase == rdsv1alpha1.MysqlPhaseRunning {
+		reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionReady, Status: metav1.ConditionTrue,
+			Reason: "MembersHealthy", Message: fmt.Sprintf("all members are healthy, masters [%s]", strings.Join(cr.Status.Masters, ","))})
+	} else {
+		reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionReady, Status: metav1.ConditionFalse,
+			Reason: "MembersNotHealthy", Message: fmt.Sprintf("members [%s] are not healthy", strings.Join(unhealthy, ","))})
+	}
+
+	if cr.Status.Bootstrap != nil && cr.Status.Bootstrap.Refused {
+		reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionBootstrapped, Status: metav1.ConditionFalse,
+			Reason: "BootstrapRefused", Message: cr.Status.Bootstrap.Message})
+	} else if len(cr.Status.Masters) > 0 {
+		message := fmt.Sprintf("masters [%s] are elected", strings.Join(cr.Status.Masters, ","))
+		if cr.Status.Bootstrap != nil && cr.Status.Bootstrap.Message != "" {
+			message = cr.Status.Bootstrap.Message
+		}
+		reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionBootstrapped, Status: metav1.ConditionTrue,
+			Reason: "ClusterBootstrapped", Message: message})
+	} else if meta.FindStatusCondition(cr.Status.Conditions, rdsv1alpha1.ConditionBootstrapped) == nil {
+		reconciler.SetCondition(nil, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionBootstrapped, Status: metav1.ConditionFalse,
+			Reason: "WaitingForMembers", Message: "cluster is not bootstrapped yet"})
+	}
+
+	if meta.IsStatusConditionTrue(cr.Status.Conditions, rdsv1alpha1.MysqlConditionQuorumLost) {
+		reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionDegraded, Status: metav1.ConditionTrue,
+			Reason: "QuorumLost", Message: meta.FindStatusCondition(cr.Status.Conditions, rdsv1alpha1.MysqlConditionQuorumLost).Message})
+	} else if len(cr.Status.Masters) > 0 && len(unhealthy) > 0 {
+		reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionDegraded, Status: metav1.ConditionTrue,
+			Reason: "MembersNotHealthy", Message: fmt.Sprintf("members [%s] are not healthy", strings.Join(unhealthy, ","))})
+	} else {
+		reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionDegraded, Status: metav1.ConditionFalse,
+			Reason: "MembersHealthy", Message: "no member is lost"})
+	}
+
+	if len(cr.Status.Masters) > 0 {
+		reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionFailoverInProgress, Status: metav1.ConditionFalse,
+			Reason: "MasterElected", Message: fmt.Sprintf("masters [%s] are elected", strings.Join(cr.Status.Masters, ","))})
+	}
+
+	if len(previousMasters) > 0 && len(cr.Status.Masters) > 0 && !reflect.DeepEqual(previousMasters, cr.Status.Masters) {
+		reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeWarning, "MasterChanged", fmt.Sprintf("masters changed from [%s] to [%s]",
+			strings.Join(previousMasters, ","), strings.Join(cr.Status.Masters, ",")))
+	}
+
+	cr.Status.ObservedGeneration = cr.Generation
+}
+
+// setClusterCheckFailed update Ready and FailoverInProgress conditions when cluster status can not be checked, such as pods are not running or master is lost
+func (t *MysqlReconciler) setClusterCheckFailed(cr *rdsv1alpha1.Mysql, err error) (changed bool) {
+	reason := "CheckFailed"
+	switch {
+	case errors.Is(err, types.ErrPodNotRunning):
+		reason = "PodNotRunning"
+	case errors.Is(err, types.ErrMasterNoutFound):
+		reason = "MasterNotFound"
+	case errors.Is(err, types.ErrContainerNotFound):
+		reason = "ContainerNotFound"
+	case errors.Is(err, types.ErrReplicasNotDesired):
+		reason = "ReplicasNotDesired"
+	}
+
+	changed = reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionReady, Status: metav1.ConditionFalse,
+		Reason: reason, Message: err.Error()})
+
+	// recorded masters are lost, failover is done by next successful check
+	if errors.Is(err, types.ErrMasterNoutFound) && len(cr.Status.Masters) > 0 {
+		changed = reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionFailoverInProgress, Status: metav1.ConditionTrue,
+			Reason: "MasterNotFound", Message: fmt.Sprintf("recorded masters [%s] are lost, %s", strings.Join(cr.Status.Masters, ","), err.Error())}) || changed
+	}
+	return changed
+}
+
+// isQuarantined member is quarantined when any errant transaction is not acknowledged
+func isQuarantined(errantGTIDs, acknowledgedGTIDs string) bool {
+	errant, err := mysql.ParseGTIDSet(errantGTIDs)
+	if err != nil || len(errant) < 1 {
+		return false
+	}
+
+	acknowledged, _ := mysql.ParseGTIDSet(acknowledgedGTIDs)
+	return !acknowledged.Contains(errant)
+}
+
+// newClusterManager create cluster manager of cr cluster mode, masters are recorded masters for semi sync and async mode
+func newClusterManager(cr *rdsv1alpha1.Mysql, dataSources []*mysql.DSN, dialect *mysql.Dialect, masters []*mysql.DSN, recovery *mysql.GroupRecovery, replicaOf *mysql.ReplicaOf) (clusterManager mysql.ClusterManager, err error) {
+	var cloner *mysql.Cloner
+	var quarantined []*mysql.DSN
+	if cr.Spec.Clone != nil {
+		cloner = &mysql.Cloner{Dialect: dialect}
+	}
+
+	if replicaOf.Enabled() && cr.Spec.ClusterMode != rdsv1alpha1.ModeSemiSync && cr.Spec.ClusterMode != rdsv1alpha1.ModeAsync {
+		return nil, fmt.Errorf("%w, replicaOf is not supported by mode=%s", types.ErrMysqlUnsupportedClusterMode, cr.Spec.ClusterMode)
+	}
+
+	if cr.Spec.DelayedReplica != nil && cr.Spec.ClusterMode != rdsv1alpha1.ModeSemiSync && cr.Spec.ClusterMode != rdsv1alpha1.ModeAsync {
+		return nil, fmt.Errorf("%w, delayedReplica is not supported by mode=%s", types.ErrMysqlUnsupportedClusterMode, cr.Spec.ClusterMode)
+	}
+	delayed := GetRecordedMasters(cr, builder.GetDelayedReplicaHosts(cr), dataSources)
+	maintenance := GetRecordedMasters(cr, builder.GetMaintenanceHosts(cr), dataSources)
+	delaySeconds := builder.GetReplicaDelaySeconds(cr)
+
+	for _, dsn := range dataSources {
+		if rdsutil.InArray(GetQuarantinedHosts(cr), strings.ReplaceAll(dsn.Host, "."+cr.Namespace, "")) {
+			quarantined = append(quarantined, dsn)
+		}
+	}
+
+	switch cr.Spec.ClusterMode {
+	case rdsv1alpha1.ModeMGRSP:
+		clusterManager = &mysql.MGRSP{DataSrouces: dataSources, Dialect: dialect, Recovery: recovery, Cloner: cloner, Options: builder.GetMGROptions(cr), Maintenance: maintenance}
+	case rdsv1alpha1.ModeMGRMP:
+		clusterManager = &mysql.MGRMP{DataSrouces: dataSources, Dialect: dialect, Recovery: recovery, Cloner: cloner, Options: builder.GetMGROptions(cr), Maintenance: maintenance}
+	case rdsv1alpha1.ModeSemiSync, rdsv1alpha1.ModeAsync:
+		replication := &mysql.Replication{DataSrouces: dataSources, SemiSync: cr.Spec.ClusterMode == rdsv1alpha1.ModeSemiSync, Dialect: dialect, Masters: masters,
+			Cloner: cloner, Quarantined: quarantined, ReplicaOf: replicaOf, Delayed: delayed, DelaySeconds: delaySeconds, Maintenance: maintenance}
+		if replication.SemiSync && cr.Spec.SemiSync != nil {
+			replication.DoubleMasterHA = cr.Spec.SemiSync.DoubleMasterHA
+		}
+		if replication.DoubleMasterHA && replicaOf.Enabled() {
+			return nil, fmt.Errorf("%w, replicaOf is not supported by semi sync DoubleMasterHA", types.ErrMysqlUnsupportedClusterMode)
+		}
+		clusterManager = replication
+	default:
+		return nil, fmt.Errorf("%w, mode=%s", types.ErrMysqlUnsupportedClusterMode, cr.Spec.ClusterMode)
+	}
+	return clusterManager, nil
+}
+
 // checkClusterStatus check cluster if is running , if not running, try to boostrap cluster
 func (t *MysqlReconciler) checkClusterStatus(ctx context.Context, cr *rdsv1alpha1.Mysql) (err error) {
 	var clusterManager mysql.ClusterManager
 	var dataSources = GetMysqlDataSources(cr)
 
+	dialect, err := mysql.NewDialect(cr.Spec.Version)
+	if err != nil {
+		return err
+	}
+
 	// set default values
 	masterHosts := cr.Status.Masters
+	memberStatuses := cr.Status.MemberStatuses
+	healthyHosts := cr.Status.HealthyMembers
+	drifts := cr.Status.Drifts
 	cr.Status.Members = GetMysqlHosts(cr)
 	cr.Status.Masters = []string{}
 	cr.Status.HealthyMembers = []string{}
 	cr.Status.Phase = rdsv1alpha1.MysqlPhaseNotReady
 
-	switch cr.Spec.ClusterMode {
-	case rdsv1alpha1.ModeMGRSP:
-		clusterManager = &mysql.MGRSP{DataSrouces: dataSources}
-	case rdsv1alpha1.ModeSemiSync:
-		if cr.Spec.SemiSync != nil {
-			clusterManager = &mysql.SemiSync{DataSrouces: dataSources, DoubleMasterHA: cr.Spec.SemiSync.DoubleMasterHA}
-		} else {
-			clusterManager = &mysql.SemiSync{DataSrouces: dataSources}
+	// group has never been bootstrapped when no bootstrap decision and no master are recorded
+	recovery := &mysql.GroupRecovery{
+		FirstBoot: cr.Status.Bootstrap == nil && len(masterHosts) < 1,
+		Force:     cr.Annotations[types.MysqlForceBootstrapAnnotationName] == "true",
+	}
+
+	partition := &mysql.GroupPartition{Force: cr.Annotations[types.MysqlForceMembersAnnotationName] == "true"}
+
+	if clusterManager, err = newClusterManager(cr, dataSources, dialect, GetRecordedMasters(cr, masterHosts, dataSources), recovery, t.newReplicaOf(ctx, cr, dialect)); err != nil {
+		return err
+	}
+
+	// runtime state changed by hand is found before cluster manager changes members, restarted members are not checked
+	detector := newDriftDetector(cr, dialect, GetRecordedMasters(cr, masterHosts, dataSources), dataSources)
+	detected := detector.Detect(ctx, GetRecordedMasters(cr, healthyHosts, dataSources))
+	// members with drift which is only reported are not changed by cluster manager
+	var drifted []*mysql.DSN
+	if !detector.Enforce {
+		drifted = getDriftedMembers(detected, dataSources)
+	}
+
+	switch manager := clusterManager.(type) {
+	case *mysql.MGRSP:
+		manager.Partition = partition
+	case *mysql.MGRMP:
+		manager.Partition = partition
+	case *mysql.Replication:
+		manager.Maintenance = append(manager.Maintenance, drifted...)
+	}
+
+	// paused cluster topology is not changed, members are only probed for status
+	SetPaused(cr, reconciler.IsPaused(cr))
+	if !reconciler.IsPaused(cr) {
+		err = clusterManager.StartCluster(ctx)
+	}
+
+	// replica of drifted replication source is repointed by cluster manager, drift is corrected only when cluster is started
+	for _, drift := range detected {
+		if drift.Repoint && detector.Enforce && err == nil {
+			drift.Corrected = true
+		}
+	}
+	SetDrifts(cr, detected)
+	t.recordDrifts(cr, drifts)
+	if partition.Status != nil {
+		SetQuorumLost(cr, partition.Status)
+	}
+	if recovery.Decision != nil {
+		cr.Status.Bootstrap = &rdsv1alpha1.MysqlBootstrapStatus{
+			Host:             strings.ReplaceAll(recovery.Decision.Host, "."+cr.Namespace, ""),
+			GTIDExecuted:     recovery.Decision.GTIDExecuted,
+			ReachableMembers: recovery.Decision.ReachableMembers,
+			Forced:           recovery.Decision.Forced,
+			Message:          recovery.Decision.Message,
+			Time:             metav1.Now(),
 		}
+		reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeNormal, "GroupBootstrapped", fmt.Sprintf("group is bootstrapped from %s, %s",
+			cr.Status.Bootstrap.Host, recovery.Decision.Message))
 	}
 
-	if err = clusterManager.StartCluster(ctx); err != nil {
+	if errors.Is(err, types.ErrMysqlMGRBootstrapRefused) { // report refused decision, keep recorded masters
+		cr.Status.Masters = masterHosts
+		cr.Status.Bootstrap = &rdsv1alpha1.MysqlBootstrapStatus{Refused: true, Message: err.Error(), Time: metav1.Now()}
+		logrus.WithField("cr", cr.Namespace+"/"+cr.Name).Warn(err.Error())
+		return nil
+	} else if errors.Is(err, types.ErrMysqlMGRQuorumLost) { // group is blocked until quorum is back or members are forced, keep recorded masters
+		cr.Status.Masters = masterHosts
+		logrus.WithField("cr", cr.Namespace+"/"+cr.Name).Warn(err.Error())
+		return nil
+	} else if err != nil {
 		return err
 	}
 
@@ -70,20 +553,231 @@ func (t *MysqlReconciler) checkClusterStatus(ctx context.Context, cr *rdsv1alpha
 		cr.Status.Phase = rdsv1alpha1.MysqlPhaseNotReady
 	}
 
-	if masters, err := clusterManager.FindMaster(ctx); err == nil {
-		for _, master := range masters {
-			cr.Status.Masters = append(cr.Status.Masters, strings.ReplaceAll(master.Host, "."+cr.Namespace, ""))
-		}
-	} else {
+	masters, err := clusterManager.FindMaster(ctx)
+	if err != nil {
 		return err
 	}
 
+	for _, master := range masters {
+		cr.Status.Masters = append(cr.Status.Masters, strings.ReplaceAll(master.Host, "."+cr.Namespace, ""))
+	}
+
+	probed := mysql.ProbeMembers(ctx, dataSources, dialect)
+	cr.Status.MemberStatuses = GetMemberStatuses(cr, probed)
+
+	if cr.Spec.ClusterMode == rdsv1alpha1.ModeSemiSync || cr.Spec.ClusterMode == rdsv1alpha1.ModeAsync {
+		errant, errantErr := mysql.ErrantTransactions(ctx, masters, probed)
+		if errantErr != nil {
+			logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "err": errantErr.Error()}).Debug("mysql check errant transactions failed")
+		}
+		SetErrantTransactions(cr, memberStatuses, errant, errantErr)
+	}
+
 	if !reflect.DeepEqual(masterHosts, cr.Status.Masters) {
 		// master changed, need to notify mysql proxy middleware
 		// in MYSQL mgr mode, proxysql already automatic modified master address on itself
-		// in mysql semi sync mode, mysql master hosts are fixed , such as mysql-0 and mysql-1, no need to modify proxysql mysql master info
+		// in mysql semi sync mode, proxysql find master by read_only check, failover master is turned off super_read_only
 		logrus.Debug("master list changed, need to notify mysql proxy middleware")
 	}
 
 	return err
 }
+
+// checkSwitchover execute planned primary switchover when spec.primary is not current primary.
+// target must be healthy, failed switchover to same target is retried after one minute
+func (t *MysqlReconciler) checkSwitchover(ctx context.Context, cr *rdsv1alpha1.Mysql) (err error) {
+	if cr.Spec.Primary == nil || *cr.Spec.Primary == "" || len(cr.Status.Masters) < 1 {
+		return nil
+	}
+
+	target := *cr.Spec.Primary
+	if rdsutil.InArray(cr.Status.Masters, target) || !rdsutil.InArray(cr.Status.HealthyMembers, target) {
+		return nil
+	}
+
+	if last := cr.Status.Switchover; last != nil && last.To == target && last.Phase == rdsv1alpha1.SwitchoverPhaseFailed &&
+		time.Since(last.CompletionTime.Time) < time.Minute {
+		return nil
+	}
+
+	// failed switchover is recorded in status and retried later
+	if err = t.switchPrimary(ctx, cr, target); err != nil && cr.Status.Switchover.Phase == rdsv1alpha1.SwitchoverPhaseFailed {
+		logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "primary": target}).Warn(err.Error())
+		return nil
+	}
+	return err
+}
+
+// switchPrimary make target member as primary, result is recorded in cr status.switchover
+func (t *MysqlReconciler) switchPrimary(ctx context.Context, cr *rdsv1alpha1.Mysql, target string) (err error) {
+	status := &rdsv1alpha1.MysqlSwitchoverStatus{From: strings.Join(cr.Status.Masters, ","), To: target, StartTime: metav1.Now()}
+	cr.Status.Switchover = status
+
+	dataSources := GetMysqlDataSources(cr)
+	dialect, err := mysql.NewDialect(cr.Spec.Version)
+	if err != nil {
+		return err
+	}
+
+	clusterManager, err := newClusterManager(cr, dataSources, dialect, GetRecordedMasters(cr, cr.Status.Masters, dataSources), nil, t.newReplicaOf(ctx, cr, dialect))
+	if err != nil {
+		return err
+	}
+
+	if switcher, ok := clusterManager.(mysql.PrimarySwitcher); !ok {
+		err = fmt.Errorf("%w, mode=%s", types.ErrMysqlUnsupportedClusterMode, cr.Spec.ClusterMode)
+	} else if targets := GetRecordedMasters(cr, []string{target}, dataSources); len(targets) < 1 {
+		err = fmt.Errorf("%w, primary %s is not a member", types.ErrMysqlSwitchoverFailed, target)
+	} else if rdsutil.InArray(builder.GetDelayedReplicaHosts(cr), target) {
+		err = fmt.Errorf("%w, primary %s is delayed replica", types.ErrMysqlSwitchoverFailed, target)
+	} else if rdsutil.InArray(GetQuarantinedHosts(cr), target) {
+		err = fmt.Errorf("%w, primary %s has errant transactions which are not acknowledged", types.ErrMysqlSwitchoverFailed, target)
+	} else if rdsutil.InArray(builder.GetMaintenanceHosts(cr), target) {
+		err = fmt.Errorf("%w, primary %s is under maintenance", types.ErrMysqlSwitchoverFailed, target)
+	} else {
+		err = switcher.SwitchPrimary(ctx, targets[0])
+	}
+
+	status.CompletionTime = metav1.Now()
+	if err != nil {
+		status.Phase = rdsv1alpha1.SwitchoverPhaseFailed
+		status.Message = err.Error()
+		reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeWarning, "SwitchoverFailed", err.Error())
+		return err
+	}
+
+	status.Phase = rdsv1alpha1.SwitchoverPhaseSucceeded
+	cr.Status.Masters = []string{target}
+	for k, member := range cr.Status.MemberStatuses {
+		if member.Name == target {
+			cr.Status.MemberStatuses[k].Role = rdsv1alpha1.MemberRolePrimary
+		} else if member.Role == rdsv1alpha1.MemberRolePrimary {
+			cr.Status.MemberStatuses[k].Role = rdsv1alpha1.MemberRoleSecondary
+		}
+	}
+	logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "from": status.From, "to": target}).Info("mysql primary switchover succeeded")
+	reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeNormal, "SwitchoverSucceeded", fmt.Sprintf("primary is switched over from [%s] to %s", status.From, target))
+	return nil
+}
+
+// newReplicaOf find source master of disaster recovery standby cluster and record it in cr status, nil if cr is not a standby
+func (t *MysqlReconciler) newReplicaOf(ctx context.Context, cr *rdsv1alpha1.Mysql, dialect *mysql.Dialect) (replicaOf *mysql.ReplicaOf) {
+	if cr.Spec.ReplicaOf == nil {
+		if cr.Status.ReplicaOf != nil && !cr.Status.ReplicaOf.Promoted {
+			cr.Status.ReplicaOf = nil
+		}
+		return nil
+	}
+
+	replicaOf = &mysql.ReplicaOf{Dialect: dialect}
+	status := &rdsv1alpha1.MysqlReplicaOfStatus{}
+
+	source, err := t.findReplicaOfSource(ctx, cr)
+	if err != nil {
+		status.Message = err.Error()
+		logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "err": err.Error()}).Warn("mysql replica of source master not found")
+	} else {
+		replicaOf.Source = source
+		status.Source = source.Host + ":" + strconv.Itoa(source.Port)
+	}
+
+	cr.Status.ReplicaOf = status
+	return replicaOf
+}
+
+// findReplicaOfSource master recorded in status of source Mysql CR, or remote source host which read only is turned off
+func (t *MysqlReconciler) findReplicaOfSource(ctx context.Context, cr *rdsv1alpha1.Mysql) (source *mysql.DSN, err error) {
+	spec := cr.Spec.ReplicaOf
+	user := spec.User
+
+	if spec.CRD != nil {
+		namespace := cr.Namespace
+		if spec.CRD.Namespace != nil {
+			namespace = *spec.CRD.Namespace
+		}
+
+		var sourceCR rdsv1alpha1.Mysql
+		if err = t.Get(ctx, client.ObjectKey{Namespace: namespace, Name: spec.CRD.Name}, &sourceCR); err != nil {
+			return nil, err
+		}
+
+		if len(sourceCR.Status.Masters) < 1 {
+			return nil, fmt.Errorf("%w, source mysql %s/%s has no master", types.ErrMasterNoutFound, namespace, spec.CRD.Name)
+		}
+
+		if user == nil && sourceCR.Spec.ClusterUser != nil {
+			user = &sourceCR.Spec.ClusterUser.MysqlSimpleUserInfo
+		}
+		if user == nil {
+			return nil, fmt.Errorf("source mysql %s/%s has no cluster user, replicaOf.user is required", namespace, spec.CRD.Name)
+		}
+
+		source = &mysql.DSN{
+			Host:     sourceCR.Status.Masters[0] + "." + namespace,
+			Port:     3306,
+			Username: user.Username,
+			Password: string(util.Base64Decode(user.Password)),
+			DBName:   "mysql",
+		}
+		if spec.CRD.Port != nil {
+			source.Port = *spec.CRD.Port
+		}
+		return source, nil
+	}
+
+	if user == nil {
+		user = &cr.Spec.ClusterUser.MysqlSimpleUserInfo
+	}
+
+	var sources []*mysql.DSN
+	for _, host := range spec.Remote {
+		sources = append(sources, &mysql.DSN{
+			Host:     host.Host,
+			Port:     host.Port,
+			Username: user.Username,
+			Password: string(util.Base64Decode(user.Password)),
+			DBName:   "mysql",
+		})
+	}
+
+	return mysql.FindWritableSource(ctx, sources)
+}
+
+// checkPromote promote standby cluster as independent cluster when annotation promote.mysql.hakurei.cn=true.
+// replication from source cluster is stopped on masters, returns true if cluster is promoted and spec.replicaOf should be removed
+func (t *MysqlReconciler) checkPromote(ctx context.Context, cr *rdsv1alpha1.Mysql) (promoted bool, err error) {
+	if cr.Spec.ReplicaOf == nil || cr.Annotations[types.MysqlPromoteAnnotationName] != "true" {
+		return false, nil
+	}
+
+	dialect, err := mysql.NewDialect(cr.Spec.Version)
+	if err != nil {
+		return false, err
+	}
+
+	status := cr.Status.ReplicaOf
+	if status == nil {
+		status = &rdsv1alpha1.MysqlReplicaOfStatus{}
+		cr.Status.ReplicaOf = status
+	}
+
+	masters := GetRecordedMasters(cr, cr.Status.Masters, GetMysqlDataSources(cr))
+	if len(masters) < 1 {
+		status.Message = "standby master not found, promotion is waiting"
+		return false, nil
+	}
+
+	for _, master := range masters {
+		if err = mysql.PromoteReplicaOf(ctx, master, dialect); err != nil {
+			status.Message = err.Error()
+			logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "host": master.Host}).Warn(err.Error())
+			return false, nil
+		}
+	}
+
+	status.Promoted = true
+	status.Message = ""
+	status.PromoteTime = metav1.Now()
+	logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "source": status.Source}).Info("mysql standby cluster promoted as independent cluster")
+	return true, nil
+}
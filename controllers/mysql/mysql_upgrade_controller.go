@@ -0,0 +1,183 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	"github.com/hakur/rds-operator/controllers/mysql/builder"
+	"github.com/hakur/rds-operator/util"
+	"github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// checkRollingUpgrade restart pods which are not running with statefulset update revision, statefulset uses OnDelete update strategy.
+// one pod is restarted at a time after restarted member rejoined and caught up, replicas are restarted first,
+// primary is switched over to an upgraded member and restarted last
+func (t *MysqlReconciler) checkRollingUpgrade(ctx context.Context, cr *rdsv1alpha1.Mysql) (err error) {
+	var sts appsv1.StatefulSet
+	if err = t.Get(ctx, client.ObjectKey{Namespace: cr.Namespace, Name: cr.Name + "-mysql"}, &sts); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+
+	// update revision is not computed for latest template yet
+	if sts.Status.UpdateRevision == "" || sts.Status.ObservedGeneration < sts.Generation {
+		return nil
+	}
+
+	var pods corev1.PodList
+	if err = t.List(ctx, &pods, client.InNamespace(cr.Namespace), client.MatchingLabels(builder.BuildMysqlLabels(cr))); err != nil {
+		return err
+	}
+
+	outdated := getOutdatedPods(pods.Items, sts.Status.UpdateRevision)
+	status := cr.Status.Upgrade
+	if len(outdated) < 1 {
+		if status != nil && status.Phase == rdsv1alpha1.UpgradePhaseRunning {
+			status.Phase = rdsv1alpha1.UpgradePhaseSucceeded
+			status.Pending = nil
+			status.Message = ""
+			status.CompletionTime = metav1.Now()
+			logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "revision": status.Revision}).Info("mysql rolling upgrade succeeded")
+		}
+		return nil
+	}
+
+	if status == nil || status.Revision != sts.Status.UpdateRevision {
+		status = &rdsv1alpha1.MysqlUpgradeStatus{Revision: sts.Status.UpdateRevision, Version: cr.Spec.Version, StartTime: metav1.Now()}
+		cr.Status.Upgrade = status
+	}
+	status.Phase = rdsv1alpha1.UpgradePhaseRunning
+	status.Pending = outdated
+
+	if status.Message = upgradeWaitingReason(cr, pods.Items); status.Message != "" {
+		return nil
+	}
+
+	// replicas are restarted before primary, MGRMP members are all primary, members under maintenance are not restarted
+	target := ""
+	var primary string
+	for _, name := range outdated {
+		if util.InArray(builder.GetMaintenanceHosts(cr), name) {
+			continue
+		} else if cr.Spec.ClusterMode == rdsv1alpha1.ModeMGRMP || !util.InArray(cr.Status.Masters, name) {
+			target = name
+			break
+		} else if primary == "" {
+			primary = name
+		}
+	}
+
+	if target == "" && primary == "" {
+		status.Message = fmt.Sprintf("waiting members [%s] under maintenance", strings.Join(outdated, ","))
+		return nil
+	}
+
+	if target == "" {
+		target = primary
+		if len(cr.Status.Masters) == 1 {
+			candidate := upgradeSwitchoverCandidate(cr, outdated)
+			if candidate == "" {
+				status.Message = "no upgraded healthy member to switch primary to"
+				return nil
+			}
+
+			if err = t.switchPrimary(ctx, cr, candidate); err != nil {
+				status.Message = err.Error()
+				logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "primary": candidate}).Warn(err.Error())
+				return nil
+			}
+		}
+	}
+
+	for k := range pods.Items {
+		if pods.Items[k].Name == target {
+			if err = t.Delete(ctx, &pods.Items[k]); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+	}
+
+	status.Restarting = target
+	logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "pod": target, "revision": status.Revision}).Info("mysql rolling upgrade restart pod")
+	return nil
+}
+
+// getOutdatedPods names of pods which are not running with update revision, sorted by ordinal descending
+func getOutdatedPods(pods []corev1.Pod, revision string) (outdated []string) {
+	for _, pod := range pods {
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] != revision && pod.DeletionTimestamp.IsZero() {
+			outdated = append(outdated, pod.Name)
+		}
+	}
+
+	sort.Slice(outdated, func(i, j int) bool {
+		return getPodOrdinal(outdated[i]) > getPodOrdinal(outdated[j])
+	})
+	return outdated
+}
+
+// getPodOrdinal ordinal of statefulset pod name, such as 1 of yuxing-mysql-1
+func getPodOrdinal(name string) int {
+	ordinal, _ := strconv.Atoi(name[strings.LastIndex(name, "-")+1:])
+	return ordinal
+}
+
+// upgradeWaitingReason reason of next pod can not be restarted yet, empty if all pods are ready and all members are healthy and caught up
+func upgradeWaitingReason(cr *rdsv1alpha1.Mysql, pods []corev1.Pod) string {
+	for _, pod := range pods {
+		if !pod.DeletionTimestamp.IsZero() || !isPodReady(&pod) {
+			return fmt.Sprintf("waiting pod %s ready", pod.Name)
+		}
+	}
+
+	if cr.Status.Phase != rdsv1alpha1.MysqlPhaseRunning {
+		return "waiting all members healthy"
+	}
+
+	for _, member := range cr.Status.MemberStatuses {
+		if member.MemberState != "" && member.MemberState != "ONLINE" {
+			return fmt.Sprintf("waiting member %s online, state is %s", member.Name, member.MemberState)
+		}
+
+		// delayed replicas are always behind source
+		if !member.Delayed && member.SecondsBehindSource != nil && *member.SecondsBehindSource > 0 {
+			return fmt.Sprintf("waiting member %s catch up, %d seconds behind source", member.Name, *member.SecondsBehindSource)
+		}
+	}
+
+	return ""
+}
+
+// upgradeSwitchoverCandidate healthy upgraded member which can be primary, empty if no member is available
+func upgradeSwitchoverCandidate(cr *rdsv1alpha1.Mysql, outdated []string) string {
+	excluded := append(GetQuarantinedHosts(cr), builder.GetDelayedReplicaHosts(cr)...)
+	excluded = append(excluded, builder.GetMaintenanceHosts(cr)...)
+	candidates := cr.Status.HealthyMembers
+	if cr.Spec.Primary != nil { // desired primary is preferred
+		candidates = append([]string{*cr.Spec.Primary}, candidates...)
+	}
+
+	for _, name := range candidates {
+		if util.InArray(cr.Status.HealthyMembers, name) && !util.InArray(outdated, name) && !util.InArray(excluded, name) {
+			return name
+		}
+	}
+	return ""
+}
+
+// isPodReady pod condition Ready is true
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -65,6 +65,10 @@ func BuildSecret(cr *rdsv1alpha1.MysqlBackup) (secret *corev1.Secret) {
 	secret.Data["S3_SECRET_ACCESS_KEY"] = s3SecretAccessKey
 	secret.Data["LOCK_TABLE"] = []byte(strconv.FormatBool(cr.Spec.LockTable))
 
+	if cr.Spec.MysqlVersion != "" {
+		secret.Data["MYSQL_VERSION"] = []byte(cr.Spec.MysqlVersion)
+	}
+
 	if cr.Spec.UseZlibCompress != nil && *cr.Spec.UseZlibCompress {
 		secret.Data["BACKUP_USE_ZLIB"] = []byte("true")
 	} else {
@@ -3,12 +3,16 @@ package mysqlbackup
 import (
 	"context"
 	"fmt"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
@@ -27,6 +31,8 @@ type MysqlBackupReconciler struct {
 	client.Client
 	Scheme     *runtime.Scheme
 	RestConfig *rest.Config
+	// Recorder emit events of MysqlBackup CR, such as backup job succeeded or failed
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=rds.hakurei.cn,resources=mysqlbackups,verbs=get;list;watch;create;update;patch;delete
@@ -41,9 +47,21 @@ func (t *MysqlBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	}
 
 	if err = t.checkDeleteOrApply(ctx, cr); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeWarning, "ApplyFailed", err.Error())
+		}
 		return r, client.IgnoreNotFound(err)
 	}
 
+	if cr.GetDeletionTimestamp().IsZero() {
+		if err = t.checkBackupJobs(ctx, cr); err != nil {
+			return r, client.IgnoreNotFound(err)
+		}
+
+		// backup jobs are created by cronjob, finished jobs are checked periodically
+		return ctrl.Result{RequeueAfter: time.Minute}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
@@ -55,6 +73,65 @@ func (t *MysqlBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(t)
 }
 
+// checkBackupJobs record last finished job of backup cronjob in cr status and update BackupSucceeded condition
+func (t *MysqlBackupReconciler) checkBackupJobs(ctx context.Context, cr *rdsv1alpha1.MysqlBackup) (err error) {
+	cronjob := new(batchv1.CronJob)
+	if err = t.Get(ctx, client.ObjectKey{Namespace: cr.Namespace, Name: cr.Name + "-mysqlbackup"}, cronjob); err != nil {
+		return err
+	}
+
+	var jobs batchv1.JobList
+	if err = t.List(ctx, &jobs, client.InNamespace(cr.Namespace)); err != nil {
+		return err
+	}
+
+	// finished jobs are removed after ttlSecondsAfterFinished, last finished job is kept in status
+	var last *batchv1.Job
+	var lastCondition *batchv1.JobCondition
+	for k := range jobs.Items {
+		job := &jobs.Items[k]
+		if !metav1.IsControlledBy(job, cronjob) {
+			continue
+		}
+
+		for i := range job.Status.Conditions {
+			condition := &job.Status.Conditions[i]
+			if (condition.Type != batchv1.JobComplete && condition.Type != batchv1.JobFailed) || condition.Status != corev1.ConditionTrue {
+				continue
+			}
+			if lastCondition == nil || lastCondition.LastTransitionTime.Before(&condition.LastTransitionTime) {
+				last, lastCondition = job, condition
+			}
+		}
+	}
+
+	if last == nil && meta.FindStatusCondition(cr.Status.Conditions, rdsv1alpha1.ConditionBackupSucceeded) == nil {
+		reconciler.SetCondition(nil, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionBackupSucceeded, Status: metav1.ConditionUnknown,
+			Reason: "WaitingForSchedule", Message: "no backup job is finished yet"})
+	} else if last != nil && last.Name != cr.Status.LastJob {
+		// every finished job is an event, condition transition is not enough for consecutive succeeded jobs
+		cr.Status.LastJob = last.Name
+		if lastCondition.Type == batchv1.JobComplete {
+			cr.Status.LastSuccessfulTime = last.Status.CompletionTime
+			message := fmt.Sprintf("backup job %s succeeded", last.Name)
+			reconciler.SetCondition(nil, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionBackupSucceeded, Status: metav1.ConditionTrue,
+				Reason: "JobSucceeded", Message: message})
+			reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeNormal, "BackupSucceeded", message)
+		} else {
+			message := fmt.Sprintf("backup job %s failed, %s %s", last.Name, lastCondition.Reason, lastCondition.Message)
+			reconciler.SetCondition(nil, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionBackupSucceeded, Status: metav1.ConditionFalse,
+				Reason: "JobFailed", Message: message})
+			reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeWarning, "BackupFailed", message)
+		}
+	}
+
+	cr.Status.ObservedGeneration = cr.Generation
+	if err = t.Status().Update(ctx, cr); err != nil {
+		return fmt.Errorf("status update failed -> %w", err)
+	}
+	return nil
+}
+
 func (t *MysqlBackupReconciler) checkDeleteOrApply(ctx context.Context, cr *rdsv1alpha1.MysqlBackup) (err error) {
 	if cr.GetDeletionTimestamp().IsZero() {
 		// add finalizer mark to CR,make sure CR clean is done by controller first
@@ -0,0 +1,318 @@
+package mysqluser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	mysqlcontrollers "github.com/hakur/rds-operator/controllers/mysql"
+	"github.com/hakur/rds-operator/pkg/mysql"
+	"github.com/hakur/rds-operator/pkg/reconciler"
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/hakur/rds-operator/util"
+	hutil "github.com/hakur/util"
+)
+
+const (
+	// Finalizer mysqlusers CR delete mark, account is dropped before CR is deleted
+	Finalizer = "mysqluser.rds.hakurei.cn/v1alpha1"
+)
+
+// MysqlUserReconciler reconciles a MysqlUser object
+type MysqlUserReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+	// Recorder emit events of MysqlUser CR, such as account applied or dropped
+	Recorder record.EventRecorder
+}
+
+//+kubebuilder:rbac:groups=rds.hakurei.cn,resources=mysqlusers,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=rds.hakurei.cn,resources=mysqlusers/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=rds.hakurei.cn,resources=mysqlusers/finalizers,verbs=update
+
+func (t *MysqlUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (r ctrl.Result, err error) {
+	cr := &rdsv1alpha1.MysqlUser{}
+
+	if err = t.Get(ctx, req.NamespacedName, cr); err != nil {
+		return r, client.IgnoreNotFound(err)
+	}
+
+	if !cr.GetDeletionTimestamp().IsZero() {
+		// if finalizer mark exists, that means account is not dropped yet
+		if util.InArray(cr.Finalizers, Finalizer) {
+			if err = t.drop(ctx, cr); err != nil {
+				reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeWarning, "DropFailed", err.Error())
+				return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+			}
+		}
+		// remove finalizer mark, tell k8s account has been dropped
+		cr.ObjectMeta.Finalizers = util.DelArryElement(cr.ObjectMeta.Finalizers, Finalizer)
+		if err = t.Update(ctx, cr); err != nil {
+			return r, client.IgnoreNotFound(err)
+		}
+		return r, nil
+	}
+
+	// add finalizer mark to CR, make sure account is dropped by controller first
+	if !util.InArray(cr.Finalizers, Finalizer) {
+		cr.ObjectMeta.Finalizers = append(cr.ObjectMeta.Finalizers, Finalizer)
+		if err = t.Update(ctx, cr); err != nil {
+			return r, err
+		}
+	}
+
+	lastStatus := cr.Status.DeepCopy()
+	applyErr := t.apply(ctx, cr)
+
+	// unchanged status is not written, status write triggers reconcile again
+	cr.Status.ObservedGeneration = cr.Generation
+	if !reflect.DeepEqual(lastStatus, &cr.Status) {
+		if err = t.Status().Update(ctx, cr); err != nil {
+			return r, fmt.Errorf("status update failed -> %w", err)
+		}
+	}
+
+	// masters of Mysql CR and password secret may be ready later, account is applied again periodically until it succeeded
+	if applyErr != nil {
+		return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+	}
+	return r, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (t *MysqlUserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&rdsv1alpha1.MysqlUser{}).
+		Watches(&source.Kind{Type: &rdsv1alpha1.Mysql{}}, handler.EnqueueRequestsFromMapFunc(t.findMysqlUsersForMysql), builder.WithPredicates(mysqlcontrollers.TopologyChangedPredicate())).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(t.findMysqlUsersForSecret)).
+		Complete(t)
+}
+
+// findMysqlUsersForMysql MysqlUser CRs are reconciled when their Mysql CR changed, such as masters are elected
+func (t *MysqlUserReconciler) findMysqlUsersForMysql(obj client.Object) (requests []reconcile.Request) {
+	var users rdsv1alpha1.MysqlUserList
+	if err := t.List(context.Background(), &users, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+
+	for _, v := range users.Items {
+		if namespace, err := GetReferenceNamespace(&v, v.Spec.Mysql); err == nil && v.Spec.Mysql.Name == obj.GetName() && namespace == obj.GetNamespace() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&v)})
+		}
+	}
+	return requests
+}
+
+// findMysqlUsersForSecret MysqlUser CRs are reconciled when their password secret changed
+func (t *MysqlUserReconciler) findMysqlUsersForSecret(obj client.Object) (requests []reconcile.Request) {
+	var users rdsv1alpha1.MysqlUserList
+	if err := t.List(context.Background(), &users, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+
+	for _, v := range users.Items {
+		if v.Spec.PasswordSecret.Name == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&v)})
+		}
+	}
+	return requests
+}
+
+// apply create or alter account on master of Mysql CR, applied account is recorded in status and Ready condition is set
+func (t *MysqlUserReconciler) apply(ctx context.Context, cr *rdsv1alpha1.MysqlUser) (err error) {
+	if err = checkReferences(cr); err != nil {
+		t.setNotReady(cr, "ReferenceRefused", err)
+		return err
+	}
+
+	password, version, err := GetPassword(ctx, t.Client, cr)
+	if err != nil {
+		t.setNotReady(cr, "PasswordNotFound", err)
+		return err
+	}
+
+	mysqlCR, master, dialect, err := t.getMaster(ctx, cr)
+	if err != nil {
+		t.setNotReady(cr, "MasterNotFound", err)
+		return err
+	}
+
+	// account is applied already when spec, password and master are not changed since last apply, such as reconcile triggered by unrelated change of Mysql CR
+	if cr.Status.ObservedGeneration == cr.Generation && cr.Status.PasswordVersion == version && cr.Status.Master == master.Host &&
+		meta.IsStatusConditionTrue(cr.Status.Conditions, rdsv1alpha1.ConditionReady) {
+		return nil
+	}
+
+	user := &mysql.User{
+		Username: GetUsername(cr),
+		Password: password,
+		Hosts:    cr.Spec.Hosts,
+		Grants:   convertGrants(cr.Spec.Grants),
+	}
+	if cr.Spec.ResourceLimits != nil {
+		user.Limits = mysql.UserLimits{
+			MaxQueriesPerHour:     cr.Spec.ResourceLimits.MaxQueriesPerHour,
+			MaxUpdatesPerHour:     cr.Spec.ResourceLimits.MaxUpdatesPerHour,
+			MaxConnectionsPerHour: cr.Spec.ResourceLimits.MaxConnectionsPerHour,
+			MaxUserConnections:    cr.Spec.ResourceLimits.MaxUserConnections,
+		}
+	}
+
+	// last applied account of status, hosts and privileges removed from spec are dropped and revoked
+	var last *mysql.User
+	if cr.Status.Username != "" {
+		last = &mysql.User{Username: cr.Status.Username, Hosts: cr.Status.Hosts, Grants: convertGrants(cr.Status.Grants)}
+	}
+
+	remoteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+	if err = mysql.ApplyUser(remoteCtx, master, dialect, user, last); err != nil {
+		t.setNotReady(cr, "ApplyFailed", err)
+		return err
+	}
+
+	cr.Status.Username = user.Username
+	cr.Status.Hosts = cr.Spec.Hosts
+	cr.Status.Grants = cr.Spec.Grants
+	cr.Status.PasswordVersion = version
+	cr.Status.Master = master.Host
+	reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionReady, Status: metav1.ConditionTrue,
+		Reason: "AccountApplied", Message: fmt.Sprintf("account %s is applied on mysql %s/%s", user.Username, mysqlCR.Namespace, mysqlCR.Name)})
+	return nil
+}
+
+// drop account recorded in status on master of Mysql CR, nothing is dropped when Mysql CR is not found, deleting or in other namespace
+func (t *MysqlUserReconciler) drop(ctx context.Context, cr *rdsv1alpha1.MysqlUser) (err error) {
+	if cr.Status.Username == "" {
+		return nil
+	}
+
+	mysqlCR, master, _, err := t.getMaster(ctx, cr)
+	if errors.Is(err, types.ErrReferenceRefused) {
+		return nil
+	} else if client.IgnoreNotFound(err) == nil && (mysqlCR == nil || !mysqlCR.GetDeletionTimestamp().IsZero()) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	remoteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+	if err = mysql.DropUser(remoteCtx, master, cr.Status.Username, cr.Status.Hosts); err != nil {
+		return err
+	}
+
+	reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeNormal, "AccountDropped", fmt.Sprintf("account %s is dropped on mysql %s/%s", cr.Status.Username, mysqlCR.Namespace, mysqlCR.Name))
+	return nil
+}
+
+// getMaster find admin data source of first recorded master of Mysql CR, statements executed on it are replicated to other members.
+// root account is used when spec.rootPassword is set, otherwise cluster user is used
+func (t *MysqlUserReconciler) getMaster(ctx context.Context, cr *rdsv1alpha1.MysqlUser) (mysqlCR *rdsv1alpha1.Mysql, master *mysql.DSN, dialect *mysql.Dialect, err error) {
+	namespace, err := GetReferenceNamespace(cr, cr.Spec.Mysql)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	mysqlCR = new(rdsv1alpha1.Mysql)
+	if err = t.Get(ctx, client.ObjectKey{Namespace: namespace, Name: cr.Spec.Mysql.Name}, mysqlCR); err != nil {
+		return nil, nil, nil, err
+	}
+
+	if mysqlCR.Spec.ClusterUser == nil {
+		return mysqlCR, nil, nil, fmt.Errorf("%w, [mysql=%s] cluster user is not set", types.ErrMasterNoutFound, mysqlCR.Name)
+	}
+
+	masters := mysqlcontrollers.GetRecordedMasters(mysqlCR, mysqlCR.Status.Masters, mysqlcontrollers.GetMysqlDataSources(mysqlCR))
+	if len(masters) < 1 {
+		return mysqlCR, nil, nil, fmt.Errorf("%w, [mysql=%s]", types.ErrMasterNoutFound, mysqlCR.Name)
+	}
+
+	// tls and timeouts of data source are kept when root account is used
+	master = masters[0]
+	if mysqlCR.Spec.RootPassword != nil {
+		master.Username = "root"
+		master.Password = hutil.Base64Decode(*mysqlCR.Spec.RootPassword)
+	}
+
+	if err = mysqlcontrollers.RegisterMysqlTLSConfig(ctx, t.Client, mysqlCR); err != nil {
+		return mysqlCR, nil, nil, err
+	}
+
+	dialect, err = mysql.NewDialect(mysqlCR.Spec.Version)
+	if err != nil {
+		return mysqlCR, nil, nil, err
+	}
+	return mysqlCR, master, dialect, nil
+}
+
+// setNotReady set Ready=False with reason and error message, event is emitted when reason is changed
+func (t *MysqlUserReconciler) setNotReady(cr *rdsv1alpha1.MysqlUser, reason string, err error) {
+	reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionReady, Status: metav1.ConditionFalse,
+		Reason: reason, Message: err.Error()})
+}
+
+// GetUsername mysql account name of MysqlUser CR, name of CR is used when spec.username is empty
+func GetUsername(cr *rdsv1alpha1.MysqlUser) string {
+	if cr.Spec.Username != "" {
+		return cr.Spec.Username
+	}
+	return cr.Name
+}
+
+// GetPassword plain text password in secret of spec.passwordSecret, resource version of secret is returned as well
+func GetPassword(ctx context.Context, c client.Client, cr *rdsv1alpha1.MysqlUser) (password string, version string, err error) {
+	var secret corev1.Secret
+	if err = c.Get(ctx, client.ObjectKey{Namespace: cr.Namespace, Name: cr.Spec.PasswordSecret.Name}, &secret); err != nil {
+		return "", "", err
+	}
+
+	value, ok := secret.Data[cr.Spec.PasswordSecret.Key]
+	if !ok {
+		return "", "", fmt.Errorf("key %s is not found in secret %s/%s", cr.Spec.PasswordSecret.Key, secret.Namespace, secret.Name)
+	}
+	return string(value), secret.ResourceVersion, nil
+}
+
+// GetReferenceNamespace namespace of referenced CR, it is namespace of MysqlUser CR. reference to CR of other namespace is refused,
+// otherwise any user who can create MysqlUser is able to create account on Mysql CR of other tenants
+func GetReferenceNamespace(cr *rdsv1alpha1.MysqlUser, ref rdsv1alpha1.CRReference) (namespace string, err error) {
+	if ref.Namespace != nil && *ref.Namespace != "" && *ref.Namespace != cr.Namespace {
+		return "", fmt.Errorf("%w, [mysqluser=%s/%s] [reference=%s/%s]", types.ErrReferenceRefused, cr.Namespace, cr.Name, *ref.Namespace, ref.Name)
+	}
+	return cr.Namespace, nil
+}
+
+// checkReferences check Mysql and ProxySQL CRs referenced by MysqlUser CR are in its namespace
+func checkReferences(cr *rdsv1alpha1.MysqlUser) (err error) {
+	if _, err = GetReferenceNamespace(cr, cr.Spec.Mysql); err != nil {
+		return err
+	}
+	if cr.Spec.ProxySQL != nil {
+		_, err = GetReferenceNamespace(cr, cr.Spec.ProxySQL.CRReference)
+	}
+	return err
+}
+
+// convertGrants grants of CR to mysql package grants
+func convertGrants(grants []rdsv1alpha1.MysqlUserGrant) (result []mysql.UserGrant) {
+	for _, grant := range grants {
+		result = append(result, mysql.UserGrant{Privileges: grant.Privileges, Target: grant.DatabaseTarget})
+	}
+	return result
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/jinzhu/copier"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -55,19 +56,29 @@ func (t *ProxySQLBuilder) buildProxySQLVolume() (data []corev1.Volume) {
 	return
 }
 
+// GetMaxWriters return max writer mysql instances of proxysql writer hostgroup
+func GetMaxWriters(cr *rdsv1alpha1.ProxySQL) int {
+	if cr.Spec.ClusterMode != rdsv1alpha1.ModeMGRMP {
+		return 1
+	}
+
+	if cr.Spec.MaxWriters != nil && *cr.Spec.MaxWriters > 0 {
+		return *cr.Spec.MaxWriters
+	}
+
+	return 3
+}
+
 // buildProxySQLEnvs generate pod environments variables
 func (t *ProxySQLBuilder) buildProxySQLEnvs() (data []corev1.EnvVar) {
 	var adminCredentials string
-	var maxWriters = 1
 
 	for _, adminUser := range t.CR.Spec.AdminUsers {
 		adminCredentials += adminUser.Username + ":" + hutil.Base64Decode(adminUser.Password) + ";"
 	}
 	adminCredentials = base64.StdEncoding.EncodeToString([]byte(strings.Trim(adminCredentials, ";")))
 
-	if t.CR.Spec.ClusterMode == rdsv1alpha1.ModeMGRMP {
-		maxWriters = 3
-	}
+	maxWriters := GetMaxWriters(t.CR)
 
 	data = []corev1.EnvVar{
 		{Name: "POD_IP", ValueFrom: &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: "status.podIP"}}},
@@ -144,7 +155,8 @@ func (t *ProxySQLBuilder) BuildSts() (sts *appsv1.StatefulSet, err error) {
 	podTemplateSpec.Spec.Containers = []corev1.Container{t.buildProxySQLContainer()}
 	podTemplateSpec.Spec.PriorityClassName = t.CR.Spec.PriorityClassName
 	podTemplateSpec.Spec.Volumes = t.buildProxySQLVolume()
-	podTemplateSpec.Spec.Affinity = t.CR.Spec.Affinity
+	podTemplateSpec.Spec.Affinity = reconciler.DefaultAffinity(t.CR.Spec.Affinity, BuildProxySQLLabels(t.CR))
+	podTemplateSpec.Spec.TopologySpreadConstraints = reconciler.DefaultTopologySpreadConstraints(BuildProxySQLLabels(t.CR))
 	podTemplateSpec.Spec.Tolerations = t.CR.Spec.Tolerations
 
 	quantity, err := resource.ParseQuantity(t.CR.Spec.StorageSize)
@@ -163,6 +175,15 @@ func (t *ProxySQLBuilder) BuildSts() (sts *appsv1.StatefulSet, err error) {
 	return sts, nil
 }
 
+// BuildPodDisruptionBudget generate pdb of proxysql pods, one pod is evicted at a time
+func (t *ProxySQLBuilder) BuildPodDisruptionBudget() *policyv1.PodDisruptionBudget {
+	var replicas int32 = 1
+	if t.CR.Spec.Replicas != nil {
+		replicas = *t.CR.Spec.Replicas
+	}
+	return reconciler.BuildPodDisruptionBudget(t.CR.Name+"-proxysql", t.CR.Namespace, BuildProxySQLLabels(t.CR), replicas, 1)
+}
+
 // BuildService generate proxysql statefulset service
 func (t *ProxySQLBuilder) BuildService() (svc *corev1.Service) {
 	var spec corev1.ServiceSpec
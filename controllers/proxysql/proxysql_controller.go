@@ -3,29 +3,44 @@ package proxysql
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	ctrlbuilder "sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	mysqlcontrollers "github.com/hakur/rds-operator/controllers/mysql"
+	mysqlbuilder "github.com/hakur/rds-operator/controllers/mysql/builder"
+	mysqluser "github.com/hakur/rds-operator/controllers/mysql_user"
 	"github.com/hakur/rds-operator/controllers/proxysql/builder"
 	"github.com/hakur/rds-operator/pkg/mysql"
 	"github.com/hakur/rds-operator/pkg/reconciler"
 	"github.com/hakur/rds-operator/pkg/types"
 	"github.com/hakur/rds-operator/util"
 	hutil "github.com/hakur/util"
+	"github.com/sirupsen/logrus"
 )
 
 const (
 	// Finalizer proxysql CR delete mark
 	Finalizer = "proxysql.rds.hakurei.cn/v1alpha1"
+	// MysqlUserProxySQLIndex field index of MysqlUser CR, value is namespace/name of ProxySQL CR which account is registered in
+	MysqlUserProxySQLIndex = "spec.proxySQL"
 )
 
 // ProxySQLReconciler reconciles a ProxySQL object
@@ -33,6 +48,8 @@ type ProxySQLReconciler struct {
 	client.Client
 	Scheme     *runtime.Scheme
 	RestConfig *rest.Config
+	// Recorder emit events of ProxySQL CR, such as sync failure and readiness change
+	Recorder record.EventRecorder
 }
 
 //+kubebuilder:rbac:groups=rds.hakurei.cn,resources=proxysqls,verbs=get;list;watch;create;update;patch;delete
@@ -47,10 +64,63 @@ func (t *ProxySQLReconciler) Reconcile(ctx context.Context, req ctrl.Request) (r
 	}
 
 	if err = t.checkDeleteOrApply(ctx, cr); err != nil {
+		// statefulset deleted by volume expansion is created by later reconcile after it is gone, expansion status is kept meanwhile
+		if errors.Is(err, types.ErrStatefulSetRecreating) {
+			if updateErr := t.Status().Update(ctx, cr); updateErr != nil {
+				return r, fmt.Errorf("status update failed -> %w", updateErr)
+			}
+			return ctrl.Result{RequeueAfter: time.Second * 2}, nil
+		}
+		if client.IgnoreNotFound(err) != nil {
+			reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeWarning, "ApplyFailed", err.Error())
+		}
 		return r, client.IgnoreNotFound(err)
 	}
 
-	// write data to proxysql server
+	if !cr.GetDeletionTimestamp().IsZero() {
+		return ctrl.Result{}, nil
+	}
+
+	sts := new(appsv1.StatefulSet)
+	if err = t.Get(ctx, client.ObjectKey{Namespace: cr.Namespace, Name: cr.Name + "-proxysql"}, sts); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return r, err
+		}
+		sts = nil
+	}
+	ready := reconciler.SetStatefulSetConditions(t.Recorder, cr, &cr.Status.Conditions, sts)
+
+	// paused proxysql servers are not written, DBA may change them by hand
+	if !reconciler.IsPaused(cr) {
+		err = t.syncProxySQLServers(ctx, cr)
+	}
+
+	cr.Status.ObservedGeneration = cr.Generation
+	if updateErr := t.Status().Update(ctx, cr); updateErr != nil {
+		return r, fmt.Errorf("status update failed -> %w", updateErr)
+	}
+
+	if err != nil {
+		r.Requeue = true
+		r.RequeueAfter = time.Second * 3
+		return r, err
+	}
+
+	// statefulset status change is not watched, readiness is checked periodically
+	if !ready {
+		return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+	}
+
+	// capacity of expanded pvc is not watched, expansion progress is checked periodically
+	if cr.Status.VolumeExpansion != nil && cr.Status.VolumeExpansion.Phase == rdsv1alpha1.VolumeExpansionPhaseResizing {
+		return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// syncProxySQLServers write data to running proxysql pods, Degraded condition is set when any pod is not synced
+func (t *ProxySQLReconciler) syncProxySQLServers(ctx context.Context, cr *rdsv1alpha1.ProxySQL) (err error) {
 	var proxysqlPods corev1.PodList
 	if err = t.List(ctx, &proxysqlPods, client.InNamespace(cr.Namespace), client.MatchingLabels(builder.BuildProxySQLLabels(cr))); err == nil && client.IgnoreNotFound(err) == nil {
 		for _, pod := range proxysqlPods.Items {
@@ -64,24 +134,134 @@ func (t *ProxySQLReconciler) Reconcile(ctx context.Context, req ctrl.Request) (r
 				Password: hutil.Base64Decode(cr.Spec.ClusterUser.Password),
 			}
 			if err = t.syncProxySQLData(ctx, cr, dsn); err != nil {
-				r.Requeue = true
-				r.RequeueAfter = time.Second * 3
-				return r, err
+				reconciler.SetCondition(t.Recorder, cr, &cr.Status.Conditions, metav1.Condition{Type: rdsv1alpha1.ConditionDegraded, Status: metav1.ConditionTrue,
+					Reason: "SyncFailed", Message: fmt.Sprintf("sync data to proxysql pod %s failed -> %s", pod.Name, err.Error())})
+				return err
 			}
 		}
 	}
-
-	return ctrl.Result{}, nil
+	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (t *ProxySQLReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &rdsv1alpha1.MysqlUser{}, MysqlUserProxySQLIndex, indexMysqlUserProxySQL); err != nil {
+		return err
+	}
+
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&rdsv1alpha1.ProxySQL{}).
 		Owns(&corev1.Service{}).Owns(&appsv1.StatefulSet{}).Owns(&corev1.ConfigMap{}).Owns(&corev1.Secret{}).Owns(&rdsv1alpha1.Mysql{}).
+		Watches(&source.Kind{Type: &rdsv1alpha1.Mysql{}}, handler.EnqueueRequestsFromMapFunc(t.findProxySQLsForMysql), ctrlbuilder.WithPredicates(mysqlcontrollers.TopologyChangedPredicate())).
+		Watches(&source.Kind{Type: &rdsv1alpha1.MysqlUser{}}, handler.EnqueueRequestsFromMapFunc(t.findProxySQLsForMysqlUser)).
 		Complete(t)
 }
 
+// findProxySQLsForMysql ProxySQL CRs use Mysql CR as backend, they are reconciled when Mysql CR changed, such as mysql members scaled in
+func (t *ProxySQLReconciler) findProxySQLsForMysql(obj client.Object) (requests []reconcile.Request) {
+	var proxysqls rdsv1alpha1.ProxySQLList
+	if err := t.List(context.Background(), &proxysqls); err != nil {
+		return nil
+	}
+
+	for _, v := range proxysqls.Items {
+		if v.Spec.Mysqls.CRD == nil || v.Spec.Mysqls.CRD.Name != obj.GetName() {
+			continue
+		}
+
+		namespace := v.Namespace
+		if v.Spec.Mysqls.CRD.Namespace != nil {
+			namespace = *v.Spec.Mysqls.CRD.Namespace
+		}
+
+		if namespace == obj.GetNamespace() {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&v)})
+		}
+	}
+	return requests
+}
+
+// findProxySQLsForMysqlUser ProxySQL CR is reconciled when MysqlUser CR registered in it changed, such as password is changed or CR is deleting
+func (t *ProxySQLReconciler) findProxySQLsForMysqlUser(obj client.Object) (requests []reconcile.Request) {
+	user, ok := obj.(*rdsv1alpha1.MysqlUser)
+	if !ok || user.Spec.ProxySQL == nil {
+		return nil
+	}
+
+	namespace, err := mysqluser.GetReferenceNamespace(user, user.Spec.ProxySQL.CRReference)
+	if err != nil {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: client.ObjectKey{Namespace: namespace, Name: user.Spec.ProxySQL.Name}}}
+}
+
+// indexMysqlUserProxySQL index MysqlUser CR by namespace/name of ProxySQL CR which account is registered in, reference to other namespace is not indexed
+func indexMysqlUserProxySQL(obj client.Object) []string {
+	user, ok := obj.(*rdsv1alpha1.MysqlUser)
+	if !ok || user.Spec.ProxySQL == nil {
+		return nil
+	}
+
+	namespace, err := mysqluser.GetReferenceNamespace(user, user.Spec.ProxySQL.CRReference)
+	if err != nil {
+		return nil
+	}
+	return []string{namespace + "/" + user.Spec.ProxySQL.Name}
+}
+
+// generateRegisteredUsers mysql_users rows of MysqlUser CRs which are registered in proxysql, accounts of deleting CRs are removed from proxysql.
+// usernames of accounts whose password is not read are returned as kept, their rows of proxysql are not changed, so one broken MysqlUser does not block sync
+func (t *ProxySQLReconciler) generateRegisteredUsers(ctx context.Context, cr *rdsv1alpha1.ProxySQL) (users []*mysql.TableMysqlUsers, kept []string, err error) {
+	var mysqlUsers rdsv1alpha1.MysqlUserList
+	if err = t.List(ctx, &mysqlUsers, client.InNamespace(cr.Namespace), client.MatchingFields{MysqlUserProxySQLIndex: cr.Namespace + "/" + cr.Name}); err != nil {
+		return nil, nil, err
+	}
+
+	for _, v := range mysqlUsers.Items {
+		if !v.GetDeletionTimestamp().IsZero() {
+			continue
+		}
+
+		password, _, err := mysqluser.GetPassword(ctx, t.Client, &v)
+		t.setUserRegistered(ctx, cr, &v, err)
+		if err != nil {
+			kept = append(kept, mysqluser.GetUsername(&v))
+			continue
+		}
+
+		users = append(users, &mysql.TableMysqlUsers{
+			Username:         mysqluser.GetUsername(&v),
+			Password:         password,
+			DefaultSchema:    sql.NullString{String: "mysql"},
+			Frontend:         true,
+			Backend:          true,
+			DefaultHostgroup: v.Spec.ProxySQL.DefaultHostGroup,
+			MaxConnections:   v.Spec.ProxySQL.MaxConnections,
+		})
+	}
+	return users, kept, nil
+}
+
+// setUserRegistered set ProxySQLRegistered condition of MysqlUser CR, it is False when password is not read. status is written only when condition changed
+func (t *ProxySQLReconciler) setUserRegistered(ctx context.Context, cr *rdsv1alpha1.ProxySQL, user *rdsv1alpha1.MysqlUser, err error) {
+	condition := metav1.Condition{Type: rdsv1alpha1.MysqlUserConditionProxySQLRegistered, Status: metav1.ConditionTrue, Reason: "Registered",
+		Message: fmt.Sprintf("account is registered in proxysql %s", cr.Name)}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "PasswordNotFound"
+		condition.Message = fmt.Sprintf("account of proxysql %s is kept unchanged, read password failed -> %s", cr.Name, err.Error())
+	}
+
+	if !reconciler.SetCondition(t.Recorder, user, &user.Status.Conditions, condition) {
+		return
+	}
+
+	// condition of other proxysql pods is written by next sync when status of MysqlUser is changed meanwhile
+	if updateErr := t.Status().Update(ctx, user); updateErr != nil && !apierrors.IsConflict(updateErr) {
+		logrus.WithFields(map[string]interface{}{"err": updateErr.Error(), "mysqluser": user.Namespace + "/" + user.Name}).Warn("mysqluser status update failed")
+	}
+}
+
 func (t *ProxySQLReconciler) checkDeleteOrApply(ctx context.Context, cr *rdsv1alpha1.ProxySQL) (err error) {
 	if cr.GetDeletionTimestamp().IsZero() {
 		// add finalizer mark to CR,make sure CR clean is done by controller first
@@ -91,6 +271,10 @@ func (t *ProxySQLReconciler) checkDeleteOrApply(ctx context.Context, cr *rdsv1al
 				return err
 			}
 		}
+		// paused CR sub resources are kept as they are, DBA may change them by hand
+		if reconciler.IsPaused(cr) {
+			return nil
+		}
 		// apply create CR sub resources
 		return t.apply(ctx, cr)
 	} else {
@@ -134,10 +318,19 @@ func (t *ProxySQLReconciler) applyProxySQL(ctx context.Context, cr *rdsv1alpha1.
 		return err
 	}
 
+	// pvc of existing proxysql pods are expanded when storage size grows, statefulset is recreated without deleting pods
+	if cr.Status.VolumeExpansion, err = reconciler.ExpandStatefulSetVolumes(t.Client, ctx, t.Recorder, cr, statefulset, cr.Status.VolumeExpansion); err != nil {
+		return err
+	}
+
 	if err := reconciler.ApplyStatefulSet(t.Client, ctx, statefulset, cr, t.Scheme); err != nil {
 		return err
 	}
 
+	if err := reconciler.ApplyPodDisruptionBudget(t.Client, ctx, proxysqlBuilder.BuildPodDisruptionBudget(), cr, t.Scheme); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -156,6 +349,17 @@ func (t *ProxySQLReconciler) clean(ctx context.Context, cr *rdsv1alpha1.ProxySQL
 		return fmt.Errorf("delete sub resource failed,[namespace=%s] [api=%s] [kind=%s] [cr=%s] , err is -> %s", cr.Namespace, cr.APIVersion, cr.Kind, cr.Name, err.Error())
 	}
 
+	var proxySQLPDBs policyv1.PodDisruptionBudgetList
+	if err = t.List(ctx, &proxySQLPDBs, client.InNamespace(cr.Namespace), client.MatchingLabels(builder.BuildProxySQLLabels(cr))); err == nil && client.IgnoreNotFound(err) == nil {
+		for _, v := range proxySQLPDBs.Items {
+			if err = t.Delete(ctx, &v); err != nil && client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("delete resource in [namespace=%s] [api=%s] [kind=%s] [name=%s] failed -> %s", v.Namespace, v.APIVersion, v.Kind, v.Name, err.Error())
+			}
+		}
+	} else {
+		return fmt.Errorf("delete sub resource failed,[namespace=%s] [api=%s] [kind=%s] [cr=%s] , err is -> %s", cr.Namespace, cr.APIVersion, cr.Kind, cr.Name, err.Error())
+	}
+
 	var proxyServices corev1.ServiceList
 	if err = t.List(ctx, &proxyServices, client.InNamespace(cr.Namespace), client.MatchingLabels(builder.BuildProxySQLLabels(cr))); err == nil && client.IgnoreNotFound(err) == nil {
 		for _, v := range proxyServices.Items {
@@ -225,6 +429,13 @@ func (t *ProxySQLReconciler) syncProxySQLData(ctx context.Context, cr *rdsv1alph
 			})
 		}
 
+		// MysqlUser CRs registered in this proxysql are frontend and backend users
+		registeredUsers, keptUsers, err := t.generateRegisteredUsers(ctx, cr)
+		if err != nil {
+			return err
+		}
+		addMysqlUsers = append(addMysqlUsers, registeredUsers...)
+
 		for i := 0; i < replicas; i++ {
 			addProxySQLServers = append(addProxySQLServers, &mysql.TableProxySQLServers{
 				Hostname: cr.Name + "-proxysql-" + strconv.Itoa(i) + "." + cr.Name + "-proxysql",
@@ -280,7 +491,8 @@ func (t *ProxySQLReconciler) syncProxySQLData(ctx context.Context, cr *rdsv1alph
 					break
 				}
 			}
-			if !found {
+			// row of MysqlUser whose password is not read is kept as it is
+			if !found && !util.InArray(keptUsers, a.Username) {
 				deleteMysqlUsers = append(deleteMysqlUsers, a)
 			}
 		}
@@ -325,6 +537,13 @@ func (t *ProxySQLReconciler) syncProxySQLData(ctx context.Context, cr *rdsv1alph
 			}
 		}
 
+		if cr.Spec.ClusterMode == rdsv1alpha1.ModeMGRMP {
+			if err = pa.SetGroupReplicationMaxWriters(ctx, types.ProxySQLWriterGroup, builder.GetMaxWriters(cr)); err != nil {
+				pa.Rollback(ctx)
+				return err
+			}
+		}
+
 		if err = pa.LoadMysqlServersToRuntime(ctx); err != nil {
 			return err
 		}
@@ -370,7 +589,27 @@ func (t *ProxySQLReconciler) generateMysqlServers(ctx context.Context, cr *rdsv1
 			port = *cr.Spec.Mysqls.CRD.Port
 		}
 
+		// members with unacknowledged errant transactions must not serve reads
+		var excluded []string
+		for _, member := range mysqlCR.Status.MemberStatuses {
+			if member.Quarantined {
+				excluded = append(excluded, member.Name)
+			}
+		}
+		// delayed replicas serve stale data
+		excluded = append(excluded, mysqlbuilder.GetDelayedReplicaHosts(&mysqlCR)...)
+		// members under maintenance do not serve reads, primary keeps serving writes until it is switched over
+		for _, host := range mysqlbuilder.GetMaintenanceHosts(&mysqlCR) {
+			if !util.InArray(mysqlCR.Status.Masters, host) {
+				excluded = append(excluded, host)
+			}
+		}
+
 		for i := 0; i < replicas; i++ {
+			if util.InArray(excluded, mysqlCR.Name+"-mysql-"+strconv.Itoa(i)) {
+				continue
+			}
+
 			servers = append(servers, &mysql.TableMysqlServers{
 				Hostname: mysqlCR.Name + "-mysql-" + strconv.Itoa(i) + "." + mysqlCR.Name + "-mysql",
 				Port:     port,
@@ -387,7 +626,7 @@ func (t *ProxySQLReconciler) generateMysqlServers(ctx context.Context, cr *rdsv1
 	}
 
 	// must set hostgroup id for mysql server if mysql is not group replication mode, other wise mysql_servers.hostgroup_id will not auto fix by proxysql server
-	if cr.Spec.ClusterMode == rdsv1alpha1.ModeSemiSync {
+	if cr.Spec.ClusterMode == rdsv1alpha1.ModeSemiSync || cr.Spec.ClusterMode == rdsv1alpha1.ModeAsync {
 		for k := range servers {
 			servers[k].HostGroupID = types.ProxySQLWriterGroup
 		}
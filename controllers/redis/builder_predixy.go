@@ -2,6 +2,7 @@ package redis
 
 import (
 	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	"github.com/hakur/rds-operator/pkg/reconciler"
 	"github.com/jinzhu/copier"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -109,7 +110,8 @@ func buildPredixyDeploy(cr *rdsv1alpha1.Redis) (deploy *appsv1.Deployment, err e
 	podTemplateSpec.ObjectMeta = metav1.ObjectMeta{Labels: buildPredixyLabels(cr)}
 	podTemplateSpec.Spec.Containers = []corev1.Container{buildPredixyContainer(cr)}
 	podTemplateSpec.Spec.ServiceAccountName = cr.Spec.ServiceAccountName
-	podTemplateSpec.Spec.Affinity = cr.Spec.Affinity
+	podTemplateSpec.Spec.Affinity = reconciler.DefaultAffinity(cr.Spec.Affinity, buildPredixyLabels(cr))
+	podTemplateSpec.Spec.TopologySpreadConstraints = reconciler.DefaultTopologySpreadConstraints(buildPredixyLabels(cr))
 	podTemplateSpec.Spec.Tolerations = cr.Spec.Tolerations
 	podTemplateSpec.Spec.PriorityClassName = cr.Spec.PriorityClassName
 	podTemplateSpec.Spec.Volumes = []corev1.Volume{
@@ -2,6 +2,7 @@ package redis
 
 import (
 	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	"github.com/hakur/rds-operator/pkg/reconciler"
 	"github.com/jinzhu/copier"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -110,7 +111,8 @@ func buildProxyDeploy(cr *rdsv1alpha1.Redis) (deploy *appsv1.Deployment, err err
 	podTemplateSpec.ObjectMeta = metav1.ObjectMeta{Labels: buildProxyLabels(cr)}
 	podTemplateSpec.Spec.Containers = []corev1.Container{buildProxyContainer(cr)}
 	podTemplateSpec.Spec.ServiceAccountName = cr.Spec.ServiceAccountName
-	podTemplateSpec.Spec.Affinity = cr.Spec.Affinity
+	podTemplateSpec.Spec.Affinity = reconciler.DefaultAffinity(cr.Spec.Affinity, buildProxyLabels(cr))
+	podTemplateSpec.Spec.TopologySpreadConstraints = reconciler.DefaultTopologySpreadConstraints(buildProxyLabels(cr))
 	podTemplateSpec.Spec.Tolerations = cr.Spec.Tolerations
 	podTemplateSpec.Spec.PriorityClassName = cr.Spec.PriorityClassName
 	podTemplateSpec.Spec.Volumes = []corev1.Volume{
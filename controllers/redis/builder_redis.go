@@ -6,10 +6,21 @@ import (
 	"github.com/jinzhu/copier"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// redisRoleLabelName pod label of current redis server role, selected by masters and replicas services
+const redisRoleLabelName = "role.redis.hakurei.cn"
+
+const (
+	// redisRoleMaster role label value of redis cluster masters
+	redisRoleMaster = "master"
+	// redisRoleReplica role label value of redis cluster replicas
+	redisRoleReplica = "replica"
+)
+
 // buildRedisSts generate statefulset of redis servers
 func buildRedisSts(cr *rdsv1alpha1.Redis) (sts *appsv1.StatefulSet, err error) {
 	var spec appsv1.StatefulSetSpec
@@ -43,7 +54,8 @@ func buildRedisSts(cr *rdsv1alpha1.Redis) (sts *appsv1.StatefulSet, err error) {
 	}
 
 	podTemplateSpec.Spec.ServiceAccountName = cr.Spec.ServiceAccountName
-	podTemplateSpec.Spec.Affinity = cr.Spec.Affinity
+	podTemplateSpec.Spec.Affinity = reconciler.DefaultAffinity(cr.Spec.Affinity, buildRedisLabels(cr))
+	podTemplateSpec.Spec.TopologySpreadConstraints = reconciler.DefaultTopologySpreadConstraints(buildRedisLabels(cr))
 	podTemplateSpec.Spec.Tolerations = cr.Spec.Tolerations
 	podTemplateSpec.Spec.PriorityClassName = cr.Spec.PriorityClassName
 	podTemplateSpec.Spec.Volumes = []corev1.Volume{
@@ -67,6 +79,15 @@ func buildRedisSts(cr *rdsv1alpha1.Redis) (sts *appsv1.StatefulSet, err error) {
 	return
 }
 
+// buildPDB generate pdb of redis servers or proxy pods, one pod is evicted at a time
+func buildPDB(cr *rdsv1alpha1.Redis, name string, labels map[string]string, replicas *int32) *policyv1.PodDisruptionBudget {
+	var total int32 = 1
+	if replicas != nil {
+		total = *replicas
+	}
+	return reconciler.BuildPodDisruptionBudget(name, cr.Namespace, labels, total, 1)
+}
+
 // buildRedisSvc gernate servers
 func buildRedisSvc(cr *rdsv1alpha1.Redis) (svc *corev1.Service) {
 	var spec corev1.ServiceSpec
@@ -94,6 +115,14 @@ func buildRedisSvc(cr *rdsv1alpha1.Redis) (svc *corev1.Service) {
 	return svc
 }
 
+// buildRedisRoleSvc generate service of redis servers with role label value, such as masters service for writes
+func buildRedisRoleSvc(cr *rdsv1alpha1.Redis, name string, role string) (svc *corev1.Service) {
+	svc = buildRedisSvc(cr)
+	svc.Name = cr.Name + "-redis-" + name
+	svc.Spec.Selector[redisRoleLabelName] = role
+	return svc
+}
+
 // buildRedisLabels generate labels from cr resource, used for pod list filter
 func buildRedisLabels(cr *rdsv1alpha1.Redis) (labels map[string]string) {
 	labels = map[string]string{
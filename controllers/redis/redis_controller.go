@@ -2,15 +2,25 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"net"
+	"sort"
+	"time"
 
 	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
 	"github.com/hakur/rds-operator/pkg/reconciler"
+	"github.com/hakur/rds-operator/pkg/redis"
+	"github.com/hakur/rds-operator/pkg/types"
 	"github.com/hakur/rds-operator/util"
+	hutil "github.com/hakur/util"
 	monitorv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
+	"github.com/sirupsen/logrus"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -25,6 +35,8 @@ const RedisFinlizer = "redis.rds.hakurei.cn/v1alpha1"
 type RedisReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+	// Recorder emit events of Redis CR, such as sub resources apply failure and readiness change
+	Recorder record.EventRecorder
 }
 
 // SetupWithManager sets up the controller with the Manager.
@@ -43,12 +55,59 @@ func (t *RedisReconciler) Reconcile(ctx context.Context, req ctrl.Request) (r ct
 	}
 
 	if err = t.checkDeleteOrApply(ctx, cr); err != nil {
+		// statefulset deleted by volume expansion is created by later reconcile after it is gone, expansion status is kept meanwhile
+		if errors.Is(err, types.ErrStatefulSetRecreating) {
+			if updateErr := t.Status().Update(ctx, cr); updateErr != nil {
+				return r, fmt.Errorf("status update failed -> %w", updateErr)
+			}
+			return ctrl.Result{RequeueAfter: time.Second * 2}, nil
+		}
+		if client.IgnoreNotFound(err) != nil {
+			reconciler.RecordEvent(t.Recorder, cr, corev1.EventTypeWarning, "ApplyFailed", err.Error())
+		}
 		return r, client.IgnoreNotFound(err)
 	}
 
+	if cr.GetDeletionTimestamp().IsZero() {
+		ready, err := t.checkStatus(ctx, cr)
+		if err != nil {
+			return r, client.IgnoreNotFound(err)
+		}
+
+		// statefulset status change is not watched, readiness is checked periodically
+		if !ready {
+			return ctrl.Result{RequeueAfter: time.Second * 5}, nil
+		}
+
+		// redis cluster failover is done by redis servers, roles are checked periodically
+		return ctrl.Result{RequeueAfter: time.Second * 10}, nil
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// checkStatus update Ready and Degraded conditions by ready replicas of redis statefulset, masters are recorded in status and role labels of pods are updated
+func (t *RedisReconciler) checkStatus(ctx context.Context, cr *rdsv1alpha1.Redis) (ready bool, err error) {
+	sts := new(appsv1.StatefulSet)
+	if err = t.Get(ctx, client.ObjectKey{Namespace: cr.Namespace, Name: cr.Name + "-redis"}, sts); err != nil {
+		if client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+		sts = nil
+	}
+
+	ready = reconciler.SetStatefulSetConditions(t.Recorder, cr, &cr.Status.Conditions, sts)
+	if err = t.checkRoles(ctx, cr); err != nil {
+		return ready, err
+	}
+
+	cr.Status.ObservedGeneration = cr.Generation
+	if err = t.Status().Update(ctx, cr); err != nil {
+		return ready, fmt.Errorf("status update failed -> %w", err)
+	}
+	return ready, nil
+}
+
 // checkDeleteOrApply check cr should delete or apply
 func (t *RedisReconciler) checkDeleteOrApply(ctx context.Context, cr *rdsv1alpha1.Redis) (err error) {
 	if cr.GetDeletionTimestamp().IsZero() {
@@ -59,6 +118,10 @@ func (t *RedisReconciler) checkDeleteOrApply(ctx context.Context, cr *rdsv1alpha
 				return err
 			}
 		}
+		// paused CR sub resources are kept as they are, DBA may change them by hand
+		if reconciler.IsPaused(cr) {
+			return nil
+		}
 		// add bootstrap process worker
 		return t.apply(ctx, cr)
 	} else {
@@ -85,17 +148,37 @@ func (t *RedisReconciler) apply(ctx context.Context, cr *rdsv1alpha1.Redis) (err
 	}
 
 	redisService := buildRedisSvc(cr)
+	masterService := buildRedisRoleSvc(cr, "masters", redisRoleMaster)
+	replicaService := buildRedisRoleSvc(cr, "replicas", redisRoleReplica)
 	secret := buildSecret(cr)
 
+	// pvc of existing redis servers are expanded when storage size grows, statefulset is recreated without deleting pods
+	if cr.Status.VolumeExpansion, err = reconciler.ExpandStatefulSetVolumes(t.Client, ctx, t.Recorder, cr, statefulset, cr.Status.VolumeExpansion); err != nil {
+		return err
+	}
+
 	// redis servers
 	if err = reconciler.ApplyStatefulSet(t.Client, ctx, statefulset, cr, t.Scheme); err != nil {
 		return err
 	}
 
+	if err = reconciler.ApplyPodDisruptionBudget(t.Client, ctx, buildPDB(cr, statefulset.Name, buildRedisLabels(cr), statefulset.Spec.Replicas), cr, t.Scheme); err != nil {
+		return err
+	}
+
 	if err = reconciler.ApplyService(t.Client, ctx, redisService, cr, t.Scheme); err != nil {
 		return err
 	}
 
+	// role services select pods by role label of status check, they follow redis cluster failover
+	if err = reconciler.ApplyService(t.Client, ctx, masterService, cr, t.Scheme); err != nil {
+		return err
+	}
+
+	if err = reconciler.ApplyService(t.Client, ctx, replicaService, cr, t.Scheme); err != nil {
+		return err
+	}
+
 	if err = reconciler.ApplySecret(t.Client, ctx, secret, cr, t.Scheme); err != nil {
 		return err
 	}
@@ -110,6 +193,11 @@ func (t *RedisReconciler) apply(ctx context.Context, cr *rdsv1alpha1.Redis) (err
 			return err
 		}
 
+		proxyPDB := buildPDB(cr, redisClusterPorxydeployment.Name, buildProxyLabels(cr), redisClusterPorxydeployment.Spec.Replicas)
+		if err = reconciler.ApplyPodDisruptionBudget(t.Client, ctx, proxyPDB, cr, t.Scheme); err != nil {
+			return err
+		}
+
 		redisClusterProxyService := buildProxySvc(cr)
 		if err = reconciler.ApplyService(t.Client, ctx, redisClusterProxyService, cr, t.Scheme); err != nil {
 			return err
@@ -126,6 +214,11 @@ func (t *RedisReconciler) apply(ctx context.Context, cr *rdsv1alpha1.Redis) (err
 			return err
 		}
 
+		predixyPDB := buildPDB(cr, predixydeployment.Name, buildPredixyLabels(cr), predixydeployment.Spec.Replicas)
+		if err = reconciler.ApplyPodDisruptionBudget(t.Client, ctx, predixyPDB, cr, t.Scheme); err != nil {
+			return err
+		}
+
 		predixyService := buildPredixySvc(cr)
 		if err = reconciler.ApplyService(t.Client, ctx, predixyService, cr, t.Scheme); err != nil {
 			return err
@@ -145,6 +238,58 @@ func (t *RedisReconciler) apply(ctx context.Context, cr *rdsv1alpha1.Redis) (err
 	return nil
 }
 
+// checkRoles query role of running redis pods, masters are recorded in status. role labels selected by masters and replicas services are not changed when CR is paused
+func (t *RedisReconciler) checkRoles(ctx context.Context, cr *rdsv1alpha1.Redis) (err error) {
+	var pods corev1.PodList
+	if err = t.List(ctx, &pods, client.InNamespace(cr.Namespace), client.MatchingLabels(buildRedisLabels(cr))); err != nil {
+		return err
+	}
+
+	var password string
+	if cr.Spec.Password != nil {
+		password = hutil.Base64Decode(*cr.Spec.Password)
+	}
+
+	remoteCtx, cancel := context.WithTimeout(ctx, time.Second*10)
+	defer cancel()
+
+	cr.Status.Masters = nil
+	for k := range pods.Items {
+		pod := &pods.Items[k]
+		// unreachable pod is removed from role services
+		var role string
+		if pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" && pod.DeletionTimestamp.IsZero() {
+			serverRole, err := redis.Role(remoteCtx, net.JoinHostPort(pod.Status.PodIP, "6379"), password)
+			if err != nil {
+				logrus.WithFields(map[string]interface{}{"cr": cr.Namespace + "/" + cr.Name, "pod": pod.Name, "err": err.Error()}).Debug("redis query role failed")
+			} else if serverRole == redis.RoleMaster {
+				role = redisRoleMaster
+				cr.Status.Masters = append(cr.Status.Masters, pod.Name)
+			} else if serverRole == redis.RoleSlave {
+				role = redisRoleReplica
+			}
+		}
+
+		if reconciler.IsPaused(cr) || pod.Labels[redisRoleLabelName] == role {
+			continue
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		if role != "" {
+			pod.Labels[redisRoleLabelName] = role
+		} else {
+			delete(pod.Labels, redisRoleLabelName)
+		}
+
+		if err = t.Patch(ctx, pod, patch); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	sort.Strings(cr.Status.Masters)
+
+	return nil
+}
+
 func (t *RedisReconciler) applyMonitor(ctx context.Context, cr *rdsv1alpha1.Redis) (err error) {
 	data := BuildPodMonitor(cr)
 	var oldData monitorv1.PodMonitor
@@ -252,6 +397,40 @@ func (t *RedisReconciler) clean(ctx context.Context, cr *rdsv1alpha1.Redis) (err
 		return fmt.Errorf("delete sub resource failed,[namespace=%s] [api=%s] [kind=%s] [cr=%s] , err is -> %s", cr.Namespace, cr.APIVersion, cr.Kind, cr.Name, err.Error())
 	}
 
+	// remove cr all pod disruption budgets
+	var redisPDBs policyv1.PodDisruptionBudgetList
+	if err = t.List(ctx, &redisPDBs, client.InNamespace(cr.Namespace), client.MatchingLabels(buildRedisLabels(cr))); err == nil && client.IgnoreNotFound(err) == nil {
+		for _, v := range redisPDBs.Items {
+			if err = t.Delete(ctx, &v); err != nil && client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("delete resource in [namespace=%s] [api=%s] [kind=%s] [name=%s] failed -> %s", v.Namespace, v.APIVersion, v.Kind, v.Name, err.Error())
+			}
+		}
+	} else {
+		return fmt.Errorf("delete sub resource failed,[namespace=%s] [api=%s] [kind=%s] [cr=%s] , err is -> %s", cr.Namespace, cr.APIVersion, cr.Kind, cr.Name, err.Error())
+	}
+
+	var redisClusterProxyPDBs policyv1.PodDisruptionBudgetList
+	if err = t.List(ctx, &redisClusterProxyPDBs, client.InNamespace(cr.Namespace), client.MatchingLabels(buildProxyLabels(cr))); err == nil && client.IgnoreNotFound(err) == nil {
+		for _, v := range redisClusterProxyPDBs.Items {
+			if err = t.Delete(ctx, &v); err != nil && client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("delete resource in [namespace=%s] [api=%s] [kind=%s] [name=%s] failed -> %s", v.Namespace, v.APIVersion, v.Kind, v.Name, err.Error())
+			}
+		}
+	} else {
+		return fmt.Errorf("delete sub resource failed,[namespace=%s] [api=%s] [kind=%s] [cr=%s] , err is -> %s", cr.Namespace, cr.APIVersion, cr.Kind, cr.Name, err.Error())
+	}
+
+	var predixyPDBs policyv1.PodDisruptionBudgetList
+	if err = t.List(ctx, &predixyPDBs, client.InNamespace(cr.Namespace), client.MatchingLabels(buildPredixyLabels(cr))); err == nil && client.IgnoreNotFound(err) == nil {
+		for _, v := range predixyPDBs.Items {
+			if err = t.Delete(ctx, &v); err != nil && client.IgnoreNotFound(err) != nil {
+				return fmt.Errorf("delete resource in [namespace=%s] [api=%s] [kind=%s] [name=%s] failed -> %s", v.Namespace, v.APIVersion, v.Kind, v.Name, err.Error())
+			}
+		}
+	} else {
+		return fmt.Errorf("delete sub resource failed,[namespace=%s] [api=%s] [kind=%s] [cr=%s] , err is -> %s", cr.Namespace, cr.APIVersion, cr.Kind, cr.Name, err.Error())
+	}
+
 	// add pvc life deadline annotaion mark
 	if err = reconciler.AddPVCRetentionMark(t.Client, ctx, cr.Namespace, reconciler.BuildCRPVCLabels(cr, cr)); err != nil {
 		return err
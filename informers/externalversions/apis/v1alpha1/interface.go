@@ -39,6 +39,8 @@ type Interface interface {
 	Mysqls() MysqlInformer
 	// MysqlBackups returns a MysqlBackupInformer.
 	MysqlBackups() MysqlBackupInformer
+	// MysqlUsers returns a MysqlUserInformer.
+	MysqlUsers() MysqlUserInformer
 	// ProxySQLs returns a ProxySQLInformer.
 	ProxySQLs() ProxySQLInformer
 	// Redises returns a RedisInformer.
@@ -66,6 +68,11 @@ func (v *version) MysqlBackups() MysqlBackupInformer {
 	return &mysqlBackupInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
 }
 
+// MysqlUsers returns a MysqlUserInformer.
+func (v *version) MysqlUsers() MysqlUserInformer {
+	return &mysqlUserInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // ProxySQLs returns a ProxySQLInformer.
 func (v *version) ProxySQLs() ProxySQLInformer {
 	return &proxySQLInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -0,0 +1,101 @@
+/*
+MIT License
+
+Copyright (c) 2021 Software Authors
+
+Software Authors are:
+    Xing Yu, email: yuxing951@gmail.com,yuxing951@hotmail.com
+    Yi Zhou, email: 6098550@qq.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+// Code generated by informer-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	"context"
+	time "time"
+
+	apisv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	versioned "github.com/hakur/rds-operator/clientset/versioned"
+	internalinterfaces "github.com/hakur/rds-operator/informers/externalversions/internalinterfaces"
+	v1alpha1 "github.com/hakur/rds-operator/listers/apis/v1alpha1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+	watch "k8s.io/apimachinery/pkg/watch"
+	cache "k8s.io/client-go/tools/cache"
+)
+
+// MysqlUserInformer provides access to a shared informer and lister for
+// MysqlUsers.
+type MysqlUserInformer interface {
+	Informer() cache.SharedIndexInformer
+	Lister() v1alpha1.MysqlUserLister
+}
+
+type mysqlUserInformer struct {
+	factory          internalinterfaces.SharedInformerFactory
+	tweakListOptions internalinterfaces.TweakListOptionsFunc
+	namespace        string
+}
+
+// NewMysqlUserInformer constructs a new informer for MysqlUser type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewMysqlUserInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers) cache.SharedIndexInformer {
+	return NewFilteredMysqlUserInformer(client, namespace, resyncPeriod, indexers, nil)
+}
+
+// NewFilteredMysqlUserInformer constructs a new informer for MysqlUser type.
+// Always prefer using an informer factory to get a shared informer instead of getting an independent
+// one. This reduces memory footprint and number of connections to the server.
+func NewFilteredMysqlUserInformer(client versioned.Interface, namespace string, resyncPeriod time.Duration, indexers cache.Indexers, tweakListOptions internalinterfaces.TweakListOptionsFunc) cache.SharedIndexInformer {
+	return cache.NewSharedIndexInformer(
+		&cache.ListWatch{
+			ListFunc: func(options v1.ListOptions) (runtime.Object, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.ApisV1alpha1().MysqlUsers(namespace).List(context.TODO(), options)
+			},
+			WatchFunc: func(options v1.ListOptions) (watch.Interface, error) {
+				if tweakListOptions != nil {
+					tweakListOptions(&options)
+				}
+				return client.ApisV1alpha1().MysqlUsers(namespace).Watch(context.TODO(), options)
+			},
+		},
+		&apisv1alpha1.MysqlUser{},
+		resyncPeriod,
+		indexers,
+	)
+}
+
+func (f *mysqlUserInformer) defaultInformer(client versioned.Interface, resyncPeriod time.Duration) cache.SharedIndexInformer {
+	return NewFilteredMysqlUserInformer(client, f.namespace, resyncPeriod, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc}, f.tweakListOptions)
+}
+
+func (f *mysqlUserInformer) Informer() cache.SharedIndexInformer {
+	return f.factory.InformerFor(&apisv1alpha1.MysqlUser{}, f.defaultInformer)
+}
+
+func (f *mysqlUserInformer) Lister() v1alpha1.MysqlUserLister {
+	return v1alpha1.NewMysqlUserLister(f.Informer().GetIndexer())
+}
@@ -45,6 +45,14 @@ type MysqlBackupListerExpansion interface{}
 // MysqlBackupNamespaceLister.
 type MysqlBackupNamespaceListerExpansion interface{}
 
+// MysqlUserListerExpansion allows custom methods to be added to
+// MysqlUserLister.
+type MysqlUserListerExpansion interface{}
+
+// MysqlUserNamespaceListerExpansion allows custom methods to be added to
+// MysqlUserNamespaceLister.
+type MysqlUserNamespaceListerExpansion interface{}
+
 // ProxySQLListerExpansion allows custom methods to be added to
 // ProxySQLLister.
 type ProxySQLListerExpansion interface{}
@@ -0,0 +1,110 @@
+/*
+MIT License
+
+Copyright (c) 2021 Software Authors
+
+Software Authors are:
+    Xing Yu, email: yuxing951@gmail.com,yuxing951@hotmail.com
+    Yi Zhou, email: 6098550@qq.com
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in all
+copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN THE
+SOFTWARE.
+*/
+// Code generated by lister-gen. DO NOT EDIT.
+
+package v1alpha1
+
+import (
+	v1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// MysqlUserLister helps list MysqlUsers.
+// All objects returned here must be treated as read-only.
+type MysqlUserLister interface {
+	// List lists all MysqlUsers in the indexer.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.MysqlUser, err error)
+	// MysqlUsers returns an object that can list and get MysqlUsers.
+	MysqlUsers(namespace string) MysqlUserNamespaceLister
+	MysqlUserListerExpansion
+}
+
+// mysqlUserLister implements the MysqlUserLister interface.
+type mysqlUserLister struct {
+	indexer cache.Indexer
+}
+
+// NewMysqlUserLister returns a new MysqlUserLister.
+func NewMysqlUserLister(indexer cache.Indexer) MysqlUserLister {
+	return &mysqlUserLister{indexer: indexer}
+}
+
+// List lists all MysqlUsers in the indexer.
+func (s *mysqlUserLister) List(selector labels.Selector) (ret []*v1alpha1.MysqlUser, err error) {
+	err = cache.ListAll(s.indexer, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.MysqlUser))
+	})
+	return ret, err
+}
+
+// MysqlUsers returns an object that can list and get MysqlUsers.
+func (s *mysqlUserLister) MysqlUsers(namespace string) MysqlUserNamespaceLister {
+	return mysqlUserNamespaceLister{indexer: s.indexer, namespace: namespace}
+}
+
+// MysqlUserNamespaceLister helps list and get MysqlUsers.
+// All objects returned here must be treated as read-only.
+type MysqlUserNamespaceLister interface {
+	// List lists all MysqlUsers in the indexer for a given namespace.
+	// Objects returned here must be treated as read-only.
+	List(selector labels.Selector) (ret []*v1alpha1.MysqlUser, err error)
+	// Get retrieves the MysqlUser from the indexer for a given namespace and name.
+	// Objects returned here must be treated as read-only.
+	Get(name string) (*v1alpha1.MysqlUser, error)
+	MysqlUserNamespaceListerExpansion
+}
+
+// mysqlUserNamespaceLister implements the MysqlUserNamespaceLister
+// interface.
+type mysqlUserNamespaceLister struct {
+	indexer   cache.Indexer
+	namespace string
+}
+
+// List lists all MysqlUsers in the indexer for a given namespace.
+func (s mysqlUserNamespaceLister) List(selector labels.Selector) (ret []*v1alpha1.MysqlUser, err error) {
+	err = cache.ListAllByNamespace(s.indexer, s.namespace, selector, func(m interface{}) {
+		ret = append(ret, m.(*v1alpha1.MysqlUser))
+	})
+	return ret, err
+}
+
+// Get retrieves the MysqlUser from the indexer for a given namespace and name.
+func (s mysqlUserNamespaceLister) Get(name string) (*v1alpha1.MysqlUser, error) {
+	obj, exists, err := s.indexer.GetByKey(s.namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.NewNotFound(v1alpha1.Resource("mysqluser"), name)
+	}
+	return obj.(*v1alpha1.MysqlUser), nil
+}
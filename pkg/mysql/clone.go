@@ -0,0 +1,203 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// cloningMembers hosts of members which clone is in progress, avoid starting another clone before performance_schema.clone_status is updated
+var cloningMembers sync.Map
+
+// Cloner seed member from a healthy donor with clone plugin before member joins cluster.
+// a member is seeded when it misses transactions which donor has purged from binlog, because binlog based recovery of these transactions is impossible.
+// clone plugin is supported since mysql 8.0.17, mysql 5.7 members are seeded by sidecar with xtrabackup when datadir is empty
+type Cloner struct {
+	// Dialect mysql server version specific sql statements
+	Dialect *Dialect
+}
+
+// Enabled clone plugin is supported by mysql server version, a nil Cloner is disabled
+func (t *Cloner) Enabled() bool {
+	return t != nil && t.Dialect.SupportClonePlugin()
+}
+
+// SeedMember clone data from first donor which has transactions member needs, clone is running in background because it may take hours.
+// return true if member is being seeded, member must not join cluster until clone is done and member is restarted
+func (t *Cloner) SeedMember(ctx context.Context, member *DSN, donors []*DSN) (seeding bool) {
+	if !t.Enabled() {
+		return false
+	}
+
+	if _, ok := cloningMembers.Load(member.Host); ok {
+		return true
+	}
+
+	dbConn, err := Connect(ctx, member)
+	if err != nil {
+		return false
+	}
+	defer dbConn.Close()
+
+	if state, _ := getCloneState(ctx, dbConn); state == "In Progress" {
+		return true
+	}
+
+	for _, donor := range donors {
+		if donor.Host == member.Host {
+			continue
+		}
+
+		need, reason, err := t.needClone(ctx, dbConn, donor)
+		if err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": member.Host, "donor": donor.Host}).Debugf("mysql check member need clone failed")
+			continue
+		}
+
+		if !need {
+			return false
+		}
+
+		if err = t.startClone(ctx, member, donor); err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": member.Host, "donor": donor.Host}).Warn("mysql start clone failed")
+			return false
+		}
+
+		logrus.WithFields(map[string]interface{}{"host": member.Host, "donor": donor.Host, "reason": reason}).Info("mysql clone member from donor started")
+		return true
+	}
+
+	return false
+}
+
+// cloneDonors healthy members which are not master are preferred as donor, so clone does not slow down writes on master
+func cloneDonors(ctx context.Context, clusterManager ClusterManager) (donors []*DSN) {
+	masters, _ := clusterManager.FindMaster(ctx)
+	for _, dsn := range clusterManager.HealthyMembers(ctx) {
+		if !dsnInList(masters, dsn) {
+			donors = append(donors, dsn)
+		}
+	}
+	return append(donors, masters...)
+}
+
+// needClone member needs clone when it misses transactions which are purged on donor
+func (t *Cloner) needClone(ctx context.Context, memberConn *sql.Conn, donor *DSN) (need bool, reason string, err error) {
+	donorConn, err := Connect(ctx, donor)
+	if err != nil {
+		return false, "", types.ErrMyqlConnectFaild
+	}
+	defer donorConn.Close()
+
+	var donorPurgedText, memberExecutedText string
+	if err = donorConn.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_purged").Scan(&donorPurgedText); err != nil {
+		return false, "", err
+	}
+
+	if err = memberConn.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&memberExecutedText); err != nil {
+		return false, "", err
+	}
+
+	donorPurged, err := ParseGTIDSet(donorPurgedText)
+	if err != nil {
+		return false, "", err
+	}
+
+	memberExecuted, err := ParseGTIDSet(memberExecutedText)
+	if err != nil {
+		return false, "", err
+	}
+
+	if missing := donorPurged.Subtract(memberExecuted); len(missing) > 0 {
+		return true, "transactions purged on donor are missing " + missing.String(), nil
+	}
+
+	return false, "", nil
+}
+
+// startClone install clone plugin on donor and member, then execute CLONE INSTANCE on member in background.
+// member server is shut down after clone is done, it will be restarted by kubernetes
+func (t *Cloner) startClone(ctx context.Context, member, donor *DSN) (err error) {
+	for _, dsn := range []*DSN{donor, member} {
+		if err = installClonePlugin(ctx, dsn); err != nil {
+			return fmt.Errorf("%w, install [host=%s] clone plugin err -> %s", types.ErrMysqlCloneFailed, dsn.Host, err.Error())
+		}
+	}
+
+	dbConn, err := Connect(ctx, member)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+
+	// member left in ERROR state by a failed join must stop group replication before clone
+	var memberState sql.NullString
+	if err = dbConn.QueryRowContext(ctx, "SELECT MEMBER_STATE FROM performance_schema.replication_group_members WHERE MEMBER_ID=@@server_uuid").Scan(&memberState); err == nil &&
+		memberState.String != "" && memberState.String != "OFFLINE" {
+		if _, err = dbConn.ExecContext(ctx, "STOP GROUP_REPLICATION"); err != nil {
+			dbConn.Close()
+			return fmt.Errorf("%w, stop [host=%s] group replication err -> %s", types.ErrMysqlCloneFailed, member.Host, err.Error())
+		}
+	}
+
+	donorAddress := donor.Host + ":" + strconv.Itoa(donor.Port)
+	if _, err = dbConn.ExecContext(ctx, "SET GLOBAL clone_valid_donor_list=?", donorAddress); err != nil {
+		dbConn.Close()
+		return fmt.Errorf("%w, set [host=%s] clone_valid_donor_list err -> %s", types.ErrMysqlCloneFailed, member.Host, err.Error())
+	}
+
+	cloningMembers.Store(member.Host, donor.Host)
+	go func() {
+		defer dbConn.Close()
+		defer cloningMembers.Delete(member.Host)
+
+		// clone may take hours, it must not be canceled by reconcile context
+		_, err := dbConn.ExecContext(context.Background(), cloneInstanceSQL(donor))
+		var mysqlErr *mysqldriver.MySQLError
+		if err == nil || (errors.As(err, &mysqlErr) && mysqlErr.Number == 3707) { // 3707 server is not managed by supervisor, restart is done by kubernetes
+			logrus.WithFields(map[string]interface{}{"host": member.Host, "donor": donor.Host}).Info("mysql clone member from donor done")
+			return
+		}
+		logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": member.Host, "donor": donor.Host}).Warn(types.ErrMysqlCloneFailed.Error())
+	}()
+
+	return nil
+}
+
+// installClonePlugin install clone plugin if it is not loaded, plugin is registered in mysql.plugin table and loaded after restart
+func installClonePlugin(ctx context.Context, dsn *DSN) (err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	var status string
+	err = dbConn.QueryRowContext(ctx, "SELECT PLUGIN_STATUS FROM information_schema.PLUGINS WHERE PLUGIN_NAME='clone'").Scan(&status)
+	if err == nil {
+		return nil
+	} else if err != sql.ErrNoRows {
+		return err
+	}
+
+	_, err = dbConn.ExecContext(ctx, "INSTALL PLUGIN clone SONAME 'mysql_clone.so'")
+	return err
+}
+
+// getCloneState return STATE of performance_schema.clone_status, values are [ Not Started, In Progress, Completed, Failed ]
+func getCloneState(ctx context.Context, dbConn *sql.Conn) (state string, err error) {
+	err = dbConn.QueryRowContext(ctx, "SELECT STATE FROM performance_schema.clone_status").Scan(&state)
+	return state, err
+}
+
+// cloneInstanceSQL CLONE INSTANCE statement does not support placeholders
+func cloneInstanceSQL(donor *DSN) string {
+	return fmt.Sprintf("CLONE INSTANCE FROM %s@%s:%d IDENTIFIED BY %s",
+		QuoteString(donor.Username), QuoteString(donor.Host), donor.Port, QuoteString(donor.Password))
+}
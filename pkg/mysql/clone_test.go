@@ -0,0 +1,21 @@
+package mysql
+
+import "testing"
+
+func TestCloneInstanceSQL(t *testing.T) {
+	donor := &DSN{Host: "yuxing-mysql-1.default", Port: 3306, Username: "cluster", Password: `pa'ss\word`}
+	expected := `CLONE INSTANCE FROM 'cluster'@'yuxing-mysql-1.default':3306 IDENTIFIED BY 'pa\'ss\\word'`
+	if s := cloneInstanceSQL(donor); s != expected {
+		t.Fatalf("clone instance sql is not correct, got %s", s)
+	}
+
+	var cloner *Cloner
+	if cloner.Enabled() {
+		t.Fatal("nil cloner must be disabled")
+	}
+
+	mysql57, _ := NewDialect("5.7.34")
+	if (&Cloner{Dialect: mysql57}).Enabled() {
+		t.Fatal("clone plugin is not supported by mysql 5.7")
+	}
+}
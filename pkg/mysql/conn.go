@@ -0,0 +1,172 @@
+package mysql
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+)
+
+const (
+	// defaultDialTimeout dial timeout of DSN which Timeout is not set
+	defaultDialTimeout = 5 * time.Second
+	// connPoolIdleTimeout pool which is not used in this duration is closed, members of deleted clusters do not keep pools forever
+	connPoolIdleTimeout = 10 * time.Minute
+)
+
+// DefaultConnManager connection pools shared by cluster managers, probes and proxysql admin
+var DefaultConnManager = NewConnManager()
+
+// ConnManager keep one connection pool for each DSN, DSNs with same address, credentials and options share same pool
+type ConnManager struct {
+	// MaxOpenConns max connections of each pool
+	MaxOpenConns int
+	// MaxIdleConns max idle connections of each pool
+	MaxIdleConns int
+	// ConnMaxLifetime connection is reconnected after this duration, so dns change of pod is noticed
+	ConnMaxLifetime time.Duration
+
+	lock  sync.Mutex
+	pools map[string]*connPool
+}
+
+type connPool struct {
+	db       *sql.DB
+	lastUsed time.Time
+}
+
+func NewConnManager() *ConnManager {
+	return &ConnManager{
+		MaxOpenConns:    8,
+		MaxIdleConns:    2,
+		ConnMaxLifetime: 5 * time.Minute,
+		pools:           make(map[string]*connPool),
+	}
+}
+
+// Connect get a connection of dsn from pool, connection must be closed to return it to pool.
+// statements of one call share same session, so session variables and transactions work as expected
+func (t *ConnManager) Connect(ctx context.Context, dsn *DSN) (conn *sql.Conn, err error) {
+	db, err := t.pool(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return db.Conn(ctx)
+}
+
+// pool return pool of dsn, create it if not exists, pools idle too long are closed
+func (t *ConnManager) pool(dsn *DSN) (db *sql.DB, err error) {
+	key := dsn.FormatDSN()
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	now := time.Now()
+	for k, pool := range t.pools {
+		if k != key && now.Sub(pool.lastUsed) > connPoolIdleTimeout {
+			pool.db.Close()
+			delete(t.pools, k)
+		}
+	}
+
+	if pool, ok := t.pools[key]; ok {
+		pool.lastUsed = now
+		return pool.db, nil
+	}
+
+	if db, err = sql.Open("mysql", key); err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(t.MaxOpenConns)
+	db.SetMaxIdleConns(t.MaxIdleConns)
+	db.SetConnMaxLifetime(t.ConnMaxLifetime)
+
+	t.pools[key] = &connPool{db: db, lastUsed: now}
+	return db, nil
+}
+
+// Close close all pools
+func (t *ConnManager) Close() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for k, pool := range t.pools {
+		pool.db.Close()
+		delete(t.pools, k)
+	}
+}
+
+// Connect get a connection of dsn from DefaultConnManager
+func Connect(ctx context.Context, dsn *DSN) (conn *sql.Conn, err error) {
+	return DefaultConnManager.Connect(ctx, dsn)
+}
+
+// FormatDSN generate go-sql-driver data source name, password and database name are escaped by driver.
+// query arguments are interpolated by driver with escaping, so placeholders also work for statements which can not be prepared by server, such as proxysql admin statements
+func (t *DSN) FormatDSN() string {
+	cfg := mysqldriver.NewConfig()
+	cfg.User = t.Username
+	cfg.Passwd = t.Password
+	cfg.DBName = t.DBName
+	cfg.Params = map[string]string{"charset": "utf8"}
+	cfg.InterpolateParams = true
+	cfg.TLSConfig = t.TLS
+	cfg.Timeout = t.Timeout
+	cfg.ReadTimeout = t.ReadTimeout
+	cfg.WriteTimeout = t.WriteTimeout
+
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaultDialTimeout
+	}
+
+	if t.Socket != "" {
+		cfg.Net = "unix"
+		cfg.Addr = t.Socket
+	} else {
+		cfg.Net = "tcp"
+		cfg.Addr = t.Host + ":" + strconv.Itoa(t.Port)
+	}
+
+	return cfg.FormatDSN()
+}
+
+// RegisterTLSConfig register tls config of name for DSN.TLS, caPEM is required, certPEM and keyPEM are client certificate for x509 authentication.
+// server name is verified against host of DSN
+func RegisterTLSConfig(name string, caPEM, certPEM, keyPEM []byte) (err error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return errors.New("mysql tls ca certificate is invalid")
+	}
+
+	cfg := &tls.Config{RootCAs: pool}
+	if len(certPEM) > 0 {
+		cert, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return mysqldriver.RegisterTLSConfig(name, cfg)
+}
+
+// QuoteString quote s as mysql string literal, for statements which arguments can not be placeholders, such as CHANGE MASTER TO and CLONE INSTANCE
+func QuoteString(s string) string {
+	return "'" + stringEscaper.Replace(s) + "'"
+}
+
+var stringEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`'`, `\'`,
+	"\x00", `\0`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\x1a", `\Z`,
+)
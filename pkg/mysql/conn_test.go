@@ -0,0 +1,41 @@
+package mysql
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuoteString(t *testing.T) {
+	cases := map[string]string{
+		"replication":   `'replication'`,
+		`pa'ss\word`:    `'pa\'ss\\word'`,
+		"a\nb\x00c\x1a": `'a\nb\0c\Z'`,
+	}
+	for s, expected := range cases {
+		if quoted := QuoteString(s); quoted != expected {
+			t.Fatalf("quote %q is not correct, got %s", s, quoted)
+		}
+	}
+
+	dialect, _ := NewDialect("8.0.28")
+	sql := dialect.ChangeSourceSQL("yuxing-mysql-0", "replication", "pass'word")
+	if !strings.Contains(sql, `SOURCE_PASSWORD='pass\'word'`) {
+		t.Fatalf("change source sql password is not quoted, got %s", sql)
+	}
+}
+
+func TestFormatDSN(t *testing.T) {
+	dsn := &DSN{Host: "yuxing-mysql-0", Port: 3306, Username: "root", Password: "p@ss:word/", DBName: "mysql", TLS: "skip-verify", ReadTimeout: 3 * time.Second}
+	s := dsn.FormatDSN()
+	for _, expected := range []string{"root:p@ss:word/@tcp(yuxing-mysql-0:3306)/mysql?", "tls=skip-verify", "readTimeout=3s", "timeout=5s", "interpolateParams=true"} {
+		if !strings.Contains(s, expected) {
+			t.Fatalf("dsn %s does not contain %s", s, expected)
+		}
+	}
+
+	dsn = &DSN{Socket: "/var/run/mysqld/mysqld.sock", Username: "root"}
+	if s = dsn.FormatDSN(); !strings.HasPrefix(s, "root@unix(/var/run/mysqld/mysqld.sock)/") {
+		t.Fatalf("socket dsn is not correct, got %s", s)
+	}
+}
@@ -0,0 +1,47 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// replicaDelay MASTER_DELAY of member, zero if member is not a delayed replica
+func replicaDelay(delayed []*DSN, delaySeconds int, dsn *DSN) int {
+	if dsnInList(delayed, dsn) {
+		return delaySeconds
+	}
+	return 0
+}
+
+// setReplicaDelay change MASTER_DELAY of replica when it differs, only sql thread is restarted so io thread keeps receiving transactions
+func setReplicaDelay(ctx context.Context, dbConn *sql.Conn, dialect *Dialect, dsn *DSN, delaySeconds int) (err error) {
+	replicaStatus, err := queryReplicaStatus(ctx, dbConn, dialect)
+	if err != nil {
+		return fmt.Errorf("%w, query [host=%s] replica status err -> %s", types.ErrMysqlReplicaDelayFailed, dsn.Host, err.Error())
+	}
+
+	// not a replica, or delay is not changed
+	if len(replicaStatus) < 1 || replicaStatus["SQL_Delay"] == strconv.Itoa(delaySeconds) {
+		return nil
+	}
+
+	if _, err = dbConn.ExecContext(ctx, dialect.StopReplicaSQL()+" SQL_THREAD"); err != nil {
+		return fmt.Errorf("%w, stop [host=%s] sql thread err -> %s", types.ErrMysqlReplicaDelayFailed, dsn.Host, err.Error())
+	}
+
+	if _, err = dbConn.ExecContext(ctx, dialect.ChangeSourceDelaySQL(delaySeconds)); err != nil {
+		return fmt.Errorf("%w, change [host=%s] delay err -> %s", types.ErrMysqlReplicaDelayFailed, dsn.Host, err.Error())
+	}
+
+	if _, err = dbConn.ExecContext(ctx, dialect.StartReplicaSQL()+" SQL_THREAD"); err != nil {
+		return fmt.Errorf("%w, start [host=%s] sql thread err -> %s", types.ErrMysqlReplicaDelayFailed, dsn.Host, err.Error())
+	}
+
+	logrus.WithFields(map[string]interface{}{"host": dsn.Host, "delay": delaySeconds}).Info("mysql replica delay changed")
+	return nil
+}
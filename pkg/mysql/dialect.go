@@ -0,0 +1,293 @@
+package mysql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/hakur/rds-operator/util"
+)
+
+// DefaultVersion mysql server version used when version is not specified
+const DefaultVersion = "5.7.34"
+
+// Dialect generate version specific sql statements and config variable names.
+// mysql 8.0 renamed master/slave to source/replica step by step:
+// 8.0.22 START REPLICA, SHOW REPLICA STATUS, group_replication_ip_allowlist
+// 8.0.23 CHANGE REPLICATION SOURCE TO
+// 8.0.26 rpl_semi_sync_source_*, log_replica_updates and other replica_* config variables.
+// a nil Dialect is mysql 5.7
+type Dialect struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+// NewDialect parse mysql server version string like 5.7.34 or 8.0.27, empty version is DefaultVersion
+func NewDialect(version string) (t *Dialect, err error) {
+	if version == "" {
+		version = DefaultVersion
+	}
+
+	v, err := util.ParseVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("parse mysql version=[%s] failed, err -> %s", version, err.Error())
+	}
+
+	t = &Dialect{Major: v.Major, Minor: v.Minor}
+	// bugfix version may have suffix, such as 8.0.27-log
+	if patch := regexp.MustCompile(`^\d+`).FindString(v.Bugfix); patch != "" {
+		t.Patch, _ = strconv.Atoi(patch)
+	}
+
+	return t, nil
+}
+
+// AtLeast check mysql server version is greater than or equal to major.minor.patch
+func (t *Dialect) AtLeast(major, minor, patch int) bool {
+	if t == nil {
+		return false
+	}
+
+	if t.Major != major {
+		return t.Major > major
+	}
+
+	if t.Minor != minor {
+		return t.Minor > minor
+	}
+
+	return t.Patch >= patch
+}
+
+// IsMysql8 mysql server version is 8.0 or later
+func (t *Dialect) IsMysql8() bool {
+	return t.AtLeast(8, 0, 0)
+}
+
+// String return mysql server version string
+func (t *Dialect) String() string {
+	if t == nil {
+		return DefaultVersion
+	}
+	return fmt.Sprintf("%d.%d.%d", t.Major, t.Minor, t.Patch)
+}
+
+// AuthPlugin default authentication plugin for users created by operator
+func (t *Dialect) AuthPlugin() string {
+	if t.IsMysql8() {
+		return "caching_sha2_password"
+	}
+	return "mysql_native_password"
+}
+
+// SetVariableSQL set global system variable, mysql 8.0 use SET PERSIST so value survives restart before my.cnf is rendered again
+func (t *Dialect) SetVariableSQL(name, literal string) string {
+	if t.IsMysql8() {
+		return "SET PERSIST " + name + "=" + literal
+	}
+	return "SET GLOBAL " + name + "=" + literal
+}
+
+// ResetVariableSQL remove persisted value of system variable, so value of my.cnf is used after restart. empty on mysql 5.7, nothing is persisted
+func (t *Dialect) ResetVariableSQL(name string) string {
+	if t.IsMysql8() {
+		return "RESET PERSIST IF EXISTS " + name
+	}
+	return ""
+}
+
+// SupportSetAsPrimary group_replication_set_as_primary() is supported since 8.0.13
+func (t *Dialect) SupportSetAsPrimary() bool {
+	return t.AtLeast(8, 0, 13)
+}
+
+// SupportClonePlugin clone plugin is supported since 8.0.17
+func (t *Dialect) SupportClonePlugin() bool {
+	return t.AtLeast(8, 0, 17)
+}
+
+// ChangeSourceSQL point replication to source host of port 3306 with gtid auto position
+func (t *Dialect) ChangeSourceSQL(host, username, password string) string {
+	return t.ChangeSourcePortSQL(host, 3306, username, password)
+}
+
+// ChangeSourcePortSQL point replication to source host:port with gtid auto position, CHANGE MASTER TO does not support placeholders, so arguments are quoted
+func (t *Dialect) ChangeSourcePortSQL(host string, port int, username, password string) string {
+	if t.AtLeast(8, 0, 23) {
+		return "CHANGE REPLICATION SOURCE TO SOURCE_HOST=" + QuoteString(host) + ",SOURCE_PORT=" + strconv.Itoa(port) + ",SOURCE_USER=" + QuoteString(username) + ",SOURCE_PASSWORD=" + QuoteString(password) + ",SOURCE_AUTO_POSITION=1,GET_SOURCE_PUBLIC_KEY=1"
+	}
+
+	if t.IsMysql8() { // caching_sha2_password need public key when connection is not ssl
+		return "CHANGE MASTER TO MASTER_HOST=" + QuoteString(host) + ",MASTER_PORT=" + strconv.Itoa(port) + ",MASTER_USER=" + QuoteString(username) + ",MASTER_PASSWORD=" + QuoteString(password) + ",MASTER_AUTO_POSITION=1,GET_MASTER_PUBLIC_KEY=1"
+	}
+
+	return "CHANGE MASTER TO MASTER_HOST=" + QuoteString(host) + ",MASTER_PORT=" + strconv.Itoa(port) + ",MASTER_USER=" + QuoteString(username) + ",MASTER_PASSWORD=" + QuoteString(password) + ",MASTER_AUTO_POSITION=1"
+}
+
+// ChangeSourceDelaySQL set seconds of replica apply transactions later than source, sql thread must be stopped
+func (t *Dialect) ChangeSourceDelaySQL(delaySeconds int) string {
+	if t.AtLeast(8, 0, 23) {
+		return "CHANGE REPLICATION SOURCE TO SOURCE_DELAY=" + strconv.Itoa(delaySeconds)
+	}
+	return "CHANGE MASTER TO MASTER_DELAY=" + strconv.Itoa(delaySeconds)
+}
+
+// ChangeRecoveryUserSQL set user of group replication distributed recovery channel
+func (t *Dialect) ChangeRecoveryUserSQL(username, password string) string {
+	if t.AtLeast(8, 0, 23) {
+		return "CHANGE REPLICATION SOURCE TO SOURCE_USER=" + QuoteString(username) + ",SOURCE_PASSWORD=" + QuoteString(password) + " FOR CHANNEL 'group_replication_recovery'"
+	}
+	return "CHANGE MASTER TO MASTER_USER=" + QuoteString(username) + ",MASTER_PASSWORD=" + QuoteString(password) + " FOR CHANNEL 'group_replication_recovery'"
+}
+
+// StartReplicaSQL start replication threads
+func (t *Dialect) StartReplicaSQL() string {
+	if t.AtLeast(8, 0, 22) {
+		return "START REPLICA"
+	}
+	return "START SLAVE"
+}
+
+// StopReplicaSQL stop replication threads
+func (t *Dialect) StopReplicaSQL() string {
+	if t.AtLeast(8, 0, 22) {
+		return "STOP REPLICA"
+	}
+	return "STOP SLAVE"
+}
+
+// ResetReplicaAllSQL clear replication source connection settings, used when replica is promoted to master
+func (t *Dialect) ResetReplicaAllSQL() string {
+	if t.AtLeast(8, 0, 22) {
+		return "RESET REPLICA ALL"
+	}
+	return "RESET SLAVE ALL"
+}
+
+// ShowReplicaStatusSQL show replication status of default channel
+func (t *Dialect) ShowReplicaStatusSQL() string {
+	if t.AtLeast(8, 0, 22) {
+		return "SHOW REPLICA STATUS"
+	}
+	return "SHOW SLAVE STATUS"
+}
+
+// SourceHostColumn column name of source host in result of ShowReplicaStatusSQL
+func (t *Dialect) SourceHostColumn() string {
+	if t.AtLeast(8, 0, 22) {
+		return "Source_Host"
+	}
+	return "Master_Host"
+}
+
+// SourcePortColumn column name of source port in result of ShowReplicaStatusSQL
+func (t *Dialect) SourcePortColumn() string {
+	if t.AtLeast(8, 0, 22) {
+		return "Source_Port"
+	}
+	return "Master_Port"
+}
+
+// ReplicaIORunningColumn column name of io thread state in result of ShowReplicaStatusSQL
+func (t *Dialect) ReplicaIORunningColumn() string {
+	if t.AtLeast(8, 0, 22) {
+		return "Replica_IO_Running"
+	}
+	return "Slave_IO_Running"
+}
+
+// ReplicaSQLRunningColumn column name of sql thread state in result of ShowReplicaStatusSQL
+func (t *Dialect) ReplicaSQLRunningColumn() string {
+	if t.AtLeast(8, 0, 22) {
+		return "Replica_SQL_Running"
+	}
+	return "Slave_SQL_Running"
+}
+
+// SecondsBehindSourceColumn column name of replication lag in result of ShowReplicaStatusSQL
+func (t *Dialect) SecondsBehindSourceColumn() string {
+	if t.AtLeast(8, 0, 22) {
+		return "Seconds_Behind_Source"
+	}
+	return "Seconds_Behind_Master"
+}
+
+// SemiSyncPlugins semi sync plugin libraries for plugin_load_add
+func (t *Dialect) SemiSyncPlugins() string {
+	if t.AtLeast(8, 0, 26) {
+		return "semisync_source.so;semisync_replica.so"
+	}
+	return "semisync_master.so;semisync_slave.so"
+}
+
+// SemiSyncSourceEnabledVariable variable name of semi sync master enabled
+func (t *Dialect) SemiSyncSourceEnabledVariable() string {
+	if t.AtLeast(8, 0, 26) {
+		return "rpl_semi_sync_source_enabled"
+	}
+	return "rpl_semi_sync_master_enabled"
+}
+
+// SemiSyncReplicaEnabledVariable variable name of semi sync slave enabled
+func (t *Dialect) SemiSyncReplicaEnabledVariable() string {
+	if t.AtLeast(8, 0, 26) {
+		return "rpl_semi_sync_replica_enabled"
+	}
+	return "rpl_semi_sync_slave_enabled"
+}
+
+// SemiSyncSourceWaitPointVariable variable name of semi sync master wait point
+func (t *Dialect) SemiSyncSourceWaitPointVariable() string {
+	if t.AtLeast(8, 0, 26) {
+		return "rpl_semi_sync_source_wait_point"
+	}
+	return "rpl_semi_sync_master_wait_point"
+}
+
+// SemiSyncSourceStatusVariable status variable name of semi sync master is working, OFF means master fall back to async replication
+func (t *Dialect) SemiSyncSourceStatusVariable() string {
+	if t.AtLeast(8, 0, 26) {
+		return "Rpl_semi_sync_source_status"
+	}
+	return "Rpl_semi_sync_master_status"
+}
+
+// SemiSyncReplicaStatusVariable status variable name of semi sync slave is working
+func (t *Dialect) SemiSyncReplicaStatusVariable() string {
+	if t.AtLeast(8, 0, 26) {
+		return "Rpl_semi_sync_replica_status"
+	}
+	return "Rpl_semi_sync_slave_status"
+}
+
+// GroupReplicationAllowlistVariable variable name of group replication ip whitelist
+func (t *Dialect) GroupReplicationAllowlistVariable() string {
+	if t.AtLeast(8, 0, 22) {
+		return "group_replication_ip_allowlist"
+	}
+	return "group_replication_ip_whitelist"
+}
+
+// ReplicaVariable convert 5.7 slave/master config variable name to 8.0.26 replica/source variable name,
+// such as log_slave_updates, slave_parallel_type, slave_parallel_workers, slave_skip_errors, slave_preserve_commit_order
+func (t *Dialect) ReplicaVariable(name string) string {
+	if !t.AtLeast(8, 0, 26) {
+		return name
+	}
+
+	if replaced, ok := replicaVariables[name]; ok {
+		return replaced
+	}
+	return name
+}
+
+var replicaVariables = map[string]string{
+	"log_slave_updates":           "log_replica_updates",
+	"log-slave-updates":           "log_replica_updates",
+	"slave_parallel_type":         "replica_parallel_type",
+	"slave-parallel-type":         "replica_parallel_type",
+	"slave_parallel_workers":      "replica_parallel_workers",
+	"slave_skip_errors":           "replica_skip_errors",
+	"slave_preserve_commit_order": "replica_preserve_commit_order",
+}
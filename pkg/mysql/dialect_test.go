@@ -0,0 +1,82 @@
+package mysql
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDialectAtLeast(t *testing.T) {
+	dialect, err := NewDialect("8.0.23-log")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !dialect.AtLeast(8, 0, 22) || !dialect.AtLeast(8, 0, 23) || dialect.AtLeast(8, 0, 26) || !dialect.AtLeast(5, 7, 40) {
+		t.Fatalf("version compare failed, version=%s", dialect)
+	}
+
+	var nilDialect *Dialect
+	if nilDialect.IsMysql8() {
+		t.Fatal("nil dialect must be mysql 5.7")
+	}
+
+	if _, err = NewDialect("8.0"); err == nil {
+		t.Fatal("invalid version must return error")
+	}
+}
+
+func TestDialectStatements(t *testing.T) {
+	mysql57, _ := NewDialect("5.7.34")
+	mysql80, _ := NewDialect("8.0.27")
+
+	if mysql57.ShowReplicaStatusSQL() != "SHOW SLAVE STATUS" || mysql80.ShowReplicaStatusSQL() != "SHOW REPLICA STATUS" {
+		t.Fatal("show replica status statement is not correct")
+	}
+
+	if mysql57.SemiSyncSourceEnabledVariable() != "rpl_semi_sync_master_enabled" || mysql80.SemiSyncSourceEnabledVariable() != "rpl_semi_sync_source_enabled" {
+		t.Fatal("semi sync source variable is not correct")
+	}
+
+	if mysql57.SecondsBehindSourceColumn() != "Seconds_Behind_Master" || mysql80.SecondsBehindSourceColumn() != "Seconds_Behind_Source" {
+		t.Fatal("seconds behind source column is not correct")
+	}
+
+	if mysql57.SemiSyncSourceStatusVariable() != "Rpl_semi_sync_master_status" || mysql80.SemiSyncSourceStatusVariable() != "Rpl_semi_sync_source_status" {
+		t.Fatal("semi sync source status variable is not correct")
+	}
+
+	if mysql57.GroupReplicationAllowlistVariable() != "group_replication_ip_whitelist" || mysql80.GroupReplicationAllowlistVariable() != "group_replication_ip_allowlist" {
+		t.Fatal("group replication allowlist variable is not correct")
+	}
+
+	if mysql57.ReplicaVariable("slave_preserve_commit_order") != "slave_preserve_commit_order" || mysql80.ReplicaVariable("slave_preserve_commit_order") != "replica_preserve_commit_order" {
+		t.Fatal("replica variable is not correct")
+	}
+
+	if mysql57.SourcePortColumn() != "Master_Port" || mysql80.SourcePortColumn() != "Source_Port" {
+		t.Fatal("source port column is not correct")
+	}
+
+	if sql := mysql57.ChangeSourcePortSQL("10.0.0.1", 3307, "replication", "replication_password"); !strings.Contains(sql, "MASTER_PORT=3307") {
+		t.Fatalf("change source port sql is not correct, got %s", sql)
+	}
+
+	if mysql57.ChangeSourceDelaySQL(3600) != "CHANGE MASTER TO MASTER_DELAY=3600" || mysql80.ChangeSourceDelaySQL(3600) != "CHANGE REPLICATION SOURCE TO SOURCE_DELAY=3600" {
+		t.Fatal("change source delay sql is not correct")
+	}
+
+	if mysql80.AuthPlugin() != "caching_sha2_password" || mysql57.AuthPlugin() != "mysql_native_password" {
+		t.Fatal("auth plugin is not correct")
+	}
+
+	if mysql57.SetVariableSQL("max_connections", "500") != "SET GLOBAL max_connections=500" || mysql80.SetVariableSQL("max_connections", "500") != "SET PERSIST max_connections=500" {
+		t.Fatal("set variable sql is not correct")
+	}
+
+	if mysql57.ResetVariableSQL("max_connections") != "" || mysql80.ResetVariableSQL("max_connections") != "RESET PERSIST IF EXISTS max_connections" {
+		t.Fatal("reset variable sql is not correct")
+	}
+
+	t.Log(mysql80.ChangeSourceSQL("yuxing-mysql-0", "replication", "replication_password"))
+	t.Log(mysql57.ChangeSourceSQL("yuxing-mysql-0", "replication", "replication_password"))
+}
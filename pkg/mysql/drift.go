@@ -0,0 +1,255 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// grantReg GRANT line of SHOW GRANTS, privileges and target are captured
+var grantReg = regexp.MustCompile("^GRANT (.+) ON (\\S+) TO ")
+
+// allPrivilegesProbe static privileges which must be granted when ALL PRIVILEGES is desired, mysql 8.0 SHOW GRANTS expands ALL PRIVILEGES on *.*
+var allPrivilegesProbe = []string{"SELECT", "INSERT", "UPDATE", "DELETE", "CREATE", "DROP", "ALTER", "INDEX"}
+
+// Drift runtime state of member which differs from desired state derived from CR
+type Drift struct {
+	// Host member host
+	Host string
+	// Setting name of drifted setting, such as super_read_only, replication source or grants
+	Setting string
+	// Desired value derived from CR
+	Desired string
+	// Actual runtime value of member
+	Actual string
+	// Corrected drift is corrected by operator
+	Corrected bool
+	// Message reason of drift is not corrected
+	Message string
+	// Repoint drift is corrected by cluster manager which repoints replica to master, it is marked as corrected after cluster is started
+	Repoint bool
+}
+
+// UserGrants desired privileges of user on target, such as cluster user created by init.sql
+type UserGrants struct {
+	Username   string
+	Domain     string
+	Privileges []string
+	// Target database and tables, such as *.*
+	Target string
+	// AdminUsername account which corrects missing privileges on masters, privileges are only reported when it is empty
+	AdminUsername string
+	AdminPassword string
+}
+
+// DriftDetector compare runtime state of members with desired state, drifted state is corrected when Enforce is true
+type DriftDetector struct {
+	// Dialect mysql server version specific sql statements
+	Dialect *Dialect
+	// Masters recorded masters, other members replicate from one of them. roles are not checked if it is empty
+	Masters []*DSN
+	// Roles read only, replication source and semi sync variables of members are checked, only for SemiSync and Async cluster mode
+	Roles bool
+	// SemiSync rpl_semi_sync_*_enabled of members are checked
+	SemiSync bool
+	// ReadOnlyMasters masters are kept read only, such as master of disaster recovery standby
+	ReadOnlyMasters bool
+	// Grants desired privileges of cluster user, they are checked on masters, nil if grants are not checked
+	Grants *UserGrants
+	// Skipped members are not checked, such as members under maintenance
+	Skipped []*DSN
+	// Enforce correct drifted state, otherwise drift is only reported
+	Enforce bool
+}
+
+// Detect check members configured by cluster manager before, such as healthy members of last check, restarted members are configured by cluster manager instead.
+// replication source of replica is not changed here, it is repointed by cluster manager when Enforce is true
+func (t *DriftDetector) Detect(ctx context.Context, dataSources []*DSN) (drifts []*Drift) {
+	for _, dsn := range dataSources {
+		if dsnInList(t.Skipped, dsn) {
+			continue
+		}
+
+		dbConn, err := Connect(ctx, dsn)
+		if err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf(types.ErrMyqlConnectFaild.Error())
+			continue
+		}
+
+		if t.Roles && len(t.Masters) > 0 {
+			drifts = append(drifts, t.detectRole(ctx, dbConn, dsn)...)
+		}
+
+		if t.Grants != nil && dsnInList(t.Masters, dsn) {
+			if drift := t.detectGrants(ctx, dbConn, dsn); drift != nil {
+				drifts = append(drifts, drift)
+			}
+		}
+		dbConn.Close()
+	}
+
+	for _, drift := range drifts {
+		logrus.WithFields(map[string]interface{}{"host": drift.Host, "setting": drift.Setting, "desired": drift.Desired, "actual": drift.Actual,
+			"corrected": drift.Corrected}).Warn("mysql runtime state drifted")
+	}
+	return drifts
+}
+
+// detectRole check read only, semi sync variables and replication source of member by its role
+func (t *DriftDetector) detectRole(ctx context.Context, dbConn *sql.Conn, dsn *DSN) (drifts []*Drift) {
+	isMaster := dsnInList(t.Masters, dsn)
+
+	var readOnly, superReadOnly bool
+	if err := dbConn.QueryRowContext(ctx, "SELECT @@GLOBAL.read_only, @@GLOBAL.super_read_only").Scan(&readOnly, &superReadOnly); err != nil {
+		logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debug("mysql query read only failed")
+		return nil
+	}
+
+	if isMaster && !t.ReadOnlyMasters && readOnly { // read_only=OFF also turns off super_read_only
+		drifts = append(drifts, t.correct(ctx, dbConn, &Drift{Host: dsn.Host, Setting: "read_only", Desired: "OFF", Actual: "ON"}, "SET GLOBAL read_only=0"))
+	} else if !isMaster && !superReadOnly {
+		drifts = append(drifts, t.correct(ctx, dbConn, &Drift{Host: dsn.Host, Setting: "super_read_only", Desired: "ON", Actual: "OFF"}, "SET GLOBAL super_read_only=1"))
+	}
+
+	if t.SemiSync {
+		desired := map[string]string{t.Dialect.SemiSyncSourceEnabledVariable(): "ON"}
+		if !isMaster {
+			desired = map[string]string{t.Dialect.SemiSyncSourceEnabledVariable(): "OFF", t.Dialect.SemiSyncReplicaEnabledVariable(): "ON"}
+		}
+
+		for name, value := range desired {
+			actual, err := showGlobalVariable(ctx, dbConn, name)
+			if err != nil || actual == value {
+				continue
+			}
+			drifts = append(drifts, t.correct(ctx, dbConn, &Drift{Host: dsn.Host, Setting: name, Desired: value, Actual: actual}, "SET GLOBAL "+name+"="+value))
+		}
+	}
+
+	if !isMaster {
+		replicaStatus, err := queryReplicaStatus(ctx, dbConn, t.Dialect)
+		if err != nil {
+			return drifts
+		}
+
+		source := replicaStatus[t.Dialect.SourceHostColumn()]
+		if !dsnInList(t.Masters, &DSN{Host: source}) {
+			var hosts []string
+			for _, master := range t.Masters {
+				hosts = append(hosts, master.Host)
+			}
+			drifts = append(drifts, &Drift{Host: dsn.Host, Setting: "replication source", Desired: strings.Join(hosts, ","), Actual: source, Repoint: true})
+		}
+	}
+
+	return drifts
+}
+
+// detectGrants check privileges of user on master, missing privileges are granted by admin account
+func (t *DriftDetector) detectGrants(ctx context.Context, dbConn *sql.Conn, dsn *DSN) (drift *Drift) {
+	grants, err := showGrants(ctx, dbConn, t.Grants.Username, t.Grants.Domain)
+	if err != nil {
+		logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debug("mysql show grants failed")
+		return nil
+	}
+
+	missing := MissingPrivileges(grants, t.Grants.Privileges, t.Grants.Target)
+	if len(missing) < 1 {
+		return nil
+	}
+
+	drift = &Drift{Host: dsn.Host, Setting: "grants", Desired: strings.Join(t.Grants.Privileges, ","), Actual: "missing " + strings.Join(missing, ",")}
+	if !t.Enforce {
+		return drift
+	} else if t.Grants.AdminUsername == "" {
+		drift.Message = "root password is not set, privileges can not be granted"
+		return drift
+	}
+
+	admin := &DSN{Host: dsn.Host, Port: dsn.Port, Username: t.Grants.AdminUsername, Password: t.Grants.AdminPassword, DBName: "mysql"}
+	adminConn, err := Connect(ctx, admin)
+	if err != nil {
+		drift.Message = types.ErrMyqlConnectFaild.Error()
+		return drift
+	}
+	defer adminConn.Close()
+
+	return t.correct(ctx, adminConn, drift, fmt.Sprintf("GRANT %s ON %s TO %s@%s", strings.Join(t.Grants.Privileges, ","), t.Grants.Target,
+		QuoteString(t.Grants.Username), QuoteString(t.Grants.Domain)))
+}
+
+// correct execute statement when Enforce is true, drift is marked as corrected if statement succeeded
+func (t *DriftDetector) correct(ctx context.Context, dbConn *sql.Conn, drift *Drift, statement string) *Drift {
+	if !t.Enforce {
+		return drift
+	}
+
+	if _, err := dbConn.ExecContext(ctx, statement); err != nil {
+		drift.Message = fmt.Errorf("%w, [host=%s] err -> %s", types.ErrMysqlCorrectDriftFailed, drift.Host, err.Error()).Error()
+		return drift
+	}
+	drift.Corrected = true
+	return drift
+}
+
+// showGrants return SHOW GRANTS result lines of user
+func showGrants(ctx context.Context, dbConn *sql.Conn, username, domain string) (grants []string, err error) {
+	result, err := dbConn.QueryContext(ctx, "SHOW GRANTS FOR "+QuoteString(username)+"@"+QuoteString(domain))
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var grant string
+		if err = result.Scan(&grant); err != nil {
+			return nil, err
+		}
+		grants = append(grants, grant)
+	}
+	return grants, result.Err()
+}
+
+// MissingPrivileges desired privileges on target which are not found in SHOW GRANTS result lines.
+// target is compared without backquotes, ALL is granted when ALL PRIVILEGES or its common static privileges are found
+func MissingPrivileges(grants []string, privileges []string, target string) (missing []string) {
+	target = strings.ReplaceAll(target, "`", "")
+	granted := make(map[string]bool)
+	for _, grant := range grants {
+		match := grantReg.FindStringSubmatch(grant)
+		if match == nil || strings.ReplaceAll(match[2], "`", "") != target {
+			continue
+		}
+
+		for _, privilege := range strings.Split(match[1], ",") {
+			granted[strings.ToUpper(strings.TrimSpace(privilege))] = true
+		}
+	}
+
+	for _, privilege := range privileges {
+		privilege = strings.ToUpper(strings.TrimSpace(privilege))
+		if privilege == "ALL" || privilege == "ALL PRIVILEGES" {
+			if !granted["ALL PRIVILEGES"] && !privilegesGranted(granted, allPrivilegesProbe) {
+				missing = append(missing, privilege)
+			}
+		} else if !granted[privilege] && !granted["ALL PRIVILEGES"] {
+			missing = append(missing, privilege)
+		}
+	}
+	return missing
+}
+
+// privilegesGranted all privileges are in granted set
+func privilegesGranted(granted map[string]bool, privileges []string) bool {
+	for _, privilege := range privileges {
+		if !granted[privilege] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,33 @@
+package mysql
+
+import "testing"
+
+func TestMissingPrivileges(t *testing.T) {
+	grants := []string{
+		"GRANT USAGE ON *.* TO `repl`@`%`",
+		"GRANT SELECT, RELOAD, REPLICATION CLIENT ON *.* TO `repl`@`%`",
+		"GRANT ALL PRIVILEGES ON `app`.* TO `repl`@`%`",
+	}
+
+	if missing := MissingPrivileges(grants, []string{"select", "REPLICATION CLIENT"}, "*.*"); len(missing) != 0 {
+		t.Fatalf("granted privileges are reported missing, got %v", missing)
+	}
+
+	if missing := MissingPrivileges(grants, []string{"SELECT", "REPLICATION SLAVE", "SUPER"}, "*.*"); len(missing) != 2 || missing[0] != "REPLICATION SLAVE" || missing[1] != "SUPER" {
+		t.Fatalf("missing privileges are not correct, got %v", missing)
+	}
+
+	if missing := MissingPrivileges(grants, []string{"ALL PRIVILEGES"}, "app.*"); len(missing) != 0 {
+		t.Fatalf("all privileges on app.* are reported missing, got %v", missing)
+	}
+
+	if missing := MissingPrivileges(grants, []string{"ALL"}, "*.*"); len(missing) != 1 {
+		t.Fatalf("all privileges on *.* are not reported missing, got %v", missing)
+	}
+
+	// mysql 8.0 expands ALL PRIVILEGES on *.* to static privileges
+	expanded := []string{"GRANT SELECT, INSERT, UPDATE, DELETE, CREATE, DROP, RELOAD, INDEX, ALTER, SUPER ON *.* TO `repl`@`%`"}
+	if missing := MissingPrivileges(expanded, []string{"ALL"}, "*.*"); len(missing) != 0 {
+		t.Fatalf("expanded all privileges are reported missing, got %v", missing)
+	}
+}
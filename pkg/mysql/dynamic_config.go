@@ -0,0 +1,131 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	variableNameReg = regexp.MustCompile(`^[a-z0-9_]+$`)
+	sizeValueReg    = regexp.MustCompile(`^(\d+)([KMG])$`)
+)
+
+// ParseMysqldVariables parse [mysqld] section of my.cnf content as system variable name to value.
+// names are normalized as SHOW VARIABLES format, loose- prefix is removed and dash is replaced by underscore
+func ParseMysqldVariables(content string) (variables map[string]string, err error) {
+	variables = make(map[string]string)
+	if strings.TrimSpace(content) == "" {
+		return variables, nil
+	}
+
+	parser := NewConfigParser()
+	if err = parser.Parse(strings.NewReader(content)); err != nil {
+		return nil, err
+	}
+
+	mysqld, err := parser.GetSection("mysqld")
+	if err != nil {
+		return variables, nil
+	}
+
+	for key, value := range mysqld.Data {
+		name := strings.ToLower(strings.TrimSpace(key))
+		name = strings.TrimPrefix(strings.TrimPrefix(name, "loose-"), "loose_")
+		variables[strings.ReplaceAll(name, "-", "_")] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	return variables, nil
+}
+
+// ApplyVariable set global system variable on running members by statement of dialect, config file is rendered again when member restarts.
+// restart is true when variable is read only, it takes effect after members are restarted. invalid name and unknown variable return types.ErrMysqlConfigInvalid,
+// members are not restarted for them because mysqld may refuse to start with unknown option. error is returned when any member can not be connected,
+// variable is not applied until every member has it
+func ApplyVariable(ctx context.Context, members []*DSN, dialect *Dialect, name, value string) (restart bool, err error) {
+	if !variableNameReg.MatchString(name) {
+		return false, fmt.Errorf("%w, [name=%s] is not a system variable name", types.ErrMysqlConfigInvalid, name)
+	}
+
+	var unreachable []string
+	for _, dsn := range members {
+		dbConn, err := Connect(ctx, dsn)
+		if err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf(types.ErrMyqlConnectFaild.Error())
+			unreachable = append(unreachable, dsn.Host)
+			continue
+		}
+
+		_, err = dbConn.ExecContext(ctx, dialect.SetVariableSQL(name, variableLiteral(value)))
+		dbConn.Close()
+
+		var mysqlErr *mysqldriver.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == 1238 { // read only variable, other members are still applied before restart is decided
+			restart = true
+		} else if errors.As(err, &mysqlErr) && mysqlErr.Number == 1193 { // unknown system variable
+			return false, fmt.Errorf("%w, [host=%s] unknown system variable %s", types.ErrMysqlConfigInvalid, dsn.Host, name)
+		} else if err != nil {
+			return false, fmt.Errorf("%w, set [host=%s] %s=%s err -> %s", types.ErrMysqlApplyConfigFailed, dsn.Host, name, value, err.Error())
+		}
+	}
+
+	if len(unreachable) > 0 {
+		return false, fmt.Errorf("%w, set %s=%s, [hosts=%s] can not be connected", types.ErrMysqlApplyConfigFailed, name, value, strings.Join(unreachable, ","))
+	}
+
+	if !restart {
+		logrus.WithFields(map[string]interface{}{"name": name, "value": value}).Info("mysql variable applied to running members")
+	}
+	return restart, nil
+}
+
+// ResetVariable remove persisted value of variable removed from config on running members, so default value is used after restart.
+// nothing is done on mysql 5.7, error is returned when any member can not be connected
+func ResetVariable(ctx context.Context, members []*DSN, dialect *Dialect, name string) (err error) {
+	statement := dialect.ResetVariableSQL(name)
+	if statement == "" || !variableNameReg.MatchString(name) {
+		return nil
+	}
+
+	for _, dsn := range members {
+		dbConn, err := Connect(ctx, dsn)
+		if err != nil {
+			return fmt.Errorf("%w, reset [host=%s] %s err -> %s", types.ErrMysqlApplyConfigFailed, dsn.Host, name, err.Error())
+		}
+
+		_, err = dbConn.ExecContext(ctx, statement)
+		dbConn.Close()
+		if err != nil {
+			return fmt.Errorf("%w, reset [host=%s] %s err -> %s", types.ErrMysqlApplyConfigFailed, dsn.Host, name, err.Error())
+		}
+	}
+	return nil
+}
+
+// variableLiteral sql literal of my.cnf value, size suffix is converted to bytes because SET GLOBAL does not accept it
+func variableLiteral(value string) string {
+	if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return value
+	}
+
+	if match := sizeValueReg.FindStringSubmatch(strings.ToUpper(value)); match != nil {
+		size, _ := strconv.ParseInt(match[1], 10, 64)
+		switch match[2] {
+		case "G":
+			size <<= 30
+		case "M":
+			size <<= 20
+		case "K":
+			size <<= 10
+		}
+		return strconv.FormatInt(size, 10)
+	}
+
+	return QuoteString(value)
+}
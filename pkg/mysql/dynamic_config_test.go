@@ -0,0 +1,41 @@
+package mysql
+
+import "testing"
+
+func TestParseMysqldVariables(t *testing.T) {
+	variables, err := ParseMysqldVariables(`
+max_connections=1000
+[client]
+port=3306
+[mysqld]
+Max-Connections = 2000
+loose-group_replication_member_weight=60
+innodb_buffer_pool_size="1G"
+skip-name-resolve
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(variables) != 4 || variables["max_connections"] != "2000" || variables["group_replication_member_weight"] != "60" || variables["innodb_buffer_pool_size"] != "1G" {
+		t.Fatalf("parse mysqld variables is not correct, got %v", variables)
+	}
+
+	if _, ok := variables["skip_name_resolve"]; !ok {
+		t.Fatalf("option without value is not parsed, got %v", variables)
+	}
+}
+
+func TestVariableLiteral(t *testing.T) {
+	for value, literal := range map[string]string{
+		"1000": "1000",
+		"1G":   "1073741824",
+		"16m":  "16777216",
+		"ON":   "'ON'",
+		"a'b":  `'a\'b'`,
+	} {
+		if got := variableLiteral(value); got != literal {
+			t.Fatalf("literal of %s is not correct, expected %s got %s", value, literal, got)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package mysql
+
+import (
+	"context"
+
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrantTransactions find transactions executed on members but not on any master, result key is member host, members without errant transactions are not in result.
+// masters gtid_executed are queried after members are probed, so transactions committed on masters during probe are not reported as errant
+func ErrantTransactions(ctx context.Context, masters []*DSN, members []*MemberStatus) (errant map[string]GTIDSet, err error) {
+	mastersExecuted := make(GTIDSet)
+	for _, master := range masters {
+		dbConn, err := Connect(ctx, master)
+		if err != nil {
+			return nil, types.ErrMyqlConnectFaild
+		}
+
+		var executed string
+		err = dbConn.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&executed)
+		dbConn.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		set, err := ParseGTIDSet(executed)
+		if err != nil {
+			return nil, err
+		}
+		mastersExecuted = mastersExecuted.Union(set)
+	}
+
+	errant = make(map[string]GTIDSet)
+	for _, member := range members {
+		if !member.Reachable || dsnInList(masters, &DSN{Host: member.Host}) {
+			continue
+		}
+
+		set, err := errantGTIDSet(member.GTIDExecuted, mastersExecuted)
+		if err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": member.Host}).Debugf("mysql parse member gtid_executed failed")
+			continue
+		}
+
+		if len(set) > 0 {
+			errant[member.Host] = set
+		}
+	}
+
+	return errant, nil
+}
+
+// errantGTIDSet return transactions of member gtid_executed which are not executed on masters
+func errantGTIDSet(memberExecuted string, mastersExecuted GTIDSet) (errant GTIDSet, err error) {
+	executed, err := ParseGTIDSet(memberExecuted)
+	if err != nil {
+		return nil, err
+	}
+	return executed.Subtract(mastersExecuted), nil
+}
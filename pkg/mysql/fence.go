@@ -0,0 +1,79 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// fence stop writes of member which is not recorded master, such as old master come back after failover.
+// super_read_only is turned on before anything else, then semi sync master module is disabled and client connections are killed,
+// so proxysql moves member to reader hostgroup and no open transaction of old clients is committed. member is repointed by joinMaster later
+func (t *Replication) fence(ctx context.Context, dsn *DSN) (err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf(types.ErrMyqlConnectFaild.Error())
+		return nil
+	}
+	defer dbConn.Close()
+
+	readOnly, err := getReadOnly(ctx, dbConn)
+	if err != nil {
+		return fmt.Errorf("%w, query [host=%s] read only err -> %s", types.ErrMysqlFenceFailed, dsn.Host, err.Error())
+	}
+
+	if readOnly && !t.sourceAckON(ctx, dbConn) {
+		return nil
+	}
+
+	if _, err = dbConn.ExecContext(ctx, "SET GLOBAL super_read_only=1"); err != nil {
+		return fmt.Errorf("%w, set [host=%s] super read only err -> %s", types.ErrMysqlFenceFailed, dsn.Host, err.Error())
+	}
+
+	if err = t.disableSourceAck(ctx, dbConn); err != nil {
+		return fmt.Errorf("%w, disable [host=%s] master module err -> %s", types.ErrMysqlFenceFailed, dsn.Host, err.Error())
+	}
+
+	killed, err := killClientConnections(ctx, dbConn)
+	if err != nil {
+		return fmt.Errorf("%w, kill [host=%s] client connections err -> %s", types.ErrMysqlFenceFailed, dsn.Host, err.Error())
+	}
+
+	logrus.WithFields(map[string]interface{}{"host": dsn.Host, "killed": killed}).Warnf("mysql %s member is not recorded master but accepts writes, it is fenced", t.mode())
+	return nil
+}
+
+// killClientConnections kill connections of clients, system threads, replication dump threads and current connection are kept
+func killClientConnections(ctx context.Context, dbConn *sql.Conn) (killed int, err error) {
+	result, err := dbConn.QueryContext(ctx, "SELECT ID FROM information_schema.PROCESSLIST WHERE ID!=CONNECTION_ID() "+
+		"AND USER NOT IN ('system user','event_scheduler') AND COMMAND NOT IN ('Binlog Dump','Binlog Dump GTID','Daemon')")
+	if err != nil {
+		return 0, err
+	}
+
+	var ids []int64
+	for result.Next() {
+		var id int64
+		if err = result.Scan(&id); err != nil {
+			result.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	if err = result.Err(); err != nil {
+		result.Close()
+		return 0, err
+	}
+	result.Close()
+
+	for _, id := range ids {
+		// connection may be closed by client already
+		if _, err = dbConn.ExecContext(ctx, fmt.Sprintf("KILL CONNECTION %d", id)); err == nil {
+			killed++
+		}
+	}
+	return killed, nil
+}
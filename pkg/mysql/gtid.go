@@ -0,0 +1,182 @@
+package mysql
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GTIDInterval transaction id interval of gtid set, Start and End are included
+type GTIDInterval struct {
+	Start int64
+	End   int64
+}
+
+// GTIDSet mysql gtid set, key is source server uuid, value is sorted and merged transaction id intervals
+type GTIDSet map[string][]GTIDInterval
+
+// ParseGTIDSet parse gtid set text like 3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5:7,24DA167-0C0C-11E8-8442-00059A3C7B00:1-3
+func ParseGTIDSet(s string) (set GTIDSet, err error) {
+	set = make(GTIDSet)
+	s = strings.NewReplacer("\n", "", "\r", "", " ", "", "\t", "").Replace(s)
+	if s == "" {
+		return set, nil
+	}
+
+	for _, item := range strings.Split(s, ",") {
+		arr := strings.Split(item, ":")
+		if len(arr) < 2 {
+			return nil, fmt.Errorf("invalid gtid set item [%s]", item)
+		}
+
+		uuid := strings.ToLower(arr[0])
+		for _, intervalStr := range arr[1:] {
+			var interval GTIDInterval
+			ids := strings.SplitN(intervalStr, "-", 2)
+			if interval.Start, err = strconv.ParseInt(ids[0], 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid gtid set interval [%s], err -> %s", intervalStr, err.Error())
+			}
+
+			interval.End = interval.Start
+			if len(ids) > 1 {
+				if interval.End, err = strconv.ParseInt(ids[1], 10, 64); err != nil {
+					return nil, fmt.Errorf("invalid gtid set interval [%s], err -> %s", intervalStr, err.Error())
+				}
+			}
+
+			if interval.End < interval.Start {
+				return nil, fmt.Errorf("invalid gtid set interval [%s]", intervalStr)
+			}
+
+			set[uuid] = append(set[uuid], interval)
+		}
+	}
+
+	for uuid, intervals := range set {
+		set[uuid] = mergeGTIDIntervals(intervals)
+	}
+
+	return set, nil
+}
+
+// mergeGTIDIntervals sort intervals and merge overlapping or adjacent intervals
+func mergeGTIDIntervals(intervals []GTIDInterval) (merged []GTIDInterval) {
+	sort.Slice(intervals, func(i, j int) bool { return intervals[i].Start < intervals[j].Start })
+	for _, interval := range intervals {
+		if n := len(merged); n > 0 && interval.Start <= merged[n-1].End+1 {
+			if interval.End > merged[n-1].End {
+				merged[n-1].End = interval.End
+			}
+			continue
+		}
+		merged = append(merged, interval)
+	}
+	return merged
+}
+
+// Contains check all transactions of other gtid set are included in this gtid set
+func (t GTIDSet) Contains(other GTIDSet) bool {
+	return len(other.Subtract(t)) == 0
+}
+
+// Subtract return transactions of this gtid set which are not included in other gtid set
+func (t GTIDSet) Subtract(other GTIDSet) (result GTIDSet) {
+	result = make(GTIDSet)
+	for uuid, intervals := range t {
+		var left []GTIDInterval
+		for _, interval := range intervals {
+			remains := []GTIDInterval{interval}
+			for _, sub := range other[uuid] {
+				var next []GTIDInterval
+				for _, r := range remains {
+					if sub.End < r.Start || sub.Start > r.End {
+						next = append(next, r)
+						continue
+					}
+					if sub.Start > r.Start {
+						next = append(next, GTIDInterval{Start: r.Start, End: sub.Start - 1})
+					}
+					if sub.End < r.End {
+						next = append(next, GTIDInterval{Start: sub.End + 1, End: r.End})
+					}
+				}
+				remains = next
+			}
+			left = append(left, remains...)
+		}
+
+		if len(left) > 0 {
+			result[uuid] = left
+		}
+	}
+	return result
+}
+
+// Count return transactions count of gtid set
+func (t GTIDSet) Count() (count int64) {
+	for _, intervals := range t {
+		for _, interval := range intervals {
+			count += interval.End - interval.Start + 1
+		}
+	}
+	return count
+}
+
+// String return gtid set text in mysql format, server uuid are sorted
+func (t GTIDSet) String() string {
+	var uuids []string
+	for uuid := range t {
+		uuids = append(uuids, uuid)
+	}
+	sort.Strings(uuids)
+
+	var items []string
+	for _, uuid := range uuids {
+		item := uuid
+		for _, interval := range t[uuid] {
+			if interval.Start == interval.End {
+				item += ":" + strconv.FormatInt(interval.Start, 10)
+			} else {
+				item += ":" + strconv.FormatInt(interval.Start, 10) + "-" + strconv.FormatInt(interval.End, 10)
+			}
+		}
+		items = append(items, item)
+	}
+	return strings.Join(items, ",")
+}
+
+// MostAdvancedGTIDSet return index of gtid set which contains all other gtid sets.
+// if gtid sets are diverged, the one has most transactions is returned, diverged will be true.
+// if sets is empty, -1 is returned
+func MostAdvancedGTIDSet(sets []GTIDSet) (index int, diverged bool) {
+	index = -1
+	for k, set := range sets {
+		if index < 0 || set.Count() > sets[index].Count() {
+			index = k
+		}
+	}
+
+	for _, set := range sets {
+		if index >= 0 && !sets[index].Contains(set) {
+			return index, true
+		}
+	}
+
+	return index, false
+}
+
+// Union return transactions included in this gtid set or other gtid set
+func (t GTIDSet) Union(other GTIDSet) (result GTIDSet) {
+	result = make(GTIDSet)
+	for _, set := range []GTIDSet{t, other} {
+		for uuid, intervals := range set {
+			result[uuid] = append(result[uuid], intervals...)
+		}
+	}
+
+	for uuid, intervals := range result {
+		result[uuid] = mergeGTIDIntervals(intervals)
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package mysql
+
+import "testing"
+
+func TestParseGTIDSet(t *testing.T) {
+	set, err := ParseGTIDSet("3E11FA47-71CA-11E1-9E33-C80AA9429562:1-5:7:6,\n24da167a-0c0c-11e8-8442-00059a3c7b00:1-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if set.String() != "24da167a-0c0c-11e8-8442-00059a3c7b00:1-3,3e11fa47-71ca-11e1-9e33-c80aa9429562:1-7" {
+		t.Fatalf("unexpected gtid set %s", set)
+	}
+
+	if set.Count() != 10 {
+		t.Fatalf("unexpected gtid set count %d", set.Count())
+	}
+
+	if _, err = ParseGTIDSet("3E11FA47-71CA-11E1-9E33-C80AA9429562:5-1"); err == nil {
+		t.Fatal("invalid gtid set must return error")
+	}
+}
+
+func TestGTIDSetSubtract(t *testing.T) {
+	a, _ := ParseGTIDSet("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:1-10,bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb:1")
+	b, _ := ParseGTIDSet("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:3-5:8")
+
+	if s := a.Subtract(b).String(); s != "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:1-2:6-7:9-10,bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb:1" {
+		t.Fatalf("unexpected subtract result %s", s)
+	}
+
+	if !a.Contains(b) || b.Contains(a) {
+		t.Fatal("gtid set contains check failed")
+	}
+
+	c, _ := ParseGTIDSet("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:11-12")
+	if s := a.Union(c).String(); s != "aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:1-12,bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb:1" {
+		t.Fatalf("unexpected union result %s", s)
+	}
+}
+
+func TestMostAdvancedGTIDSet(t *testing.T) {
+	a, _ := ParseGTIDSet("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:1-10")
+	b, _ := ParseGTIDSet("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:1-12")
+	c, _ := ParseGTIDSet("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:1-11")
+
+	if index, diverged := MostAdvancedGTIDSet([]GTIDSet{a, b, c}); index != 1 || diverged {
+		t.Fatalf("unexpected most advanced gtid set index=%d diverged=%t", index, diverged)
+	}
+
+	d, _ := ParseGTIDSet("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:1-10,bbbbbbbb-bbbb-bbbb-bbbb-bbbbbbbbbbbb:1")
+	if _, diverged := MostAdvancedGTIDSet([]GTIDSet{b, d}); !diverged {
+		t.Fatal("diverged gtid sets not detected")
+	}
+}
+
+func TestErrantGTIDSet(t *testing.T) {
+	masters, _ := ParseGTIDSet("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:1-10")
+
+	errant, err := errantGTIDSet("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:1-8", masters)
+	if err != nil || len(errant) > 0 {
+		t.Fatalf("lagging replica must not have errant transactions, errant=%s", errant)
+	}
+
+	errant, err = errantGTIDSet("aaaaaaaa-aaaa-aaaa-aaaa-aaaaaaaaaaaa:1-10,cccccccc-cccc-cccc-cccc-cccccccccccc:1-2", masters)
+	if err != nil || errant.String() != "cccccccc-cccc-cccc-cccc-cccccccccccc:1-2" {
+		t.Fatalf("unexpected errant transactions %s", errant)
+	}
+}
@@ -0,0 +1,156 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"sync"
+)
+
+// MemberStatus replication detail of a mysql member, collected from SHOW SLAVE STATUS, performance_schema and global status
+type MemberStatus struct {
+	Host string
+	// Reachable member can be connected and queried
+	Reachable bool
+	// MemberState group replication member state, empty if member is not in a group
+	MemberState string
+	// GTIDExecuted value of gtid_executed
+	GTIDExecuted string
+	// SecondsBehindSource replication lag of default channel, nil if member is not a replica or sql thread is not running
+	SecondsBehindSource *int64
+	// IORunning io thread state of default channel, such as Yes No Connecting, empty if member is not a replica
+	IORunning string
+	// SQLRunning sql thread state of default channel, empty if member is not a replica
+	SQLRunning   string
+	LastIOError  string
+	LastSQLError string
+	// SemiSyncSourceStatus semi sync master is waiting replica ack, OFF means master fall back to async replication
+	SemiSyncSourceStatus string
+	// SemiSyncReplicaStatus semi sync replica is sending ack
+	SemiSyncReplicaStatus string
+	// Error probe failed reason
+	Error string
+}
+
+// ProbeMembers collect replication detail of all members in parallel, result is in same order of dataSources
+func ProbeMembers(ctx context.Context, dataSources []*DSN, dialect *Dialect) (statuses []*MemberStatus) {
+	statuses = make([]*MemberStatus, len(dataSources))
+	var wg sync.WaitGroup
+	for k, dsn := range dataSources {
+		wg.Add(1)
+		go func(k int, dsn *DSN) {
+			defer wg.Done()
+			statuses[k] = ProbeMember(ctx, dsn, dialect)
+		}(k, dsn)
+	}
+	wg.Wait()
+	return statuses
+}
+
+// ProbeMember collect replication detail of member, query errors are reported in MemberStatus.Error
+func ProbeMember(ctx context.Context, dsn *DSN, dialect *Dialect) (status *MemberStatus) {
+	status = &MemberStatus{Host: dsn.Host}
+
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	defer dbConn.Close()
+
+	if err = dbConn.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&status.GTIDExecuted); err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.Reachable = true
+
+	var memberState sql.NullString
+	err = dbConn.QueryRowContext(ctx, "SELECT MEMBER_STATE FROM performance_schema.replication_group_members WHERE MEMBER_ID=@@server_uuid").Scan(&memberState)
+	if err != nil && err != sql.ErrNoRows {
+		status.Error = err.Error()
+		return status
+	}
+	status.MemberState = memberState.String
+
+	replicaStatus, err := queryReplicaStatus(ctx, dbConn, dialect)
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+
+	if len(replicaStatus) > 0 {
+		status.IORunning = replicaStatus[dialect.ReplicaIORunningColumn()]
+		status.SQLRunning = replicaStatus[dialect.ReplicaSQLRunningColumn()]
+		status.LastIOError = replicaStatus["Last_IO_Error"]
+		status.LastSQLError = replicaStatus["Last_SQL_Error"]
+		if seconds, err := strconv.ParseInt(replicaStatus[dialect.SecondsBehindSourceColumn()], 10, 64); err == nil {
+			status.SecondsBehindSource = &seconds
+		}
+	}
+
+	semiSyncStatus, err := queryGlobalStatus(ctx, dbConn, "Rpl_semi_sync_%")
+	if err != nil {
+		status.Error = err.Error()
+		return status
+	}
+	status.SemiSyncSourceStatus = semiSyncStatus[dialect.SemiSyncSourceStatusVariable()]
+	status.SemiSyncReplicaStatus = semiSyncStatus[dialect.SemiSyncReplicaStatusVariable()]
+
+	return status
+}
+
+// queryReplicaStatus return SHOW SLAVE STATUS result as column name to value map, map is empty if instance is not a replica
+func queryReplicaStatus(ctx context.Context, dbConn *sql.Conn, dialect *Dialect) (status map[string]string, err error) {
+	result, err := dbConn.QueryContext(ctx, dialect.ShowReplicaStatusSQL())
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	// code source http://noops.me/?p=1128
+	cols, _ := result.Columns()
+	buff := make([]interface{}, len(cols))
+	data := make([]sql.NullString, len(cols))
+	for i := range buff {
+		buff[i] = &data[i]
+	}
+
+	status = make(map[string]string)
+	for result.Next() {
+		if err = result.Scan(buff...); err != nil {
+			return nil, err
+		}
+
+		for k, v := range cols {
+			status[v] = data[k].String
+		}
+	}
+
+	return status, nil
+}
+
+// showGlobalVariable return value of global variable name, sql.ErrNoRows is returned if variable not exists, such as variables of uninstalled plugin
+func showGlobalVariable(ctx context.Context, dbConn *sql.Conn, name string) (value string, err error) {
+	err = dbConn.QueryRowContext(ctx, "SHOW GLOBAL VARIABLES LIKE ?", name).Scan(&name, &value)
+	return value, err
+}
+
+// queryGlobalStatus return global status variables matched pattern as name to value map
+func queryGlobalStatus(ctx context.Context, dbConn *sql.Conn, pattern string) (status map[string]string, err error) {
+	result, err := dbConn.QueryContext(ctx, "SHOW GLOBAL STATUS LIKE ?", pattern)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	status = make(map[string]string)
+	for result.Next() {
+		var name, value string
+		if err = result.Scan(&name, &value); err != nil {
+			return nil, err
+		}
+		status[name] = value
+	}
+
+	return status, nil
+}
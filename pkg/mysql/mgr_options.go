@@ -0,0 +1,99 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// mgrVariableVersions minimum mysql server version of group replication variables
+var mgrVariableVersions = map[string][3]int{
+	"group_replication_flow_control_mode":                {5, 7, 17},
+	"group_replication_flow_control_applier_threshold":   {5, 7, 17},
+	"group_replication_flow_control_certifier_threshold": {5, 7, 17},
+	"group_replication_recovery_retry_count":             {5, 7, 17},
+	"group_replication_unreachable_majority_timeout":     {5, 7, 19},
+	"group_replication_member_weight":                    {5, 7, 20},
+	"group_replication_member_expel_timeout":             {8, 0, 13},
+	"group_replication_consistency":                      {8, 0, 14},
+	"group_replication_autorejoin_tries":                 {8, 0, 16},
+}
+
+// MGROptions group replication variable name to value, name is without loose- prefix, such as group_replication_consistency
+type MGROptions map[string]string
+
+// ParseMGROptions parse options formatted by MGROptions.String, such as group_replication_member_weight=60,group_replication_consistency=BEFORE
+func ParseMGROptions(s string) (options MGROptions) {
+	options = make(MGROptions)
+	for _, item := range strings.Split(s, ",") {
+		arr := strings.SplitN(strings.TrimSpace(item), "=", 2)
+		if len(arr) != 2 || arr[0] == "" {
+			continue
+		}
+		options[arr[0]] = arr[1]
+	}
+	return options
+}
+
+// String format options as name=value list splited by comma, names are sorted
+func (t MGROptions) String() string {
+	var items []string
+	for name, value := range t {
+		items = append(items, name+"="+value)
+	}
+	sort.Strings(items)
+	return strings.Join(items, ",")
+}
+
+// Supported return options supported by mysql server version of dialect, unknown variables are dropped
+func (t MGROptions) Supported(dialect *Dialect) (options MGROptions) {
+	options = make(MGROptions)
+	for name, value := range t {
+		if version, ok := mgrVariableVersions[name]; ok && dialect.AtLeast(version[0], version[1], version[2]) {
+			options[name] = value
+		}
+	}
+	return options
+}
+
+// applyMGROptions set group replication variables of running member when they differ, all variables of MGROptions are dynamic
+func applyMGROptions(ctx context.Context, dsn *DSN, dialect *Dialect, options MGROptions) (err error) {
+	if len(options) < 1 {
+		return nil
+	}
+
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	for name, value := range options.Supported(dialect) {
+		current, err := showGlobalVariable(ctx, dbConn, name)
+		if err != nil {
+			return fmt.Errorf("%w, query [host=%s] %s err -> %s", types.ErrMysqlMGROptionsFailed, dsn.Host, name, err.Error())
+		}
+
+		if strings.EqualFold(current, value) {
+			continue
+		}
+
+		// numeric variables do not accept quoted value
+		literal := value
+		if _, err := strconv.Atoi(value); err != nil {
+			literal = QuoteString(value)
+		}
+
+		if _, err = dbConn.ExecContext(ctx, "SET GLOBAL "+name+"="+literal); err != nil {
+			return fmt.Errorf("%w, set [host=%s] %s=%s err -> %s", types.ErrMysqlMGROptionsFailed, dsn.Host, name, value, err.Error())
+		}
+		logrus.WithFields(map[string]interface{}{"host": dsn.Host, "name": name, "value": value, "old": current}).Info("mysql group replication variable changed")
+	}
+
+	return nil
+}
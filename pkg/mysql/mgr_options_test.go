@@ -0,0 +1,25 @@
+package mysql
+
+import "testing"
+
+func TestMGROptions(t *testing.T) {
+	options := ParseMGROptions("group_replication_member_weight=60, group_replication_consistency=BEFORE,invalid,=1")
+	if len(options) != 2 || options["group_replication_member_weight"] != "60" || options["group_replication_consistency"] != "BEFORE" {
+		t.Fatalf("parse mgr options is not correct, got %v", options)
+	}
+
+	if s := options.String(); s != "group_replication_consistency=BEFORE,group_replication_member_weight=60" {
+		t.Fatalf("format mgr options is not correct, got %s", s)
+	}
+
+	mysql57, _ := NewDialect("5.7.34")
+	mysql80, _ := NewDialect("8.0.27")
+	options["unknown_variable"] = "1"
+	if supported := options.Supported(mysql57); len(supported) != 1 || supported["group_replication_member_weight"] != "60" {
+		t.Fatalf("mgr options supported by 5.7 is not correct, got %v", supported)
+	}
+
+	if supported := options.Supported(mysql80); len(supported) != 2 {
+		t.Fatalf("mgr options supported by 8.0 is not correct, got %v", supported)
+	}
+}
@@ -0,0 +1,218 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// GroupPartition group replication partition handling options.
+// when majority of members are UNREACHABLE, surviving partition blocks writes until quorum is restored or group membership is forced
+type GroupPartition struct {
+	// Force unblock surviving partition with group_replication_force_members when quorum is lost
+	Force bool
+	// Status partition check result made by StartCluster, nil if partition is not checked
+	Status *PartitionStatus
+}
+
+// PartitionStatus group view of the largest partition of reachable members
+type PartitionStatus struct {
+	// QuorumLost ONLINE members of largest partition are not majority of group
+	QuorumLost bool
+	// Online hosts of ONLINE members in largest partition
+	Online []string
+	// Unreachable member hosts reported UNREACHABLE by largest partition
+	Unreachable []string
+	// Forced group membership is forced to ONLINE members of largest partition
+	Forced bool
+	// Message reason of status
+	Message string
+}
+
+// groupView group members seen by a reachable member
+type groupView struct {
+	dsn          *DSN
+	serverUUID   string
+	localAddress string
+	// members member id to member state
+	members map[string]string
+	// hosts member id to MEMBER_HOST
+	hosts map[string]string
+}
+
+// Resolve detect loss of quorum, unblock surviving partition when Force is true, otherwise types.ErrMysqlMGRQuorumLost is returned.
+// members of other partitions are returned only after members are forced, their group replication must be restarted to rejoin forced group
+func (t *GroupPartition) Resolve(ctx context.Context, dataSources []*DSN) (rejoin []*DSN, err error) {
+	if t == nil {
+		return nil, nil
+	}
+
+	var views []*groupView
+	for _, dsn := range dataSources {
+		view, err := queryGroupView(ctx, dsn)
+		if err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql query group view failed")
+			continue
+		}
+		views = append(views, view)
+	}
+
+	survivor := largestPartition(views)
+	if survivor == nil {
+		t.Status = &PartitionStatus{Message: "no member is online"}
+		return nil, nil
+	}
+
+	status := &PartitionStatus{}
+	var online []*groupView
+	for id, state := range survivor.members {
+		if state == "UNREACHABLE" {
+			status.Unreachable = append(status.Unreachable, survivor.hosts[id])
+		}
+	}
+	for _, view := range views {
+		if survivor.members[view.serverUUID] == "ONLINE" && view.members[view.serverUUID] == "ONLINE" {
+			online = append(online, view)
+			status.Online = append(status.Online, view.dsn.Host)
+		}
+	}
+	sort.Strings(status.Unreachable)
+	sort.Strings(status.Online)
+
+	t.Status = status
+	// member which is ONLINE in its own view may be RECOVERING in view of survivor during ordinary view change, it is not stale when group has quorum
+	if len(status.Unreachable) < 1 || onlineCount(survivor) >= len(survivor.members)/2+1 {
+		return nil, nil
+	}
+
+	status.QuorumLost = true
+	status.Message = fmt.Sprintf("%d of %d members are online in largest partition, group is blocked", onlineCount(survivor), len(survivor.members))
+	if !t.Force {
+		status.Message += fmt.Sprintf(", set annotation %s=true to force group members", types.MysqlForceMembersAnnotationName)
+		return nil, fmt.Errorf("%w, %s", types.ErrMysqlMGRQuorumLost, status.Message)
+	}
+
+	// every ONLINE member of surviving partition must be kept, otherwise it is expelled by forced membership
+	if len(online) != onlineCount(survivor) {
+		status.Message += fmt.Sprintf(", %d online members are not reachable by operator, force members is refused", onlineCount(survivor)-len(online))
+		return nil, fmt.Errorf("%w, %s", types.ErrMysqlMGRQuorumLost, status.Message)
+	}
+
+	if err = forceMembers(ctx, survivor.dsn, online); err != nil {
+		status.Message = err.Error()
+		return nil, err
+	}
+
+	status.Forced = true
+	status.Message = fmt.Sprintf("group members are forced to [%s]", strings.Join(status.Online, ","))
+	logrus.WithField("members", status.Online).Warn("mysql group replication quorum lost, group members are forced")
+
+	return staleMembers(views, survivor), nil
+}
+
+// staleMembers members which are ONLINE in their own view but not in view of surviving partition, they are left in old partition
+func staleMembers(views []*groupView, survivor *groupView) (stale []*DSN) {
+	for _, view := range views {
+		if view.members[view.serverUUID] == "ONLINE" && survivor.members[view.serverUUID] != "ONLINE" {
+			stale = append(stale, view.dsn)
+		}
+	}
+	return stale
+}
+
+// queryGroupView query group members and their states seen by member
+func queryGroupView(ctx context.Context, dsn *DSN) (view *groupView, err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return nil, types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	view = &groupView{dsn: dsn, members: make(map[string]string), hosts: make(map[string]string)}
+	if err = dbConn.QueryRowContext(ctx, "SELECT @@server_uuid, @@group_replication_local_address").Scan(&view.serverUUID, &view.localAddress); err != nil {
+		return nil, err
+	}
+
+	result, err := dbConn.QueryContext(ctx, "SELECT MEMBER_ID, MEMBER_HOST, MEMBER_STATE FROM performance_schema.replication_group_members WHERE MEMBER_ID!=''")
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		var id, host, state string
+		if err = result.Scan(&id, &host, &state); err != nil {
+			return nil, err
+		}
+		view.members[id] = state
+		view.hosts[id] = host
+	}
+
+	return view, result.Err()
+}
+
+// largestPartition return view of ONLINE member which sees most ONLINE members, nil if no member is ONLINE
+func largestPartition(views []*groupView) (survivor *groupView) {
+	for _, view := range views {
+		if view.members[view.serverUUID] != "ONLINE" {
+			continue
+		}
+		if survivor == nil || onlineCount(view) > onlineCount(survivor) {
+			survivor = view
+		}
+	}
+	return survivor
+}
+
+// onlineCount ONLINE members count of view
+func onlineCount(view *groupView) (count int) {
+	for _, state := range view.members {
+		if state == "ONLINE" {
+			count++
+		}
+	}
+	return count
+}
+
+// forceMembers set group membership to local addresses of online members, variable is cleared after membership is forced
+func forceMembers(ctx context.Context, dsn *DSN, online []*groupView) (err error) {
+	var addresses []string
+	for _, view := range online {
+		addresses = append(addresses, view.localAddress)
+	}
+
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	if _, err = dbConn.ExecContext(ctx, "SET GLOBAL group_replication_force_members=?", strings.Join(addresses, ",")); err != nil {
+		return fmt.Errorf("%w, force [host=%s] members [%s] err -> %s", types.ErrMysqlMGRForceMembersFailed, dsn.Host, strings.Join(addresses, ","), err.Error())
+	}
+
+	if _, err = dbConn.ExecContext(ctx, "SET GLOBAL group_replication_force_members=''"); err != nil {
+		return fmt.Errorf("%w, clear [host=%s] force members err -> %s", types.ErrMysqlMGRForceMembersFailed, dsn.Host, err.Error())
+	}
+
+	return nil
+}
+
+// stopGroupReplication stop group replication of member left in old partition, it rejoins group by START group_replication later
+func stopGroupReplication(ctx context.Context, dsn *DSN) (err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	if _, err = dbConn.ExecContext(ctx, "STOP group_replication"); err != nil {
+		return fmt.Errorf("%w, stop [host=%s] group replication err -> %s", types.ErrMysqlMGRForceMembersFailed, dsn.Host, err.Error())
+	}
+	logrus.WithField("host", dsn.Host).Info("mysql member of old partition left group, it will rejoin forced group")
+	return nil
+}
@@ -0,0 +1,33 @@
+package mysql
+
+import "testing"
+
+func TestLargestPartition(t *testing.T) {
+	// mysql-0 is in surviving partition, mysql-1 is left in old partition, mysql-2 is down
+	views := []*groupView{
+		{dsn: &DSN{Host: "yuxing-mysql-0"}, serverUUID: "a", members: map[string]string{"a": "ONLINE", "b": "UNREACHABLE", "c": "UNREACHABLE"}},
+		{dsn: &DSN{Host: "yuxing-mysql-1"}, serverUUID: "b", members: map[string]string{"a": "UNREACHABLE", "b": "ONLINE", "c": "UNREACHABLE"}},
+	}
+
+	survivor := largestPartition(views)
+	if survivor == nil || survivor.serverUUID != "a" {
+		t.Fatal("largest partition is not correct")
+	}
+
+	if onlineCount(survivor) >= len(survivor.members)/2+1 {
+		t.Fatal("quorum of largest partition must be lost")
+	}
+
+	if stale := staleMembers(views, survivor); len(stale) != 1 || stale[0].Host != "yuxing-mysql-1" {
+		t.Fatalf("stale members are not correct, got %v", stale)
+	}
+
+	views[0].members = map[string]string{"a": "ONLINE", "c": "ONLINE"}
+	if survivor = largestPartition(views); survivor.serverUUID != "a" || onlineCount(survivor) < len(survivor.members)/2+1 {
+		t.Fatal("forced partition must have quorum")
+	}
+
+	if largestPartition([]*groupView{{serverUUID: "a", members: map[string]string{"a": "OFFLINE"}}}) != nil {
+		t.Fatal("offline member must not be largest partition")
+	}
+}
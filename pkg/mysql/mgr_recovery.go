@@ -0,0 +1,134 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// GroupRecovery group replication full outage recovery options.
+// after all members are down, group must be bootstrapped from the member has most advanced transactions,
+// otherwise transactions only exist on other members will be lost
+type GroupRecovery struct {
+	// FirstBoot group has never been bootstrapped, first data source is bootstrap member
+	FirstBoot bool
+	// Force bootstrap group even if quorum of members are not reachable
+	Force bool
+	// Decision bootstrap decision made by StartCluster, nil if group is not bootstrapped
+	Decision *BootstrapDecision
+}
+
+// BootstrapDecision which member group replication is bootstrapped from, and why
+type BootstrapDecision struct {
+	// Host bootstrap member host
+	Host string
+	// GTIDExecuted gtid_executed and received transactions of bootstrap member
+	GTIDExecuted string
+	// ReachableMembers reachable members count when bootstrap
+	ReachableMembers int
+	// Forced bootstrap without quorum of members reachable
+	Forced bool
+	// Message reason of decision
+	Message string
+}
+
+// ChooseBootstrapMember collect gtid_executed from every reachable member, return the member has most advanced transactions.
+// if quorum of members are not reachable and Force is false, types.ErrMysqlMGRBootstrapRefused is returned
+func (t *GroupRecovery) ChooseBootstrapMember(ctx context.Context, dataSources []*DSN) (member *DSN, err error) {
+	if t == nil || t.FirstBoot {
+		if len(dataSources) < 1 {
+			return nil, types.ErrMasterNoutFound
+		}
+		t.setDecision(&BootstrapDecision{Host: dataSources[0].Host, Message: "group first boot"})
+		return dataSources[0], nil
+	}
+
+	var reachable []*DSN
+	var gtidSets []GTIDSet
+	for _, dsn := range dataSources {
+		gtidSet, err := getMemberTransactions(ctx, dsn)
+		if err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql query member transactions failed")
+			continue
+		}
+		reachable = append(reachable, dsn)
+		gtidSets = append(gtidSets, gtidSet)
+	}
+
+	quorum := len(dataSources)/2 + 1
+	if len(reachable) < quorum && !t.Force {
+		return nil, fmt.Errorf("%w, reachable members %d less than quorum %d, set annotation %s=true to force bootstrap",
+			types.ErrMysqlMGRBootstrapRefused, len(reachable), quorum, types.MysqlForceBootstrapAnnotationName)
+	}
+
+	index, diverged := MostAdvancedGTIDSet(gtidSets)
+	if index < 0 {
+		return nil, fmt.Errorf("%w, no member is reachable", types.ErrMysqlMGRBootstrapRefused)
+	}
+
+	member = reachable[index]
+	decision := &BootstrapDecision{
+		Host:             member.Host,
+		GTIDExecuted:     gtidSets[index].String(),
+		ReachableMembers: len(reachable),
+		Forced:           len(reachable) < quorum,
+		Message:          fmt.Sprintf("member has most advanced transactions of %d/%d reachable members", len(reachable), len(dataSources)),
+	}
+
+	if diverged {
+		decision.Message += ", transactions of members are diverged"
+		logrus.WithField("host", member.Host).Warn("mysql group members transactions are diverged, bootstrap from member has most transactions")
+	}
+
+	t.setDecision(decision)
+	return member, nil
+}
+
+func (t *GroupRecovery) setDecision(decision *BootstrapDecision) {
+	if t != nil {
+		t.Decision = decision
+	}
+}
+
+// getMemberTransactions return gtid_executed and received but not applied transactions of group replication applier channel
+func getMemberTransactions(ctx context.Context, dsn *DSN) (gtidSet GTIDSet, err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return nil, types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	var gtidExecuted string
+	if err = dbConn.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&gtidExecuted); err != nil {
+		return nil, err
+	}
+
+	if gtidSet, err = ParseGTIDSet(gtidExecuted); err != nil {
+		return nil, err
+	}
+
+	// relay log of applier channel will be applied when group replication start
+	result, err := dbConn.QueryContext(ctx, "SELECT RECEIVED_TRANSACTION_SET FROM performance_schema.replication_connection_status WHERE CHANNEL_NAME='group_replication_applier'")
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	var received []string
+	for result.Next() {
+		var s string
+		if err = result.Scan(&s); err == nil && s != "" {
+			received = append(received, s)
+		}
+	}
+
+	receivedSet, err := ParseGTIDSet(strings.Join(received, ","))
+	if err != nil {
+		return nil, err
+	}
+
+	return gtidSet.Union(receivedSet), nil
+}
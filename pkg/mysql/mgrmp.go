@@ -0,0 +1,283 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// MGRMP mysql group replication multi primary mode cluster manager, every ONLINE member of group is a writer
+type MGRMP struct {
+	// DataSrouces mysql instance data sources
+	DataSrouces []*DSN
+	// Dialect mysql server version specific sql statements
+	Dialect *Dialect
+	// Recovery full outage recovery options, choose bootstrap member when no member is running
+	Recovery *GroupRecovery
+	// Cloner seed member from donor before it joins cluster, nil if clone is disabled
+	Cloner *Cloner
+	// Options group replication variables applied to running members
+	Options MGROptions
+	// Partition loss of quorum handling options, nil if partition is not checked
+	Partition *GroupPartition
+	// Maintenance members under maintenance by DBA, group replication of them is not started or changed
+	Maintenance []*DSN
+}
+
+func (t *MGRMP) StartCluster(ctx context.Context) (err error) {
+	select {
+	case <-ctx.Done():
+		return types.ErrCtxTimeout
+	default:
+		var masters []*DSN
+		var bootMember *DSN
+		masters, _ = t.FindMaster(ctx)
+
+		// members left in old partition rejoin group after surviving partition is unblocked
+		rejoin, err := t.Partition.Resolve(ctx, t.DataSrouces)
+		if err != nil {
+			return err
+		}
+		for _, dsn := range rejoin {
+			if err = stopGroupReplication(ctx, dsn); err != nil {
+				return err
+			}
+		}
+
+		if len(masters) < 1 { // no member is running, bootstrap group from the most advanced member
+			if bootMember, err = t.Recovery.ChooseBootstrapMember(ctx, t.DataSrouces); err != nil {
+				return err
+			}
+
+			if err = t.bootCluster(ctx, bootMember); errors.Is(err, types.ErrMysqlMGRIsAlreadyRunning) {
+				t.Recovery.setDecision(nil) // group is not bootstrapped by this call
+			} else if err != nil {
+				return err
+			}
+		}
+
+		for _, dsn := range t.DataSrouces { // ordinary start mysql group replication
+			if (bootMember != nil && dsn.Host == bootMember.Host) || dsnInList(t.Maintenance, dsn) {
+				continue
+			}
+
+			err = t.joinMaster(ctx, dsn)
+			if err != nil && !errors.Is(err, types.ErrMysqlMGRIsAlreadyRunning) {
+				return err
+			}
+		}
+
+		for _, dsn := range t.DataSrouces { // variables changed in spec are applied without restart
+			if dsnInList(t.Maintenance, dsn) {
+				continue
+			}
+
+			if err = applyMGROptions(ctx, dsn, t.Dialect, t.Options); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// bootCluster set mysql instance as cluster bootstrap node
+func (t *MGRMP) bootCluster(ctx context.Context, dsn *DSN) (err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+
+	defer dbConn.Close()
+
+	memberState, err := t.getMemberState(ctx, dbConn)
+	if err != nil {
+		logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql query mgr member state failed")
+	}
+
+	if memberState == "ONLINE" || memberState == "RECOVERING" {
+		return types.ErrMysqlMGRIsAlreadyRunning
+	}
+
+	if err = t.setMultiPrimaryMode(ctx, dbConn, memberState); err != nil {
+		return err
+	}
+
+	_, err = dbConn.ExecContext(ctx, "SET GLOBAL group_replication_bootstrap_group=ON")
+	if err != nil {
+		return fmt.Errorf("%w, err -> %s", types.ErrMysqlStartMGRMPClusterFailed, err.Error())
+	}
+
+	_, err = dbConn.ExecContext(ctx, "START group_replication")
+	if err != nil {
+		return fmt.Errorf("%w, err -> %s", types.ErrMysqlStartMGRMPClusterFailed, err.Error())
+	}
+
+	_, err = dbConn.ExecContext(ctx, "SET GLOBAL group_replication_bootstrap_group=OFF")
+	if err != nil {
+		return fmt.Errorf("%w, err -> %s", types.ErrMysqlStartMGRMPClusterFailed, err.Error())
+	}
+
+	return
+}
+
+// joinMaster make mysql instance join group as a writer member
+func (t *MGRMP) joinMaster(ctx context.Context, dsn *DSN) (err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+
+	defer dbConn.Close()
+
+	memberState, err := t.getMemberState(ctx, dbConn)
+	if err != nil {
+		logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql query mgr member state failed")
+	}
+
+	if memberState == "ONLINE" || memberState == "RECOVERING" {
+		return types.ErrMysqlMGRIsAlreadyRunning
+	}
+
+	if t.Cloner.Enabled() && t.Cloner.SeedMember(ctx, dsn, cloneDonors(ctx, t)) { // join group after clone is done
+		return nil
+	}
+
+	if err = t.setMultiPrimaryMode(ctx, dbConn, memberState); err != nil {
+		return err
+	}
+
+	_, err = dbConn.ExecContext(ctx, t.Dialect.ChangeRecoveryUserSQL(dsn.Username, dsn.Password))
+	if err != nil {
+		return fmt.Errorf("%w, err -> %s", types.ErrMysqlStartMGRMPClusterFailed, err.Error())
+	}
+
+	_, err = dbConn.ExecContext(ctx, "START group_replication")
+	if err != nil {
+		return fmt.Errorf("%w, err -> %s", types.ErrMysqlStartMGRMPClusterFailed, err.Error())
+	}
+
+	return
+}
+
+// setMultiPrimaryMode make sure group replication mode variables are same as group, otherwise member could not join group.
+// these variables can only be changed when group replication is stopped
+func (t *MGRMP) setMultiPrimaryMode(ctx context.Context, dbConn *sql.Conn, memberState string) (err error) {
+	// member left in ERROR state by a failed join must stop group replication before change mode variables
+	if memberState == "ERROR" {
+		if _, err = dbConn.ExecContext(ctx, "STOP group_replication"); err != nil {
+			return fmt.Errorf("%w, stop group replication err -> %s", types.ErrMysqlStartMGRMPClusterFailed, err.Error())
+		}
+	}
+
+	if _, err = dbConn.ExecContext(ctx, "SET GLOBAL group_replication_single_primary_mode=OFF"); err != nil {
+		return fmt.Errorf("%w, disable single primary mode err -> %s", types.ErrMysqlStartMGRMPClusterFailed, err.Error())
+	}
+
+	if _, err = dbConn.ExecContext(ctx, "SET GLOBAL group_replication_enforce_update_everywhere_checks=ON"); err != nil {
+		return fmt.Errorf("%w, enable update everywhere checks err -> %s", types.ErrMysqlStartMGRMPClusterFailed, err.Error())
+	}
+
+	return nil
+}
+
+// FindMaster return all ONLINE members, in multi primary mode every ONLINE member accept writes
+func (t *MGRMP) FindMaster(ctx context.Context) (masters []*DSN, err error) {
+	select {
+	case <-ctx.Done():
+		return nil, types.ErrCtxTimeout
+	default:
+		for _, dsn := range t.DataSrouces {
+			dbConn, err := Connect(ctx, dsn)
+			if err != nil {
+				logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf(types.ErrMyqlConnectFaild.Error())
+				continue
+			}
+
+			if on, err := t.checkMemberOnline(ctx, dbConn); on {
+				masters = append(masters, dsn)
+			} else {
+				logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql check mgr member is online failed")
+			}
+			dbConn.Close()
+		}
+	}
+
+	if len(masters) < 1 {
+		return nil, types.ErrMasterNoutFound
+	}
+	return masters, nil
+}
+
+// getMemberState get member state of this mysql instance in performance_schema.replication_group_members
+// values are [ ONLINE RECOVERING OFFLINE ERROR UNREACHABLE ], OFFLINE when group replication is not started
+func (t *MGRMP) getMemberState(ctx context.Context, dbConn *sql.Conn) (memberState string, err error) {
+	result, err := dbConn.QueryContext(ctx, "SELECT MEMBER_STATE FROM performance_schema.replication_group_members WHERE MEMBER_ID=@@server_uuid")
+	if err != nil {
+		return
+	}
+	defer result.Close()
+
+	if result.Next() {
+		err = result.Scan(&memberState)
+	} else {
+		err = errors.New("mysql query result scan group replication member state failed")
+	}
+
+	return
+}
+
+// checkMemberOnline check member state of this mysql instance is ONLINE
+func (t *MGRMP) checkMemberOnline(ctx context.Context, dbConn *sql.Conn) (on bool, err error) {
+	memberState, err := t.getMemberState(ctx, dbConn)
+	if err != nil {
+		return false, err
+	}
+
+	if memberState != "ONLINE" {
+		return false, fmt.Errorf("mysql group replication member state is %s", memberState)
+	}
+
+	return true, nil
+}
+
+func (t *MGRMP) HealthyMembers(ctx context.Context) (members []*DSN) {
+	select {
+	case <-ctx.Done():
+		return members
+	default:
+		var wg sync.WaitGroup
+		var lock sync.Mutex
+
+		for _, dsn := range t.DataSrouces {
+			wg.Add(1)
+			go func(dsn *DSN) {
+				defer wg.Done()
+				dbConn, err := Connect(ctx, dsn)
+				if err != nil {
+					logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf(types.ErrMyqlConnectFaild.Error())
+					return
+				}
+				defer dbConn.Close()
+
+				if on, err := t.checkMemberOnline(ctx, dbConn); on {
+					lock.Lock()
+					members = append(members, dsn)
+					lock.Unlock()
+				} else {
+					logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql check mgr member is online failed")
+				}
+			}(dsn)
+		}
+
+		wg.Wait()
+	}
+
+	return
+}
@@ -5,7 +5,9 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
 	"sync"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/hakur/rds-operator/pkg/types"
@@ -15,6 +17,18 @@ import (
 type MGRSP struct {
 	// DataSrouces mysql instance data sources
 	DataSrouces []*DSN
+	// Dialect mysql server version specific sql statements
+	Dialect *Dialect
+	// Recovery full outage recovery options, choose bootstrap member when no member is running
+	Recovery *GroupRecovery
+	// Cloner seed member from donor before it joins cluster, nil if clone is disabled
+	Cloner *Cloner
+	// Options group replication variables applied to running members
+	Options MGROptions
+	// Partition loss of quorum handling options, nil if partition is not checked
+	Partition *GroupPartition
+	// Maintenance members under maintenance by DBA, group replication of them is not started or changed
+	Maintenance []*DSN
 }
 
 func (t *MGRSP) StartCluster(ctx context.Context) (err error) {
@@ -23,19 +37,52 @@ func (t *MGRSP) StartCluster(ctx context.Context) (err error) {
 		return types.ErrCtxTimeout
 	default:
 		var masters []*DSN
+		var bootMember *DSN
 		masters, _ = t.FindMaster(ctx)
 
-		for k, dsn := range t.DataSrouces { // ordinary start mysql group replication
-			if k == 0 && len(masters) < 1 {
-				err = t.bootCluster(ctx, dsn)
-			} else {
-				err = t.joinMaster(ctx, dsn)
+		// members left in old partition rejoin group after surviving partition is unblocked
+		rejoin, err := t.Partition.Resolve(ctx, t.DataSrouces)
+		if err != nil {
+			return err
+		}
+		for _, dsn := range rejoin {
+			if err = stopGroupReplication(ctx, dsn); err != nil {
+				return err
+			}
+		}
+
+		if len(masters) < 1 { // no member is running, bootstrap group from the most advanced member
+			if bootMember, err = t.Recovery.ChooseBootstrapMember(ctx, t.DataSrouces); err != nil {
+				return err
+			}
+
+			if err = t.bootCluster(ctx, bootMember); errors.Is(err, types.ErrMysqlMGRIsAlreadyRunning) {
+				t.Recovery.setDecision(nil) // group is not bootstrapped by this call
+			} else if err != nil {
+				return err
+			}
+		}
+
+		for _, dsn := range t.DataSrouces { // ordinary start mysql group replication
+			if (bootMember != nil && dsn.Host == bootMember.Host) || dsnInList(t.Maintenance, dsn) {
+				continue
 			}
 
+			err = t.joinMaster(ctx, dsn)
 			if err != nil && !errors.Is(err, types.ErrMysqlMGRIsAlreadyRunning) {
 				return err
 			}
 		}
+
+		for _, dsn := range t.DataSrouces { // variables changed in spec are applied without restart
+			if dsnInList(t.Maintenance, dsn) {
+				continue
+			}
+
+			if err = applyMGROptions(ctx, dsn, t.Dialect, t.Options); err != nil {
+				return err
+			}
+		}
 	}
 
 	return nil
@@ -43,12 +90,7 @@ func (t *MGRSP) StartCluster(ctx context.Context) (err error) {
 
 // bootCluster set mysql instance as cluster bootstrap node
 func (t *MGRSP) bootCluster(ctx context.Context, dsn *DSN) (err error) {
-	dbConn, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/mysql",
-		dsn.Username,
-		dsn.Password,
-		dsn.Host,
-		dsn.Port,
-	))
+	dbConn, err := Connect(ctx, dsn)
 	if err != nil {
 		return types.ErrMyqlConnectFaild
 	}
@@ -81,12 +123,7 @@ func (t *MGRSP) bootCluster(ctx context.Context, dsn *DSN) (err error) {
 
 // joinMaster make mysql instance join master node as slave
 func (t *MGRSP) joinMaster(ctx context.Context, dsn *DSN) (err error) {
-	dbConn, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/mysql",
-		dsn.Username,
-		dsn.Password,
-		dsn.Host,
-		dsn.Port,
-	))
+	dbConn, err := Connect(ctx, dsn)
 	if err != nil {
 		return types.ErrMyqlConnectFaild
 	}
@@ -99,7 +136,11 @@ func (t *MGRSP) joinMaster(ctx context.Context, dsn *DSN) (err error) {
 		logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql check mgr is running failed")
 	}
 
-	_, err = dbConn.ExecContext(ctx, "CHANGE MASTER TO MASTER_USER='"+dsn.Username+"' ,MASTER_PASSWORD='"+dsn.Password+"'  FOR CHANNEL 'group_replication_recovery'")
+	if t.Cloner.Enabled() && t.Cloner.SeedMember(ctx, dsn, cloneDonors(ctx, t)) { // join group after clone is done
+		return nil
+	}
+
+	_, err = dbConn.ExecContext(ctx, t.Dialect.ChangeRecoveryUserSQL(dsn.Username, dsn.Password))
 	if err != nil {
 		return fmt.Errorf("%w, err -> %s", types.ErrMysqlStartMGRSPClusterFailed, err.Error())
 	}
@@ -118,48 +159,18 @@ func (t *MGRSP) FindMaster(ctx context.Context) (masters []*DSN, err error) {
 		return nil, types.ErrCtxTimeout
 	default:
 		for _, dsn := range t.DataSrouces {
-			dbConn, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/mysql",
-				dsn.Username,
-				dsn.Password,
-				dsn.Host,
-				dsn.Port,
-			))
+			dbConn, err := Connect(ctx, dsn)
 			if err != nil {
 				logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf(types.ErrMyqlConnectFaild.Error())
 				continue
 			}
-			defer dbConn.Close()
-
-			row, err := dbConn.QueryContext(ctx, "SHOW VARIABLES LIKE 'server_uuid'")
-			if err != nil {
-				logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql query local server uuid failed")
-				continue
-			}
 
-			var myServerUUID string
-			if row.Next() { // only one row data, for loop is not required
-				row.Scan(&myServerUUID, &myServerUUID) // two colume, need twice scan, second scan is our target data value
-			}
-
-			if myServerUUID == "" {
-				logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql result scan server uuid value failed")
-				continue
-			}
-
-			row, err = dbConn.QueryContext(ctx, "SELECT VARIABLE_VALUE FROM performance_schema.global_status WHERE VARIABLE_NAME= 'group_replication_primary_member' AND VARIABLE_VALUE=?", myServerUUID)
-			if err != nil {
-				logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql query master server uuid failed")
-				continue
-			}
-
-			var masterServerUUID string
-			if row.Next() { // only one row data, for loop is not required
-				row.Scan(&masterServerUUID)
-			}
-
-			if masterServerUUID != "" && masterServerUUID == myServerUUID {
+			if isPrimary, err := t.checkPrimary(ctx, dbConn); isPrimary {
 				masters = append(masters, dsn)
+			} else if err != nil {
+				logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql query master server uuid failed")
 			}
+			dbConn.Close()
 		}
 
 	}
@@ -170,17 +181,28 @@ func (t *MGRSP) FindMaster(ctx context.Context) (masters []*DSN, err error) {
 	return masters, nil
 }
 
-func (t *MGRSP) checkMGRIsRunning(ctx context.Context, dbConn *sql.DB) (on bool, err error) {
-	result, err := dbConn.QueryContext(ctx, "select service_state from performance_schema.replication_applier_status where channel_name='group_replication_applier'")
-	if err != nil {
-		return
+// checkPrimary check server uuid of this mysql instance is group replication primary member
+func (t *MGRSP) checkPrimary(ctx context.Context, dbConn *sql.Conn) (isPrimary bool, err error) {
+	var myServerUUID, primaryUUID string
+	if err = dbConn.QueryRowContext(ctx, "SELECT @@server_uuid").Scan(&myServerUUID); err != nil {
+		return false, err
+	}
+
+	err = dbConn.QueryRowContext(ctx, "SELECT VARIABLE_VALUE FROM performance_schema.global_status WHERE VARIABLE_NAME='group_replication_primary_member'").Scan(&primaryUUID)
+	if err == sql.ErrNoRows {
+		return false, nil
 	}
 
+	return err == nil && primaryUUID == myServerUUID, err
+}
+
+func (t *MGRSP) checkMGRIsRunning(ctx context.Context, dbConn *sql.Conn) (on bool, err error) {
 	var mgrOn string
-	if result.Next() {
-		result.Scan(&mgrOn)
-	} else {
+	err = dbConn.QueryRowContext(ctx, "select service_state from performance_schema.replication_applier_status where channel_name='group_replication_applier'").Scan(&mgrOn)
+	if err == sql.ErrNoRows {
 		err = errors.New("mysql query result scan group_replication_applier service_state failed")
+	} else if err != nil {
+		return
 	}
 
 	if mgrOn == "ON" {
@@ -198,17 +220,13 @@ func (t *MGRSP) HealthyMembers(ctx context.Context) (members []*DSN) {
 		return members
 	default:
 		var wg sync.WaitGroup
+		var lock sync.Mutex
 
 		for _, dsn := range t.DataSrouces {
 			wg.Add(1)
 			go func(dsn *DSN) {
 				defer wg.Done()
-				dbConn, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/mysql",
-					dsn.Username,
-					dsn.Password,
-					dsn.Host,
-					dsn.Port,
-				))
+				dbConn, err := Connect(ctx, dsn)
 				if err != nil {
 					logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf(types.ErrMyqlConnectFaild.Error())
 					return
@@ -216,7 +234,9 @@ func (t *MGRSP) HealthyMembers(ctx context.Context) (members []*DSN) {
 				defer dbConn.Close()
 
 				if on, err := t.checkMGRIsRunning(ctx, dbConn); on {
+					lock.Lock()
 					members = append(members, dsn)
+					lock.Unlock()
 				} else {
 					logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql check mgr is running failed")
 				}
@@ -228,3 +248,129 @@ func (t *MGRSP) HealthyMembers(ctx context.Context) (members []*DSN) {
 
 	return
 }
+
+// SwitchPrimary make target member as primary.
+// mysql 8.0.13+ use group_replication_set_as_primary(), mysql 5.7 raise member weight of target and restart group replication of old primary
+func (t *MGRSP) SwitchPrimary(ctx context.Context, target *DSN) (err error) {
+	masters, err := t.FindMaster(ctx)
+	if err != nil {
+		return fmt.Errorf("%w, err -> %s", types.ErrMysqlSwitchoverFailed, err.Error())
+	}
+
+	oldPrimary := masters[0]
+	if oldPrimary.Host == target.Host {
+		return nil
+	}
+
+	targetUUID, err := getServerUUID(ctx, target)
+	if err != nil {
+		return fmt.Errorf("%w, query [host=%s] server uuid err -> %s", types.ErrMysqlSwitchoverFailed, target.Host, err.Error())
+	}
+
+	if t.Dialect.SupportSetAsPrimary() {
+		dbConn, err := Connect(ctx, oldPrimary)
+		if err != nil {
+			return types.ErrMyqlConnectFaild
+		}
+		defer dbConn.Close()
+
+		if _, err = dbConn.ExecContext(ctx, "SELECT group_replication_set_as_primary(?)", targetUUID); err != nil {
+			return fmt.Errorf("%w, set [host=%s] as primary err -> %s", types.ErrMysqlSwitchoverFailed, target.Host, err.Error())
+		}
+	} else {
+		return t.switchPrimaryByWeight(ctx, oldPrimary, target, targetUUID)
+	}
+
+	return t.waitPrimary(ctx, targetUUID)
+}
+
+// switchPrimaryByWeight mysql 5.7 elect member with highest group_replication_member_weight as primary when primary leave group.
+// member weights of spec are restored after new primary is elected or switchover failed, so they do not decide next failover
+func (t *MGRSP) switchPrimaryByWeight(ctx context.Context, oldPrimary, target *DSN, targetUUID string) (err error) {
+	defer t.setMemberWeights(nil)
+
+	t.setMemberWeights(target)
+
+	dbConn, err := Connect(ctx, oldPrimary)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	if _, err = dbConn.ExecContext(ctx, "STOP group_replication"); err != nil {
+		return fmt.Errorf("%w, stop [host=%s] group replication err -> %s", types.ErrMysqlSwitchoverFailed, oldPrimary.Host, err.Error())
+	}
+
+	// old primary rejoin group as secondary member
+	if err = t.joinMaster(ctx, oldPrimary); err != nil {
+		return fmt.Errorf("%w, old primary [host=%s] rejoin group err -> %s", types.ErrMysqlSwitchoverFailed, oldPrimary.Host, err.Error())
+	}
+
+	return t.waitPrimary(ctx, targetUUID)
+}
+
+// setMemberWeights set group_replication_member_weight of members, target is 100 and others are 50.
+// weight of spec.mgr.memberWeight or mysql default 50 is set to all members when target is nil. unreachable members are skipped
+func (t *MGRSP) setMemberWeights(target *DSN) {
+	// switchover context may be expired when weights are restored
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	for _, dsn := range t.DataSrouces {
+		weight := 50
+		if target == nil {
+			if value, err := strconv.Atoi(t.Options["group_replication_member_weight"]); err == nil {
+				weight = value
+			}
+		} else if dsn.Host == target.Host {
+			weight = 100
+		}
+
+		dbConn, err := Connect(ctx, dsn)
+		if err != nil {
+			continue
+		}
+
+		if _, err = dbConn.ExecContext(ctx, "SET GLOBAL group_replication_member_weight=?", weight); err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql set group replication member weight failed")
+		}
+		dbConn.Close()
+	}
+}
+
+// waitPrimary wait until member of server uuid become primary
+func (t *MGRSP) waitPrimary(ctx context.Context, serverUUID string) (err error) {
+	for {
+		for _, dsn := range t.DataSrouces {
+			dbConn, err := Connect(ctx, dsn)
+			if err != nil {
+				continue
+			}
+
+			var primaryUUID string
+			err = dbConn.QueryRowContext(ctx, "SELECT VARIABLE_VALUE FROM performance_schema.global_status WHERE VARIABLE_NAME='group_replication_primary_member'").Scan(&primaryUUID)
+			dbConn.Close()
+			if err == nil && primaryUUID == serverUUID {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w, wait new primary timeout", types.ErrMysqlSwitchoverFailed)
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// getServerUUID query server_uuid of mysql instance
+func getServerUUID(ctx context.Context, dsn *DSN) (serverUUID string, err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return "", types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	err = dbConn.QueryRowContext(ctx, "SELECT @@server_uuid").Scan(&serverUUID)
+	return serverUUID, err
+}
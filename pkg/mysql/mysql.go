@@ -3,7 +3,7 @@ package mysql
 import (
 	"context"
 	"database/sql"
-	"fmt"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
@@ -14,21 +14,37 @@ type ClusterManager interface {
 	HealthyMembers(ctx context.Context) (members []*DSN)
 }
 
+// PrimarySwitcher cluster manager which support planned primary switchover
+type PrimarySwitcher interface {
+	// SwitchPrimary make target member as primary, old primary become a secondary member
+	SwitchPrimary(ctx context.Context, target *DSN) (err error)
+}
+
+// MemberRemover cluster manager which support removing member before it is deleted
+type MemberRemover interface {
+	// RemoveMember stop replication of member, member is not in DataSrouces of cluster manager
+	RemoveMember(ctx context.Context, member *DSN) (err error)
+}
+
 type DSN struct {
 	Host     string
 	Port     int
 	Username string
 	Password string
 	DBName   string
+	// Socket unix socket path, Host and Port are used for tcp connection if it is empty
+	Socket string
+	// TLS tls config name of go-sql-driver, values are [ true false skip-verify preferred ] or name registered by RegisterTLSConfig
+	TLS string
+	// Timeout dial timeout, default is 5 seconds
+	Timeout time.Duration
+	// ReadTimeout I/O read timeout of each call, default is no timeout because CLONE INSTANCE may wait hours
+	ReadTimeout time.Duration
+	// WriteTimeout I/O write timeout of each call, default is no timeout
+	WriteTimeout time.Duration
 }
 
+// NewDBFromDSN open a new connection pool which is not shared, caller must close it. use Connect for shared pool
 func NewDBFromDSN(opts *DSN) (db *sql.DB, err error) {
-	db, err = sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=utf8",
-		opts.Username,
-		opts.Password,
-		opts.Host,
-		opts.Port,
-		opts.DBName,
-	))
-	return
+	return sql.Open("mysql", opts.FormatDSN())
 }
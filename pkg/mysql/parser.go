@@ -45,7 +45,7 @@ func (t *ConfigParser) Parse(r io.Reader) (err error) {
 			}
 		} else if commentReg.Match(line) {
 
-		} else {
+		} else if currentSection != nil { // options before any section are ignored
 			arr := strings.Split(string(line), "=")
 			currentSection.Set(arr[0], strings.Join(arr[1:], "="))
 		}
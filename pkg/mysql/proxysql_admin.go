@@ -11,20 +11,17 @@ import (
 	hutil "github.com/hakur/util"
 )
 
+// NewProxySQLAdmin connect proxysql admin interface, statement arguments are interpolated by driver because proxysql admin does not support prepared statement
 func NewProxySQLAdmin(dsn DSN) (t *ProxySQLAdmin, err error) {
-	t = new(ProxySQLAdmin)
-	if db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/main",
-		dsn.Username,
-		dsn.Password,
-		dsn.Host,
-		dsn.Port,
-	)); err != nil {
-		t = nil
-	} else {
-		t.Conn = db
+	dsn.DBName = "main"
+	db, err := sql.Open("mysql", dsn.FormatDSN())
+	if err != nil {
+		return nil, err
 	}
+	// BEGIN and COMMIT must be executed in same session
+	db.SetMaxOpenConns(1)
 
-	return
+	return &ProxySQLAdmin{Conn: db}, nil
 }
 
 type ProxySQLAdmin struct {
@@ -40,6 +37,8 @@ func (t *ProxySQLAdmin) GetProxySQLServers(ctx context.Context) (data []*TablePr
 	if err != nil {
 		return data, err
 	}
+	defer result.Close()
+
 	for result.Next() {
 		ps := new(TableProxySQLServers)
 		err = result.Scan(&ps.Hostname, &ps.Port, &ps.Weight, &ps.Comment)
@@ -103,33 +102,28 @@ func (t *ProxySQLAdmin) AddProxySQLServers(ctx context.Context, servers []*Table
 			// check hostname exists
 			// INSERT INTO proxysql_servers(hostname,port,weight,comment) values ('yuxing-proxysql-0','6032','1','') ON DUPLICATE KEY UPDATE is not support with proxysql 2.3.2
 			hostnameCount := 0
-			countResukt, err := t.Conn.QueryContext(ctx, "SELECT COUNT(hostname) FROM proxysql_servers WHERE hostname='"+server.Hostname+"'")
-			if err != nil {
+			if err := t.Conn.QueryRowContext(ctx, "SELECT COUNT(hostname) FROM proxysql_servers WHERE hostname=?", server.Hostname).Scan(&hostnameCount); err != nil {
 				return fmt.Errorf("count hostname=%s from proxysql db error -> %s", server.Hostname, err.Error())
-			} else {
-				if countResukt.Next() {
-					countResukt.Scan(&hostnameCount)
-				}
 			}
 
 			if hostnameCount > 0 {
-				_, err = t.Conn.ExecContext(ctx, fmt.Sprintf("UPDATE proxysql_servers set port=%d,weight='%d',comment='%s' WHERE hostname='%s'",
+				_, err = t.Conn.ExecContext(ctx, "UPDATE proxysql_servers set port=?,weight=?,comment=? WHERE hostname=?",
 					server.Port,
 					server.Weight,
 					server.Comment,
 					server.Hostname,
-				))
+				)
 
 				if err != nil {
 					return fmt.Errorf("update hostname=%s to proxysql db error -> %s", server.Hostname, err.Error())
 				}
 			} else {
-				_, err = t.Conn.ExecContext(ctx, fmt.Sprintf("INSERT INTO proxysql_servers(hostname,port,weight,comment) values ('%s','%d','%d','%s')",
+				_, err = t.Conn.ExecContext(ctx, "INSERT INTO proxysql_servers(hostname,port,weight,comment) values (?,?,?,?)",
 					server.Hostname,
 					server.Port,
 					server.Weight,
 					server.Comment,
-				))
+				)
 
 				if err != nil {
 					return fmt.Errorf("insert hostname=%s to proxysql db error -> %s", server.Hostname, err.Error())
@@ -141,7 +135,13 @@ func (t *ProxySQLAdmin) AddProxySQLServers(ctx context.Context, servers []*Table
 }
 
 func (t *ProxySQLAdmin) RemoveProxySQLServer(ctx context.Context, hostname string) (err error) {
-	_, err = t.Conn.ExecContext(ctx, "DELETE FROM proxysql_servers where hostname='"+hostname+"'")
+	_, err = t.Conn.ExecContext(ctx, "DELETE FROM proxysql_servers where hostname=?", hostname)
+	return err
+}
+
+// SetGroupReplicationMaxWriters update max_writers of mysql_group_replication_hostgroups row of writer hostgroup
+func (t *ProxySQLAdmin) SetGroupReplicationMaxWriters(ctx context.Context, writerHostgroup, maxWriters int) (err error) {
+	_, err = t.Conn.ExecContext(ctx, "UPDATE mysql_group_replication_hostgroups SET max_writers=? WHERE writer_hostgroup=?", maxWriters, writerHostgroup)
 	return err
 }
 
@@ -153,6 +153,7 @@ func (t *ProxySQLAdmin) GetMysqlServers(ctx context.Context) (data []*TableMysql
 	if err != nil {
 		return data, err
 	}
+	defer result.Close()
 
 	for result.Next() {
 		ms := new(TableMysqlServers)
@@ -187,17 +188,12 @@ func (t *ProxySQLAdmin) AddMysqlServers(ctx context.Context, servers []*TableMys
 			hutil.DefaultValue(server)
 			// check hostname exists
 			hostnameCount := 0
-			countResukt, err := t.Conn.QueryContext(ctx, "SELECT COUNT(hostname) FROM mysql_servers WHERE hostname='"+server.Hostname+"'")
-			if err != nil {
+			if err := t.Conn.QueryRowContext(ctx, "SELECT COUNT(hostname) FROM mysql_servers WHERE hostname=?", server.Hostname).Scan(&hostnameCount); err != nil {
 				return fmt.Errorf("count hostname=%s from proxysql db error -> %s", server.Hostname, err.Error())
-			} else {
-				if countResukt.Next() {
-					countResukt.Scan(&hostnameCount)
-				}
 			}
 
 			if hostnameCount > 0 {
-				_, err = t.Conn.ExecContext(ctx, fmt.Sprintf("UPDATE mysql_servers set hostgroup_id=%d,hostname='%s',port=%d,gtid_port=%d,status='%s',weight=%d,compression=%d,max_connections=%d,max_replication_lag=%d,use_ssl=%d,max_latency_ms=%d,comment='%s' WHERE hostname='%s'",
+				_, err = t.Conn.ExecContext(ctx, "UPDATE mysql_servers set hostgroup_id=?,hostname=?,port=?,gtid_port=?,status=?,weight=?,compression=?,max_connections=?,max_replication_lag=?,use_ssl=?,max_latency_ms=?,comment=? WHERE hostname=?",
 					server.HostGroupID,
 					server.Hostname,
 					server.Port,
@@ -211,13 +207,13 @@ func (t *ProxySQLAdmin) AddMysqlServers(ctx context.Context, servers []*TableMys
 					server.MaxLatencyMS,
 					server.Comment,
 					server.Hostname,
-				))
+				)
 
 				if err != nil {
 					return fmt.Errorf("update hostname=%s to proxysql db error -> %s", server.Hostname, err.Error())
 				}
 			} else {
-				_, err = t.Conn.ExecContext(ctx, fmt.Sprintf("INSERT INTO mysql_servers(hostgroup_id,hostname,port,gtid_port,status,weight,compression,max_connections,max_replication_lag,use_ssl,max_latency_ms,comment) values (%d,'%s',%d,%d,'%s',%d,%d,%d,%d,%d,%d,'%s')",
+				_, err = t.Conn.ExecContext(ctx, "INSERT INTO mysql_servers(hostgroup_id,hostname,port,gtid_port,status,weight,compression,max_connections,max_replication_lag,use_ssl,max_latency_ms,comment) values (?,?,?,?,?,?,?,?,?,?,?,?)",
 					server.HostGroupID,
 					server.Hostname,
 					server.Port,
@@ -230,7 +226,7 @@ func (t *ProxySQLAdmin) AddMysqlServers(ctx context.Context, servers []*TableMys
 					hutil.BoolToInt(server.UseSSL),
 					server.MaxLatencyMS,
 					server.Comment,
-				))
+				)
 
 				if err != nil {
 					return fmt.Errorf("insert hostname=%s to proxysql db error -> %s", server.Hostname, err.Error())
@@ -242,7 +238,7 @@ func (t *ProxySQLAdmin) AddMysqlServers(ctx context.Context, servers []*TableMys
 }
 
 func (t *ProxySQLAdmin) RemoveMysqlServer(ctx context.Context, hostname string) (err error) {
-	_, err = t.Conn.ExecContext(ctx, "DELETE FROM mysql_servers where hostname='"+hostname+"'")
+	_, err = t.Conn.ExecContext(ctx, "DELETE FROM mysql_servers where hostname=?", hostname)
 	return err
 }
 
@@ -254,6 +250,7 @@ func (t *ProxySQLAdmin) GetMysqlUsers(ctx context.Context) (data []*TableMysqlUs
 	if err != nil {
 		return data, err
 	}
+	defer result.Close()
 
 	for result.Next() {
 		user := new(TableMysqlUsers)
@@ -290,13 +287,8 @@ func (t *ProxySQLAdmin) AddMysqlUsers(ctx context.Context, users []*TableMysqlUs
 			hutil.DefaultValue(user)
 			// check user exists
 			usernameCount := 0
-			countResukt, err := t.Conn.QueryContext(ctx, "SELECT COUNT(username) FROM mysql_users WHERE username='"+user.Username+"' AND frontend="+hutil.BoolToStrNumber(user.Frontend))
-			if err != nil {
+			if err := t.Conn.QueryRowContext(ctx, "SELECT COUNT(username) FROM mysql_users WHERE username=? AND frontend=?", user.Username, hutil.BoolToInt(user.Frontend)).Scan(&usernameCount); err != nil {
 				return fmt.Errorf("count username=%s,frontend=%s from proxysql db error -> %s", user.Username, hutil.BoolToStrNumber(user.Frontend), err.Error())
-			} else {
-				if countResukt.Next() {
-					countResukt.Scan(&usernameCount)
-				}
 			}
 
 			// Username              string         //username
@@ -314,7 +306,7 @@ func (t *ProxySQLAdmin) AddMysqlUsers(ctx context.Context, users []*TableMysqlUs
 			// Attributes            string         //attributes
 			// Comment               string         //comment
 			if usernameCount > 0 {
-				_, err = t.Conn.ExecContext(ctx, fmt.Sprintf("UPDATE mysql_users set username='%s',password='%s',active=%d,use_ssl=%d,default_hostgroup=%d,default_schema='%s',schema_locked=%d,transaction_persistent=%d,fast_forward=%d,backend=%d,frontend=%d,max_connections=%d,attributes='%s',comment='%s' WHERE username='%s' AND frontend=%d",
+				_, err = t.Conn.ExecContext(ctx, "UPDATE mysql_users set username=?,password=?,active=?,use_ssl=?,default_hostgroup=?,default_schema=?,schema_locked=?,transaction_persistent=?,fast_forward=?,backend=?,frontend=?,max_connections=?,attributes=?,comment=? WHERE username=? AND frontend=?",
 					user.Username,
 					user.Password,
 					hutil.BoolToInt(user.Active),
@@ -331,13 +323,13 @@ func (t *ProxySQLAdmin) AddMysqlUsers(ctx context.Context, users []*TableMysqlUs
 					user.Comment,
 					user.Username,
 					hutil.BoolToInt(user.Frontend),
-				))
+				)
 
 				if err != nil {
 					return fmt.Errorf("update username=%s,frontend=%s to proxysql db error -> %s", user.Username, hutil.BoolToStrNumber(user.Frontend), err.Error())
 				}
 			} else {
-				_, err = t.Conn.ExecContext(ctx, fmt.Sprintf("INSERT INTO mysql_users(username,password,active,use_ssl,default_hostgroup,default_schema,schema_locked,transaction_persistent,fast_forward,backend,frontend,max_connections,attributes,comment) values ('%s','%s',%d,%d,%d,'%s',%d,%d,%d,%d,%d,%d,'%s','%s')",
+				_, err = t.Conn.ExecContext(ctx, "INSERT INTO mysql_users(username,password,active,use_ssl,default_hostgroup,default_schema,schema_locked,transaction_persistent,fast_forward,backend,frontend,max_connections,attributes,comment) values (?,?,?,?,?,?,?,?,?,?,?,?,?,?)",
 					user.Username,
 					user.Password,
 					hutil.BoolToInt(user.Active),
@@ -352,7 +344,7 @@ func (t *ProxySQLAdmin) AddMysqlUsers(ctx context.Context, users []*TableMysqlUs
 					user.MaxConnections,
 					user.Attributes,
 					user.Comment,
-				))
+				)
 
 				if err != nil {
 					return fmt.Errorf("insert username=%s,frontend=%s to proxysql db error -> %s", user.Username, hutil.BoolToStrNumber(user.Frontend), err.Error())
@@ -364,6 +356,6 @@ func (t *ProxySQLAdmin) AddMysqlUsers(ctx context.Context, users []*TableMysqlUs
 }
 
 func (t *ProxySQLAdmin) RemoveMysqlUser(ctx context.Context, username string, frontend bool) (err error) {
-	_, err = t.Conn.ExecContext(ctx, "DELETE FROM mysql_users where username='"+username+"' and frontend="+hutil.BoolToStrNumber(frontend))
+	_, err = t.Conn.ExecContext(ctx, "DELETE FROM mysql_users where username=? and frontend=?", username, hutil.BoolToInt(frontend))
 	return err
 }
@@ -0,0 +1,77 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// RemoveMember stop group replication of member, then remove member from group_replication_group_seeds of other members
+func (t *MGRSP) RemoveMember(ctx context.Context, member *DSN) (err error) {
+	return removeGroupMember(ctx, member, t.DataSrouces)
+}
+
+// RemoveMember stop group replication of member, then remove member from group_replication_group_seeds of other members
+func (t *MGRMP) RemoveMember(ctx context.Context, member *DSN) (err error) {
+	return removeGroupMember(ctx, member, t.DataSrouces)
+}
+
+// RemoveMember stop replication of member and turn off its semi sync slave module, a master can not be removed. member which is not reachable is already removed
+func (t *Replication) RemoveMember(ctx context.Context, member *DSN) (err error) {
+	dbConn, err := Connect(ctx, member)
+	if err != nil { // member is already down, nothing to stop
+		logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": member.Host}).Debugf("mysql removed member is not reachable")
+		return nil
+	}
+	defer dbConn.Close()
+
+	if _, err = dbConn.ExecContext(ctx, t.Dialect.StopReplicaSQL()); err != nil {
+		return fmt.Errorf("%w, stop [host=%s] replica err -> %s", types.ErrMysqlRemoveMemberFailed, member.Host, err.Error())
+	}
+
+	if err = t.disableReplicaAck(ctx, dbConn); err != nil {
+		return fmt.Errorf("%w, turn off [host=%s] semi sync replica err -> %s", types.ErrMysqlRemoveMemberFailed, member.Host, err.Error())
+	}
+
+	return nil
+}
+
+// removeGroupMember member leave group with STOP GROUP_REPLICATION, so group view changes immediately instead of waiting member is expelled
+func removeGroupMember(ctx context.Context, member *DSN, remains []*DSN) (err error) {
+	if dbConn, err := Connect(ctx, member); err != nil { // member is already down, it will be expelled by group, seeds of other members are still updated
+		logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": member.Host}).Debugf("mysql removed member is not reachable")
+	} else {
+		_, err = dbConn.ExecContext(ctx, "STOP GROUP_REPLICATION")
+		dbConn.Close()
+		if err != nil {
+			return fmt.Errorf("%w, stop [host=%s] group replication err -> %s", types.ErrMysqlRemoveMemberFailed, member.Host, err.Error())
+		}
+	}
+
+	var seeds []string
+	for _, dsn := range remains {
+		seeds = append(seeds, dsn.Host+":33061")
+	}
+
+	for _, dsn := range remains {
+		if err = setGroupSeeds(ctx, dsn, strings.Join(seeds, ",")); err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Warn("mysql update group_replication_group_seeds failed")
+		}
+	}
+
+	return nil
+}
+
+func setGroupSeeds(ctx context.Context, dsn *DSN, seeds string) (err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	_, err = dbConn.ExecContext(ctx, "SET GLOBAL group_replication_group_seeds=?", seeds)
+	return err
+}
@@ -0,0 +1,132 @@
+package mysql
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// ReplicaOf disaster recovery standby of source cluster, only for SemiSync and Async cluster mode.
+// master of standby cluster replicates asynchronously from source master and is kept super read only, other members replicate from standby master as usual
+type ReplicaOf struct {
+	// Source current master of source cluster, nil if it is not found, then standby master keeps replicating from last source
+	Source *DSN
+	// Dialect mysql server version specific sql statements of standby cluster
+	Dialect *Dialect
+}
+
+// Enabled cluster is a disaster recovery standby
+func (t *ReplicaOf) Enabled() bool {
+	return t != nil
+}
+
+// Follow make standby master replicate from source master with gtid auto position, replication is repointed when source cluster master changed
+func (t *ReplicaOf) Follow(ctx context.Context, master *DSN) (err error) {
+	dbConn, err := Connect(ctx, master)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	// only replication threads can write standby master
+	if _, err = dbConn.ExecContext(ctx, "SET GLOBAL super_read_only=1"); err != nil {
+		return fmt.Errorf("%w, set [host=%s] super read only err -> %s", types.ErrMysqlReplicaOfFailed, master.Host, err.Error())
+	}
+
+	if t.Source == nil {
+		logrus.WithField("host", master.Host).Debug("mysql replica of source master is not found, keep last source")
+		return nil
+	}
+
+	replicaStatus, err := queryReplicaStatus(ctx, dbConn, t.Dialect)
+	if err != nil {
+		return fmt.Errorf("%w, query [host=%s] replica status err -> %s", types.ErrMysqlReplicaOfFailed, master.Host, err.Error())
+	}
+
+	if replicaStatus[t.Dialect.SourceHostColumn()] != t.Source.Host || replicaStatus[t.Dialect.SourcePortColumn()] != strconv.Itoa(t.Source.Port) {
+		if _, err = dbConn.ExecContext(ctx, t.Dialect.StopReplicaSQL()); err != nil {
+			return fmt.Errorf("%w, stop slave [host=%s] err -> %s", types.ErrMysqlReplicaOfFailed, master.Host, err.Error())
+		}
+
+		if _, err = dbConn.ExecContext(ctx, t.Dialect.ChangeSourcePortSQL(t.Source.Host, t.Source.Port, t.Source.Username, t.Source.Password)); err != nil {
+			return fmt.Errorf("%w, change master [host=%s] err -> %s", types.ErrMysqlReplicaOfFailed, master.Host, err.Error())
+		}
+		logrus.WithFields(map[string]interface{}{"host": master.Host, "source": t.Source.Host}).Info("mysql standby master follow source master")
+	} else if replicaStatus[t.Dialect.ReplicaIORunningColumn()] == "Yes" && replicaStatus[t.Dialect.ReplicaSQLRunningColumn()] == "Yes" {
+		return nil
+	}
+
+	// START SLAVE only starts threads which are not running
+	if _, err = dbConn.ExecContext(ctx, t.Dialect.StartReplicaSQL()); err != nil {
+		return fmt.Errorf("%w, start slave [host=%s] err -> %s", types.ErrMysqlReplicaOfFailed, master.Host, err.Error())
+	}
+
+	return nil
+}
+
+// FollowsSource check member replicates from a host which is not member of cluster, that means it is standby master
+func (t *ReplicaOf) FollowsSource(ctx context.Context, dsn *DSN, members []*DSN) (follows bool, err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return false, types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	replicaStatus, err := queryReplicaStatus(ctx, dbConn, t.Dialect)
+	if err != nil {
+		return false, err
+	}
+
+	source := replicaStatus[t.Dialect.SourceHostColumn()]
+	return source != "" && !dsnInList(members, &DSN{Host: source}), nil
+}
+
+// PromoteReplicaOf stop replication from source cluster and turn off read only of standby master, standby cluster become an independent cluster
+func PromoteReplicaOf(ctx context.Context, master *DSN, dialect *Dialect) (err error) {
+	dbConn, err := Connect(ctx, master)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	if _, err = dbConn.ExecContext(ctx, dialect.StopReplicaSQL()); err != nil {
+		return fmt.Errorf("%w, stop slave [host=%s] err -> %s", types.ErrMysqlReplicaOfPromoteFailed, master.Host, err.Error())
+	}
+
+	if _, err = dbConn.ExecContext(ctx, dialect.ResetReplicaAllSQL()); err != nil {
+		return fmt.Errorf("%w, reset slave [host=%s] err -> %s", types.ErrMysqlReplicaOfPromoteFailed, master.Host, err.Error())
+	}
+
+	// read_only=OFF also turns off super_read_only
+	if _, err = dbConn.ExecContext(ctx, "SET GLOBAL read_only=0"); err != nil {
+		return fmt.Errorf("%w, turn off [host=%s] read only err -> %s", types.ErrMysqlReplicaOfPromoteFailed, master.Host, err.Error())
+	}
+
+	return nil
+}
+
+// FindWritableSource return the first source which read only is turned off, it is master of source cluster
+func FindWritableSource(ctx context.Context, sources []*DSN) (source *DSN, err error) {
+	for _, dsn := range sources {
+		dbConn, err := Connect(ctx, dsn)
+		if err != nil {
+			continue
+		}
+
+		readOnly, err := getReadOnly(ctx, dbConn)
+		dbConn.Close()
+		if err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql query source read only failed")
+			continue
+		}
+
+		if !readOnly {
+			return dsn, nil
+		}
+	}
+
+	return nil, types.ErrMasterNoutFound
+}
@@ -0,0 +1,597 @@
+package mysql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+// Replication mysql primary/replica cluster of SemiSync and Async cluster mode, one master (two masters with semi sync DoubleMasterHA) and many replicas.
+// every member is started with read_only=ON, master is the only member which read_only is turned off by operator,
+// so proxysql mysql_replication_hostgroups with check_type read_only can route writes to master.
+// master waits ack of replicas when SemiSync is true, semi sync plugin variables are only changed in semi_sync.go
+type Replication struct {
+	// DataSrouces mysql instance data sources
+	DataSrouces []*DSN
+	// SemiSync master waits ack of semi sync replicas, otherwise replication is asynchronous
+	SemiSync bool
+	// DoubleMasterHA two masters replicate from each other, only for SemiSync
+	DoubleMasterHA bool
+	// Dialect mysql server version specific sql statements
+	Dialect *Dialect
+	// Masters masters recorded by last cluster check, if empty, cluster is first boot and fixed masters (mysql-0, mysql-1 with DoubleMasterHA) are used.
+	// if all recorded masters are dead, the most advanced replica will be promoted as new master
+	Masters []*DSN
+	// Cloner seed member from donor before it joins cluster, nil if clone is disabled
+	Cloner *Cloner
+	// Quarantined members have errant transactions which are not acknowledged, they are never promoted as master
+	Quarantined []*DSN
+	// ReplicaOf master replicates from source cluster master and is kept super read only, nil if cluster is not a disaster recovery standby
+	ReplicaOf *ReplicaOf
+	// Delayed delayed replicas apply transactions DelaySeconds later than master, they are never promoted as master
+	Delayed []*DSN
+	// DelaySeconds MASTER_DELAY of delayed replicas
+	DelaySeconds int
+	// Maintenance members under maintenance by DBA or with drift which is only reported, their replication is not changed and they are never promoted as master
+	Maintenance []*DSN
+}
+
+// mode name of replication mode in logs
+func (t *Replication) mode() string {
+	if t.SemiSync {
+		return "semi sync"
+	}
+	return "async"
+}
+
+// failoverErr sentinel error of failover of replication mode
+func (t *Replication) failoverErr() error {
+	if t.SemiSync {
+		return types.ErrMysqlSemiSyncFailoverFailed
+	}
+	return types.ErrMysqlAsyncFailoverFailed
+}
+
+// masterErr sentinel error of master boot of replication mode
+func (t *Replication) masterErr() error {
+	if t.SemiSync {
+		return types.ErrMysqlStartSemiSyncMasterFailed
+	}
+	return types.ErrMysqlStartAsyncMasterFailed
+}
+
+// replicaErr sentinel error of replica join of replication mode
+func (t *Replication) replicaErr() error {
+	if t.SemiSync {
+		return types.ErrMysqlStartSemiSyncSlaveFailed
+	}
+	return types.ErrMysqlStartAsyncReplicaFailed
+}
+
+func (t *Replication) StartCluster(ctx context.Context) (err error) {
+	select {
+	case <-ctx.Done():
+		return types.ErrCtxTimeout
+	default:
+		var master *DSN
+		masters := t.desiredMasters()
+		if len(masters) < 1 {
+			return types.ErrMasterNoutFound
+		}
+
+		aliveMasters := aliveMembers(ctx, masters)
+		if len(aliveMasters) < 1 {
+			if len(t.Masters) < 1 { // first boot, fixed masters must be booted
+				return types.ErrMasterNoutFound
+			}
+
+			// recorded masters are dead, promote a replica
+			newMaster, err := t.failover(ctx, masters)
+			if err != nil {
+				return err
+			}
+			masters = []*DSN{newMaster}
+			aliveMasters = masters
+		}
+
+		// members which are not masters must not accept writes, proxysql routes writes to any member which read only is off
+		for _, dsn := range t.DataSrouces {
+			if dsnInList(masters, dsn) || dsnInList(t.Maintenance, dsn) {
+				continue
+			}
+
+			if err = t.fence(ctx, dsn); err != nil {
+				return err
+			}
+		}
+
+		for _, dsn := range masters {
+			if !dsnInList(aliveMasters, dsn) {
+				logrus.WithField("host", dsn.Host).Debugf("mysql %s master is not reachable, skip boot", t.mode())
+				continue
+			}
+
+			if dsnInList(t.Maintenance, dsn) { // master under maintenance is not changed, replicas still follow it
+				master = dsn
+				continue
+			}
+
+			if err = t.bootCluster(ctx, dsn, masters); err != nil {
+				return err
+			}
+			master = dsn
+		}
+
+		if master == nil {
+			return types.ErrMasterNoutFound
+		}
+
+		for _, dsn := range t.DataSrouces {
+			if dsnInList(masters, dsn) || dsnInList(t.Maintenance, dsn) {
+				continue
+			}
+
+			if err = t.joinMaster(ctx, dsn, master, 1); err != nil {
+				logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Warnf("mysql %s replica join master failed", t.mode())
+			}
+		}
+	}
+
+	return nil
+}
+
+// desiredMasters return recorded masters, or fixed masters when cluster is first boot.
+// only one master is kept without DoubleMasterHA, even if more members were recorded as masters
+func (t *Replication) desiredMasters() (masters []*DSN) {
+	var maxMasters = 1
+	if t.DoubleMasterHA {
+		maxMasters = 2
+	}
+
+	masters = t.Masters
+	if len(masters) < 1 {
+		masters = t.DataSrouces
+	}
+
+	if len(masters) > maxMasters {
+		masters = masters[:maxMasters]
+	}
+	return masters
+}
+
+// aliveMembers return members which can be connected
+func aliveMembers(ctx context.Context, members []*DSN) (alive []*DSN) {
+	for _, dsn := range members {
+		dbConn, err := Connect(ctx, dsn)
+		if err != nil {
+			continue
+		}
+
+		if err = dbConn.PingContext(ctx); err == nil {
+			alive = append(alive, dsn)
+		} else {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf(types.ErrMyqlConnectFaild.Error())
+		}
+		dbConn.Close()
+	}
+	return alive
+}
+
+// failover promote the replica with most advanced gtid_executed as new master when all masters are dead.
+// failover is refused if any replica io thread is still connected to master, that means only operator lost connection to master
+func (t *Replication) failover(ctx context.Context, deadMasters []*DSN) (newMaster *DSN, err error) {
+	var candidates []*DSN
+	var gtidSets []GTIDSet
+
+	for _, dsn := range t.DataSrouces {
+		if dsnInList(deadMasters, dsn) {
+			continue
+		}
+
+		if dsnInList(t.Delayed, dsn) {
+			logrus.WithField("host", dsn.Host).Debugf("mysql %s replica is delayed, skip failover candidate", t.mode())
+			continue
+		}
+
+		if dsnInList(t.Maintenance, dsn) {
+			logrus.WithField("host", dsn.Host).Debugf("mysql %s replica is under maintenance, skip failover candidate", t.mode())
+			continue
+		}
+
+		if dsnInList(t.Quarantined, dsn) {
+			logrus.WithField("host", dsn.Host).Warnf("mysql %s replica has errant transactions, skip failover candidate", t.mode())
+			continue
+		}
+
+		ioRunning, executed, err := replicaState(ctx, dsn, t.Dialect)
+		if err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql %s query replica state failed, skip failover candidate", t.mode())
+			continue
+		}
+
+		if ioRunning {
+			return nil, fmt.Errorf("%w, replica [host=%s] is still connected to master", t.failoverErr(), dsn.Host)
+		}
+
+		candidates = append(candidates, dsn)
+		gtidSets = append(gtidSets, executed)
+	}
+
+	index, diverged := MostAdvancedGTIDSet(gtidSets)
+	if index < 0 {
+		return nil, fmt.Errorf("%w, no replica is reachable", t.failoverErr())
+	}
+
+	newMaster = candidates[index]
+	if diverged {
+		logrus.WithField("host", newMaster.Host).Warnf("mysql %s replicas gtid_executed are diverged, promote replica has most transactions", t.mode())
+	}
+
+	if err = t.promote(ctx, newMaster); err != nil {
+		return nil, err
+	}
+
+	logrus.WithField("host", newMaster.Host).Infof("mysql %s master is dead, replica promoted as new master", t.mode())
+	return newMaster, nil
+}
+
+// replicaState wait relay log applied, then return io thread state and gtid_executed of replica
+func replicaState(ctx context.Context, dsn *DSN, dialect *Dialect) (ioRunning bool, executed GTIDSet, err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return false, nil, types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	status, err := queryReplicaStatus(ctx, dbConn, dialect)
+	if err != nil {
+		return false, nil, err
+	}
+
+	ioRunning = status[dialect.ReplicaIORunningColumn()] == "Yes"
+
+	// relay log received from dead master may not applied yet
+	if retrieved := status["Retrieved_Gtid_Set"]; retrieved != "" && !ioRunning {
+		if _, err = dbConn.ExecContext(ctx, "SELECT WAIT_FOR_EXECUTED_GTID_SET(?, 2)", retrieved); err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql wait relay log applied failed")
+		}
+	}
+
+	var gtidExecuted string
+	if err = dbConn.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&gtidExecuted); err != nil {
+		return false, nil, err
+	}
+
+	executed, err = ParseGTIDSet(gtidExecuted)
+	return ioRunning, executed, err
+}
+
+// promote stop replication of replica and clear its master settings, bootCluster will turn off read only later
+func (t *Replication) promote(ctx context.Context, dsn *DSN) (err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	if _, err = dbConn.ExecContext(ctx, t.Dialect.StopReplicaSQL()); err != nil {
+		return fmt.Errorf("%w, stop slave [host=%s] err -> %s", t.failoverErr(), dsn.Host, err.Error())
+	}
+
+	if _, err = dbConn.ExecContext(ctx, t.Dialect.ResetReplicaAllSQL()); err != nil {
+		return fmt.Errorf("%w, reset slave [host=%s] err -> %s", t.failoverErr(), dsn.Host, err.Error())
+	}
+
+	return nil
+}
+
+// bootCluster turn off read only of master, read_only=OFF also turns off super_read_only.
+// master of standby cluster is kept read only and replicates from source cluster, with DoubleMasterHA master replicates from another master
+func (t *Replication) bootCluster(ctx context.Context, dsn *DSN, masters []*DSN) (err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	if err = t.enableSourceAck(ctx, dbConn, dsn); err != nil {
+		return err
+	}
+
+	if t.ReplicaOf.Enabled() { // standby master replicates asynchronously from source master, it does not ack source master
+		if err = t.disableReplicaAck(ctx, dbConn); err != nil {
+			return fmt.Errorf("%w, disable [host=%s] slave module err -> %s", types.ErrMysqlReplicaOfFailed, dsn.Host, err.Error())
+		}
+		return t.ReplicaOf.Follow(ctx, dsn)
+	}
+
+	readOnly, err := getReadOnly(ctx, dbConn)
+	if err != nil {
+		return fmt.Errorf("%w, query [host=%s] read only err -> %s", t.masterErr(), dsn.Host, err.Error())
+	}
+
+	if readOnly {
+		if _, err = dbConn.ExecContext(ctx, "SET GLOBAL read_only=0"); err != nil {
+			return fmt.Errorf("%w, turn off [host=%s] read only err -> %s", t.masterErr(), dsn.Host, err.Error())
+		}
+		logrus.WithField("host", dsn.Host).Infof("mysql %s master read only is turned off", t.mode())
+	}
+
+	if t.DoubleMasterHA && len(masters) > 1 { // after failover, only one master is left
+		for _, v := range masters {
+			if v.Host != dsn.Host {
+				return t.joinMaster(ctx, dsn, v, 0)
+			}
+		}
+		return fmt.Errorf("mysql [host=%s] semi sync DoubleMasterHA enabled, but another master not found", dsn.Host)
+	}
+
+	return nil
+}
+
+// joinMaster make mysql instance replicate from master. replica is set super_read_only before anything else, so old master come back after failover is demoted,
+// master of DoubleMasterHA joins another master with superReadOnly 0. stopped replication threads are started again, except sql thread of delayed replica
+func (t *Replication) joinMaster(ctx context.Context, dsn *DSN, master *DSN, superReadOnly int) (err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer dbConn.Close()
+
+	if superReadOnly == 1 {
+		if _, err = dbConn.ExecContext(ctx, "SET GLOBAL super_read_only=1"); err != nil {
+			return fmt.Errorf("%w, set [host=%s] super read only err -> %s", t.replicaErr(), dsn.Host, err.Error())
+		}
+	}
+
+	if err = t.enableReplicaAck(ctx, dbConn, dsn, superReadOnly == 1); err != nil {
+		return err
+	}
+
+	if superReadOnly == 0 {
+		if _, err = dbConn.ExecContext(ctx, "SET GLOBAL super_read_only=0"); err != nil {
+			return fmt.Errorf("%w, set [host=%s] super read only = 0 err -> %s", t.replicaErr(), dsn.Host, err.Error())
+		}
+	}
+
+	replicaStatus, err := queryReplicaStatus(ctx, dbConn, t.Dialect)
+	if err != nil {
+		return fmt.Errorf("%w, query [host=%s] replica status err -> %s", t.replicaErr(), dsn.Host, err.Error())
+	}
+	ioRunning := replicaStatus[t.Dialect.ReplicaIORunningColumn()]
+
+	// replica can not fetch purged binlog from master, seed it from donor, replication is started after clone is done
+	if ioRunning != "Yes" && t.Cloner.Enabled() && t.Cloner.SeedMember(ctx, dsn, cloneDonors(ctx, t)) {
+		return nil
+	}
+
+	if replicaStatus[t.Dialect.SourceHostColumn()] != master.Host {
+		if _, err = dbConn.ExecContext(ctx, t.Dialect.StopReplicaSQL()); err != nil {
+			return fmt.Errorf("%w, stop slave [host=%s] err -> %s", t.replicaErr(), dsn.Host, err.Error())
+		}
+
+		if _, err = dbConn.ExecContext(ctx, t.Dialect.ChangeSourceSQL(master.Host, dsn.Username, dsn.Password)); err != nil {
+			return fmt.Errorf("%w, change master [host=%s] err -> %s", t.replicaErr(), dsn.Host, err.Error())
+		}
+	} else if ioRunning == "Yes" && replicaStatus[t.Dialect.ReplicaSQLRunningColumn()] == "Yes" {
+		return setReplicaDelay(ctx, dbConn, t.Dialect, dsn, replicaDelay(t.Delayed, t.DelaySeconds, dsn))
+	} else if ioRunning == "Yes" && dsnInList(t.Delayed, dsn) {
+		// sql thread of delayed replica is stopped by user to recover data, keep it stopped
+		logrus.WithField("host", dsn.Host).Debug("mysql delayed replica sql thread is stopped, skip start slave")
+		return nil
+	}
+
+	// START SLAVE only starts threads which are not running
+	if _, err = dbConn.ExecContext(ctx, t.Dialect.StartReplicaSQL()); err != nil {
+		return fmt.Errorf("%w, start slave [host=%s] err -> %s", t.replicaErr(), dsn.Host, err.Error())
+	}
+
+	return setReplicaDelay(ctx, dbConn, t.Dialect, dsn, replicaDelay(t.Delayed, t.DelaySeconds, dsn))
+}
+
+// FindMaster master is the member which read only is turned off, master of standby cluster is the member which replicates from source cluster.
+// old master come back after failover is not master until read only is turned off by operator
+func (t *Replication) FindMaster(ctx context.Context) (masters []*DSN, err error) {
+	select {
+	case <-ctx.Done():
+		return nil, types.ErrCtxTimeout
+	default:
+		for _, dsn := range t.DataSrouces {
+			if t.ReplicaOf.Enabled() { // standby master is kept read only
+				if follows, err := t.ReplicaOf.FollowsSource(ctx, dsn, t.DataSrouces); err == nil && follows {
+					masters = append(masters, dsn)
+				}
+				continue
+			}
+
+			dbConn, err := Connect(ctx, dsn)
+			if err != nil {
+				logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf(types.ErrMyqlConnectFaild.Error())
+				continue
+			}
+
+			if readOnly, err := getReadOnly(ctx, dbConn); err == nil && !readOnly {
+				masters = append(masters, dsn)
+			}
+			dbConn.Close()
+		}
+	}
+
+	if len(masters) < 1 {
+		return masters, types.ErrMasterNoutFound
+	}
+
+	return masters, nil
+}
+
+// HealthyMembers master which read only is turned off, and replicas which io thread and sql thread are running
+func (t *Replication) HealthyMembers(ctx context.Context) (members []*DSN) {
+	select {
+	case <-ctx.Done():
+		return members
+	default:
+		var wg sync.WaitGroup
+		var lock sync.Mutex
+
+		for _, dsn := range t.DataSrouces {
+			wg.Add(1)
+			go func(dsn *DSN) {
+				defer wg.Done()
+				dbConn, err := Connect(ctx, dsn)
+				if err != nil {
+					logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf(types.ErrMyqlConnectFaild.Error())
+					return
+				}
+				defer dbConn.Close()
+
+				if healthy, err := t.checkHealthy(ctx, dbConn); healthy {
+					lock.Lock()
+					members = append(members, dsn)
+					lock.Unlock()
+				} else if err != nil {
+					logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Debugf("mysql check %s member is healthy failed", t.mode())
+				}
+			}(dsn)
+		}
+
+		wg.Wait()
+	}
+
+	return
+}
+
+func (t *Replication) checkHealthy(ctx context.Context, dbConn *sql.Conn) (healthy bool, err error) {
+	readOnly, err := getReadOnly(ctx, dbConn)
+	if err != nil {
+		return false, err
+	}
+
+	if !readOnly {
+		return true, nil
+	}
+
+	replicaStatus, err := queryReplicaStatus(ctx, dbConn, t.Dialect)
+	if err != nil {
+		return false, err
+	}
+
+	return replicaStatus[t.Dialect.ReplicaIORunningColumn()] == "Yes" && replicaStatus[t.Dialect.ReplicaSQLRunningColumn()] == "Yes", nil
+}
+
+// dsnInList check dsn host is in dsn list
+func dsnInList(list []*DSN, dsn *DSN) bool {
+	for _, v := range list {
+		if v.Host == dsn.Host {
+			return true
+		}
+	}
+	return false
+}
+
+// SwitchPrimary controlled demote old master and promote target replica as master.
+// old master is set super_read_only first, target replica must apply all transactions of old master in timeout, otherwise old master is restored.
+// DoubleMasterHA is not supported
+func (t *Replication) SwitchPrimary(ctx context.Context, target *DSN) (err error) {
+	if t.DoubleMasterHA {
+		return fmt.Errorf("%w, semi sync DoubleMasterHA is not supported", types.ErrMysqlSwitchoverFailed)
+	}
+
+	masters, err := t.FindMaster(ctx)
+	if err != nil {
+		return fmt.Errorf("%w, err -> %s", types.ErrMysqlSwitchoverFailed, err.Error())
+	}
+
+	oldMaster := masters[0]
+	if oldMaster.Host == target.Host {
+		return nil
+	}
+
+	oldConn, err := Connect(ctx, oldMaster)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer oldConn.Close()
+
+	targetConn, err := Connect(ctx, target)
+	if err != nil {
+		return types.ErrMyqlConnectFaild
+	}
+	defer targetConn.Close()
+
+	// stop writes on old master
+	if _, err = oldConn.ExecContext(ctx, "SET GLOBAL super_read_only=1"); err != nil {
+		return fmt.Errorf("%w, set old master [host=%s] super read only err -> %s", types.ErrMysqlSwitchoverFailed, oldMaster.Host, err.Error())
+	}
+
+	if err = waitCatchUp(ctx, oldConn, targetConn); err != nil {
+		if t.ReplicaOf.Enabled() { // standby master is not writable
+			return err
+		}
+
+		// restore old master writes
+		// read_only=OFF also turns off super_read_only, super_read_only=OFF keeps read_only on
+		if _, restoreErr := oldConn.ExecContext(context.Background(), "SET GLOBAL read_only=0"); restoreErr != nil {
+			logrus.WithFields(map[string]interface{}{"err": restoreErr.Error(), "host": oldMaster.Host}).Error("mysql restore old master writes failed")
+		}
+		return err
+	}
+
+	if err = t.promote(ctx, target); err != nil {
+		return fmt.Errorf("%w, promote [host=%s] err -> %s", types.ErrMysqlSwitchoverFailed, target.Host, err.Error())
+	}
+
+	if err = t.bootCluster(ctx, target, []*DSN{target}); err != nil {
+		return fmt.Errorf("%w, promote [host=%s] err -> %s", types.ErrMysqlSwitchoverFailed, target.Host, err.Error())
+	}
+
+	for _, dsn := range t.DataSrouces { // old master and other replicas follow new master
+		if dsn.Host == target.Host {
+			continue
+		}
+
+		if err = t.joinMaster(ctx, dsn, target, 1); err != nil {
+			logrus.WithFields(map[string]interface{}{"err": err.Error(), "host": dsn.Host}).Warn("mysql replica follow new master failed")
+		}
+	}
+
+	return nil
+}
+
+// waitCatchUp wait target replica executed all transactions of old master, at most half of time left of ctx is used,
+// promotion and repointing of other replicas are done in the other half
+func waitCatchUp(ctx context.Context, oldConn, targetConn *sql.Conn) (err error) {
+	var gtidExecuted string
+	if err = oldConn.QueryRowContext(ctx, "SELECT @@GLOBAL.gtid_executed").Scan(&gtidExecuted); err != nil {
+		return fmt.Errorf("%w, query old master gtid_executed err -> %s", types.ErrMysqlSwitchoverFailed, err.Error())
+	}
+
+	timeout := 10
+	if deadline, ok := ctx.Deadline(); ok {
+		if timeout = int(time.Until(deadline).Seconds() / 2); timeout < 1 {
+			return fmt.Errorf("%w, no time left to wait replica catch up", types.ErrMysqlSwitchoverFailed)
+		}
+	}
+
+	var timedOut int
+	if err = targetConn.QueryRowContext(ctx, "SELECT WAIT_FOR_EXECUTED_GTID_SET(?, ?)", gtidExecuted, timeout).Scan(&timedOut); err != nil {
+		return fmt.Errorf("%w, wait replica catch up err -> %s", types.ErrMysqlSwitchoverFailed, err.Error())
+	}
+
+	if timedOut != 0 {
+		return fmt.Errorf("%w, replica not catch up old master in %d seconds", types.ErrMysqlSwitchoverFailed, timeout)
+	}
+
+	return nil
+}
+
+// getReadOnly return value of global variable read_only
+func getReadOnly(ctx context.Context, dbConn *sql.Conn) (readOnly bool, err error) {
+	err = dbConn.QueryRowContext(ctx, "SELECT @@GLOBAL.read_only").Scan(&readOnly)
+	return readOnly, err
+}
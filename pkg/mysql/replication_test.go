@@ -0,0 +1,30 @@
+package mysql
+
+import "testing"
+
+func TestReplicationDesiredMasters(t *testing.T) {
+	members := []*DSN{{Host: "yuxing-mysql-0"}, {Host: "yuxing-mysql-1"}, {Host: "yuxing-mysql-2"}}
+
+	tests := map[string]struct {
+		replication *Replication
+		want        []string
+	}{
+		"async first boot":                   {&Replication{DataSrouces: members}, []string{"yuxing-mysql-0"}},
+		"semi sync double master first boot": {&Replication{DataSrouces: members, SemiSync: true, DoubleMasterHA: true}, []string{"yuxing-mysql-0", "yuxing-mysql-1"}},
+		"recorded master":                    {&Replication{DataSrouces: members, SemiSync: true, Masters: members[2:]}, []string{"yuxing-mysql-2"}},
+		"split brain keeps one master":       {&Replication{DataSrouces: members, Masters: members[1:]}, []string{"yuxing-mysql-1"}},
+		"double master after failover":       {&Replication{DataSrouces: members, SemiSync: true, DoubleMasterHA: true, Masters: members[2:]}, []string{"yuxing-mysql-2"}},
+	}
+
+	for name, test := range tests {
+		masters := test.replication.desiredMasters()
+		if len(masters) != len(test.want) {
+			t.Fatalf("%s: desired masters are not correct, got %d masters, want %v", name, len(masters), test.want)
+		}
+		for i, dsn := range masters {
+			if dsn.Host != test.want[i] {
+				t.Fatalf("%s: desired masters are not correct, got %s at %d, want %v", name, dsn.Host, i, test.want)
+			}
+		}
+	}
+}
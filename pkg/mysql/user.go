@@ -0,0 +1,202 @@
+package mysql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/hakur/rds-operator/pkg/types"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// privilegeReg static privilege name, such as SELECT or REPLICATION CLIENT, column privileges are not supported
+	privilegeReg = regexp.MustCompile(`^[A-Za-z_ ]+$`)
+	// targetReg database target of grant, such as *.* or app.* or `app`.`orders`
+	targetReg = regexp.MustCompile("^[`\\w$*-]+(\\.[`\\w$*-]+)?$")
+)
+
+// UserGrant privileges of account on target
+type UserGrant struct {
+	Privileges []string
+	// Target database and tables, such as app.*
+	Target string
+}
+
+// UserLimits account resource limits, zero means no limit
+type UserLimits struct {
+	MaxQueriesPerHour     int
+	MaxUpdatesPerHour     int
+	MaxConnectionsPerHour int
+	MaxUserConnections    int
+}
+
+// User desired mysql account, account of every host is created with same password, privileges and resource limits
+type User struct {
+	Username string
+	Password string
+	Hosts    []string
+	Grants   []UserGrant
+	Limits   UserLimits
+}
+
+// ApplyUser create account of every host on master, password and resource limits of existing account are altered.
+// privileges of last grants which are not desired are revoked, hosts of last account which are not desired are dropped. last is nil when account is never applied
+func ApplyUser(ctx context.Context, dsn *DSN, dialect *Dialect, user *User, last *User) (err error) {
+	statements, err := user.applyStatements(dialect, last)
+	if err != nil {
+		return err
+	}
+
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("%w, [host=%s] err -> %s", types.ErrMyqlConnectFaild, dsn.Host, err.Error())
+	}
+	defer dbConn.Close()
+
+	for _, statement := range statements {
+		_, err = dbConn.ExecContext(ctx, statement)
+		var mysqlErr *mysqldriver.MySQLError
+		if errors.As(err, &mysqlErr) && (mysqlErr.Number == 1141 || mysqlErr.Number == 1147) { // 1141 1147 privilege to revoke is not granted, such as revoked by DBA
+			continue
+		} else if err != nil {
+			return fmt.Errorf("%w, [host=%s] [user=%s] err -> %s", types.ErrMysqlApplyUserFailed, dsn.Host, user.Username, err.Error())
+		}
+	}
+
+	logrus.WithFields(map[string]interface{}{"host": dsn.Host, "user": user.Username, "hosts": user.Hosts}).Info("mysql user account applied")
+	return nil
+}
+
+// DropUser drop account of every host on master, account which does not exist is ignored
+func DropUser(ctx context.Context, dsn *DSN, username string, hosts []string) (err error) {
+	dbConn, err := Connect(ctx, dsn)
+	if err != nil {
+		return fmt.Errorf("%w, [host=%s] err -> %s", types.ErrMyqlConnectFaild, dsn.Host, err.Error())
+	}
+	defer dbConn.Close()
+
+	for _, host := range hosts {
+		if _, err = dbConn.ExecContext(ctx, dropUserSQL(username, host)); err != nil {
+			return fmt.Errorf("%w, [host=%s] [user=%s] err -> %s", types.ErrMysqlDropUserFailed, dsn.Host, username, err.Error())
+		}
+	}
+
+	logrus.WithFields(map[string]interface{}{"host": dsn.Host, "user": username, "hosts": hosts}).Info("mysql user account dropped")
+	return nil
+}
+
+// RevokedGrants privileges of last grants which are not desired on same target, targets are compared without backquotes
+func RevokedGrants(last []UserGrant, desired []UserGrant) (revoked []UserGrant) {
+	for _, lastGrant := range last {
+		target := strings.ReplaceAll(lastGrant.Target, "`", "")
+		kept := make(map[string]bool)
+		for _, grant := range desired {
+			if strings.ReplaceAll(grant.Target, "`", "") != target {
+				continue
+			}
+			for _, privilege := range grant.Privileges {
+				kept[normalizePrivilege(privilege)] = true
+			}
+		}
+
+		var privileges []string
+		for _, privilege := range lastGrant.Privileges {
+			if !kept[normalizePrivilege(privilege)] {
+				privileges = append(privileges, privilege)
+			}
+		}
+
+		if len(privileges) > 0 {
+			revoked = append(revoked, UserGrant{Privileges: privileges, Target: lastGrant.Target})
+		}
+	}
+	return revoked
+}
+
+// applyStatements statements which drop hosts of last account, create or alter account of every host, then revoke and grant privileges
+func (t *User) applyStatements(dialect *Dialect, last *User) (statements []string, err error) {
+	for _, grant := range t.Grants {
+		if err = validateGrant(grant); err != nil {
+			return nil, err
+		}
+	}
+
+	var revoked []UserGrant
+	if last != nil {
+		for _, host := range last.Hosts {
+			if last.Username != t.Username || !inStrings(t.Hosts, host) {
+				statements = append(statements, dropUserSQL(last.Username, host))
+			}
+		}
+		if last.Username == t.Username {
+			revoked = RevokedGrants(last.Grants, t.Grants)
+		}
+	}
+
+	account := func(host string) string { return QuoteString(t.Username) + "@" + QuoteString(host) }
+	identified := fmt.Sprintf("IDENTIFIED WITH %s BY %s", dialect.AuthPlugin(), QuoteString(t.Password))
+	limits := fmt.Sprintf("WITH MAX_QUERIES_PER_HOUR %d MAX_UPDATES_PER_HOUR %d MAX_CONNECTIONS_PER_HOUR %d MAX_USER_CONNECTIONS %d",
+		t.Limits.MaxQueriesPerHour, t.Limits.MaxUpdatesPerHour, t.Limits.MaxConnectionsPerHour, t.Limits.MaxUserConnections)
+
+	for _, host := range t.Hosts {
+		statements = append(statements,
+			fmt.Sprintf("CREATE USER IF NOT EXISTS %s %s %s", account(host), identified, limits),
+			fmt.Sprintf("ALTER USER %s %s %s", account(host), identified, limits),
+		)
+		for _, grant := range revoked {
+			if inStrings(last.Hosts, host) {
+				statements = append(statements, fmt.Sprintf("REVOKE %s ON %s FROM %s", strings.Join(grant.Privileges, ","), grant.Target, account(host)))
+			}
+		}
+		for _, grant := range t.Grants {
+			statements = append(statements, fmt.Sprintf("GRANT %s ON %s TO %s", strings.Join(grant.Privileges, ","), grant.Target, account(host)))
+		}
+	}
+	return statements, nil
+}
+
+// validateGrant privileges and target are part of GRANT statement, they can not be quoted as string literal
+func validateGrant(grant UserGrant) error {
+	if !targetReg.MatchString(grant.Target) {
+		return fmt.Errorf("%w, [target=%s]", types.ErrMysqlInvalidGrant, grant.Target)
+	}
+
+	if len(grant.Privileges) < 1 {
+		return fmt.Errorf("%w, [target=%s] privileges are empty", types.ErrMysqlInvalidGrant, grant.Target)
+	}
+
+	for _, privilege := range grant.Privileges {
+		if !privilegeReg.MatchString(privilege) {
+			return fmt.Errorf("%w, [target=%s] [privilege=%s]", types.ErrMysqlInvalidGrant, grant.Target, privilege)
+		}
+	}
+	return nil
+}
+
+// dropUserSQL drop account of host if it exists
+func dropUserSQL(username, host string) string {
+	return "DROP USER IF EXISTS " + QuoteString(username) + "@" + QuoteString(host)
+}
+
+// normalizePrivilege upper case privilege name, ALL is same as ALL PRIVILEGES
+func normalizePrivilege(privilege string) string {
+	privilege = strings.ToUpper(strings.TrimSpace(privilege))
+	if privilege == "ALL" {
+		return "ALL PRIVILEGES"
+	}
+	return privilege
+}
+
+// inStrings s is in list
+func inStrings(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
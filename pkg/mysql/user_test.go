@@ -0,0 +1,83 @@
+package mysql
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/hakur/rds-operator/pkg/types"
+)
+
+func TestRevokedGrants(t *testing.T) {
+	last := []UserGrant{
+		{Privileges: []string{"SELECT", "INSERT", "DELETE"}, Target: "app.*"},
+		{Privileges: []string{"ALL"}, Target: "`report`.*"},
+		{Privileges: []string{"SELECT"}, Target: "logs.*"},
+	}
+	desired := []UserGrant{
+		{Privileges: []string{"select", "INSERT"}, Target: "`app`.*"},
+		{Privileges: []string{"ALL PRIVILEGES"}, Target: "report.*"},
+	}
+
+	revoked := RevokedGrants(last, desired)
+	if len(revoked) != 2 {
+		t.Fatalf("revoked grants are not correct, got %v", revoked)
+	}
+
+	if revoked[0].Target != "app.*" || strings.Join(revoked[0].Privileges, ",") != "DELETE" {
+		t.Fatalf("privileges removed from app.* are not revoked, got %v", revoked[0])
+	}
+
+	if revoked[1].Target != "logs.*" || strings.Join(revoked[1].Privileges, ",") != "SELECT" {
+		t.Fatalf("privileges of removed target are not revoked, got %v", revoked[1])
+	}
+}
+
+func TestApplyUserStatements(t *testing.T) {
+	user := &User{
+		Username: "app",
+		Password: "pa'ss",
+		Hosts:    []string{"%"},
+		Grants:   []UserGrant{{Privileges: []string{"SELECT"}, Target: "app.*"}},
+		Limits:   UserLimits{MaxUserConnections: 10},
+	}
+	last := &User{
+		Username: "app",
+		Hosts:    []string{"%", "localhost"},
+		Grants:   []UserGrant{{Privileges: []string{"SELECT", "INSERT"}, Target: "app.*"}},
+	}
+
+	dialect, _ := NewDialect("8.0.27")
+	statements, err := user.applyStatements(dialect, last)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"DROP USER IF EXISTS 'app'@'localhost'",
+		"CREATE USER IF NOT EXISTS 'app'@'%' IDENTIFIED WITH caching_sha2_password BY 'pa\\'ss' WITH MAX_QUERIES_PER_HOUR 0 MAX_UPDATES_PER_HOUR 0 MAX_CONNECTIONS_PER_HOUR 0 MAX_USER_CONNECTIONS 10",
+		"ALTER USER 'app'@'%' IDENTIFIED WITH caching_sha2_password BY 'pa\\'ss' WITH MAX_QUERIES_PER_HOUR 0 MAX_UPDATES_PER_HOUR 0 MAX_CONNECTIONS_PER_HOUR 0 MAX_USER_CONNECTIONS 10",
+		"REVOKE INSERT ON app.* FROM 'app'@'%'",
+		"GRANT SELECT ON app.* TO 'app'@'%'",
+	}
+	if strings.Join(statements, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("statements are not correct, got\n%s", strings.Join(statements, "\n"))
+	}
+
+	// renamed account drops all hosts of last account, privileges of last account are dropped with it
+	user.Username = "app2"
+	if statements, _ = user.applyStatements(nil, last); statements[0] != "DROP USER IF EXISTS 'app'@'%'" || statements[1] != "DROP USER IF EXISTS 'app'@'localhost'" || strings.HasPrefix(statements[4], "REVOKE") {
+		t.Fatalf("renamed account statements are not correct, got\n%s", strings.Join(statements, "\n"))
+	}
+
+	for _, grant := range []UserGrant{
+		{Privileges: []string{"SELECT; DROP DATABASE app"}, Target: "app.*"},
+		{Privileges: []string{"SELECT"}, Target: "app.* TO 'root'@'%'"},
+		{Target: "app.*"},
+	} {
+		user.Grants = []UserGrant{grant}
+		if _, err = user.applyStatements(nil, nil); !errors.Is(err, types.ErrMysqlInvalidGrant) {
+			t.Fatalf("invalid grant %v is accepted", grant)
+		}
+	}
+}
@@ -10,6 +10,8 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -147,6 +149,50 @@ func ApplyCronJob(c client.Client, ctx context.Context, data *batchv1.CronJob, p
 	return nil
 }
 
+// ApplyPodDisruptionBudget apply pod disruption budget
+func ApplyPodDisruptionBudget(c client.Client, ctx context.Context, data *policyv1.PodDisruptionBudget, parentObject metav1.Object, scheme *runtime.Scheme) (err error) {
+	var oldData policyv1.PodDisruptionBudget
+	if err := c.Get(ctx, client.ObjectKeyFromObject(data), &oldData); err != nil {
+		if err := client.IgnoreNotFound(err); err == nil {
+			// if pdb not exists, create it now
+			if err := c.Create(ctx, data); err != nil {
+				return err
+			}
+		} else {
+			return err
+		}
+	} else {
+		// if pdb exists, update it now
+		data.ResourceVersion = oldData.ResourceVersion
+		if err := c.Update(ctx, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplyNetworkPolicy apply network policy
+func ApplyNetworkPolicy(c client.Client, ctx context.Context, data *networkingv1.NetworkPolicy, parentObject metav1.Object, scheme *runtime.Scheme) (err error) {
+	var oldData networkingv1.NetworkPolicy
+	if err := c.Get(ctx, client.ObjectKeyFromObject(data), &oldData); err != nil {
+		if err := client.IgnoreNotFound(err); err == nil {
+			// if network policy not exists, create it now
+			if err := c.Create(ctx, data); err != nil {
+				return err
+			}
+		} else {
+			return err
+		}
+	} else {
+		// if network policy exists, update it now
+		data.ResourceVersion = oldData.ResourceVersion
+		if err := c.Update(ctx, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // AddPVCRetentionMark add deadline annottion to pvc
 func AddPVCRetentionMark(c client.Client, ctx context.Context, namespace string, labelSelector map[string]string) (err error) {
 	var pvcs corev1.PersistentVolumeClaimList
@@ -185,6 +231,54 @@ func RemovePVCRetentionMark(c client.Client, ctx context.Context, namespace stri
 	return err
 }
 
+// AddPVCRetentionMarkByName add deadline annottion to pvc of specific names, such as pvc of scaled in statefulset pods
+func AddPVCRetentionMarkByName(c client.Client, ctx context.Context, namespace string, names []string) (err error) {
+	for _, name := range names {
+		var pvc corev1.PersistentVolumeClaim
+		if err = c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &pvc); err != nil {
+			if client.IgnoreNotFound(err) == nil {
+				continue
+			}
+			return err
+		}
+
+		if pvc.Annotations == nil {
+			pvc.Annotations = make(map[string]string)
+		}
+
+		if _, ok := pvc.Annotations[types.PVCDeleteDateAnnotationName]; !ok {
+			pvc.Annotations[types.PVCDeleteDateAnnotationName] = strconv.FormatInt(time.Now().Unix()+types.PVCDeleteRetentionSeconds, 10)
+			if err = c.Update(ctx, &pvc); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// RemovePVCRetentionMarkByName delete deadline annottion from pvc of specific names
+func RemovePVCRetentionMarkByName(c client.Client, ctx context.Context, namespace string, names []string) (err error) {
+	for _, name := range names {
+		var pvc corev1.PersistentVolumeClaim
+		if err = c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &pvc); err != nil {
+			if client.IgnoreNotFound(err) == nil {
+				continue
+			}
+			return err
+		}
+
+		if _, ok := pvc.Annotations[types.PVCDeleteDateAnnotationName]; ok {
+			delete(pvc.Annotations, types.PVCDeleteDateAnnotationName)
+			if err = c.Update(ctx, &pvc); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
 // BuildCRPVCLabels generate CR subresource pvc labels
 func BuildCRPVCLabels(metaObj metav1.Object, obj runtime.Object) map[string]string {
 	return map[string]string{
@@ -213,3 +307,8 @@ func CheckOwnerRefExists(owner metav1.Object, refs []metav1.OwnerReference) (ref
 
 	return refExists
 }
+
+// IsPaused pause annotation is set on CR, operator stops changing sub resources and cluster topology
+func IsPaused(obj metav1.Object) bool {
+	return obj.GetAnnotations()[types.PauseAnnotationName] == "true"
+}
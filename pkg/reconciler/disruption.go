@@ -0,0 +1,78 @@
+package reconciler
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+const (
+	// TopologyZoneKey node label of zone, pods are spread across zones
+	TopologyZoneKey = "topology.kubernetes.io/zone"
+	// TopologyHostKey node label of host name, pods are spread across hosts
+	TopologyHostKey = "kubernetes.io/hostname"
+)
+
+// BuildPodDisruptionBudget generate pdb of pods selected by labels, at most maxUnavailable pods are evicted at same time by node drain.
+// at least one pod is always evictable, otherwise node drain is blocked forever, such as single pod or group replication of 2 members
+func BuildPodDisruptionBudget(name, namespace string, labels map[string]string, replicas int32, maxUnavailable int32) (pdb *policyv1.PodDisruptionBudget) {
+	if replicas < 2 || maxUnavailable < 1 {
+		maxUnavailable = 1
+	}
+	value := intstr.FromInt(int(maxUnavailable))
+
+	pdb = new(policyv1.PodDisruptionBudget)
+	pdb.ObjectMeta = metav1.ObjectMeta{
+		Name:      name,
+		Namespace: namespace,
+		Labels:    labels,
+	}
+	pdb.Spec.MaxUnavailable = &value
+	pdb.Spec.Selector = &metav1.LabelSelector{MatchLabels: labels}
+	return pdb
+}
+
+// QuorumMaxUnavailable max unavailable members which keep majority of group online, such as 1 of 3 members and 2 of 5 members.
+// 0 for 2 members, such group has no quorum tolerance
+func QuorumMaxUnavailable(replicas int32) int32 {
+	return replicas - (replicas/2 + 1)
+}
+
+// DefaultAffinity add preferred pod anti affinity on hosts when affinity of CR has no pod anti affinity, affinity of CR is not changed
+func DefaultAffinity(affinity *corev1.Affinity, labels map[string]string) *corev1.Affinity {
+	if affinity != nil && affinity.PodAntiAffinity != nil {
+		return affinity
+	}
+
+	result := new(corev1.Affinity)
+	if affinity != nil {
+		result = affinity.DeepCopy()
+	}
+	result.PodAntiAffinity = &corev1.PodAntiAffinity{
+		PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
+			{
+				Weight: 100,
+				PodAffinityTerm: corev1.PodAffinityTerm{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: labels},
+					TopologyKey:   TopologyHostKey,
+				},
+			},
+		},
+	}
+	return result
+}
+
+// DefaultTopologySpreadConstraints spread pods selected by labels across zones and hosts, pods are still scheduled when constraints can not be satisfied
+func DefaultTopologySpreadConstraints(labels map[string]string) []corev1.TopologySpreadConstraint {
+	var constraints []corev1.TopologySpreadConstraint
+	for _, key := range []string{TopologyZoneKey, TopologyHostKey} {
+		constraints = append(constraints, corev1.TopologySpreadConstraint{
+			MaxSkew:           1,
+			TopologyKey:       key,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+			LabelSelector:     &metav1.LabelSelector{MatchLabels: labels},
+		})
+	}
+	return constraints
+}
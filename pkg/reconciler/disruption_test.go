@@ -0,0 +1,66 @@
+package reconciler
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestQuorumMaxUnavailable(t *testing.T) {
+	for replicas, maxUnavailable := range map[int32]int32{
+		1: 0,
+		2: 0,
+		3: 1,
+		4: 1,
+		5: 2,
+		7: 3,
+	} {
+		if got := QuorumMaxUnavailable(replicas); got != maxUnavailable {
+			t.Fatalf("max unavailable of %d members is not correct, expected %d got %d", replicas, maxUnavailable, got)
+		}
+	}
+}
+
+func TestBuildPodDisruptionBudget(t *testing.T) {
+	for _, v := range []struct {
+		replicas       int32
+		maxUnavailable int32
+		expected       int
+	}{
+		{replicas: 1, maxUnavailable: 0, expected: 1},
+		{replicas: 2, maxUnavailable: 0, expected: 1},
+		{replicas: 3, maxUnavailable: 1, expected: 1},
+		{replicas: 5, maxUnavailable: 2, expected: 2},
+	} {
+		pdb := BuildPodDisruptionBudget("yuxing-mysql", "default", map[string]string{"app": "mysql"}, v.replicas, v.maxUnavailable)
+		if pdb.Spec.MaxUnavailable.IntValue() != v.expected {
+			t.Fatalf("max unavailable of %d replicas is not correct, expected %d got %s", v.replicas, v.expected, pdb.Spec.MaxUnavailable.String())
+		}
+	}
+}
+
+func TestDefaultAffinity(t *testing.T) {
+	labels := map[string]string{"app": "mysql"}
+
+	affinity := DefaultAffinity(nil, labels)
+	if affinity.PodAntiAffinity == nil || len(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution) != 1 {
+		t.Fatalf("default pod anti affinity is not added, got %v", affinity)
+	}
+	if term := affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm; term.TopologyKey != TopologyHostKey || term.LabelSelector.MatchLabels["app"] != "mysql" {
+		t.Fatalf("default pod anti affinity term is not correct, got %v", term)
+	}
+
+	nodeAffinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}
+	affinity = DefaultAffinity(nodeAffinity, labels)
+	if affinity.NodeAffinity == nil || affinity.PodAntiAffinity == nil {
+		t.Fatalf("node affinity is not kept with default pod anti affinity, got %v", affinity)
+	}
+	if nodeAffinity.PodAntiAffinity != nil {
+		t.Fatal("affinity of CR is changed")
+	}
+
+	custom := &corev1.Affinity{PodAntiAffinity: &corev1.PodAntiAffinity{}}
+	if affinity = DefaultAffinity(custom, labels); affinity != custom {
+		t.Fatalf("pod anti affinity of CR is replaced, got %v", affinity)
+	}
+}
@@ -0,0 +1,80 @@
+package reconciler
+
+import (
+	"fmt"
+
+	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+)
+
+// SetCondition set condition of CR status with generation of CR, event with condition reason and message is emitted when status or reason is changed.
+// event is a warning when condition is abnormal, such as Ready=False or Degraded=True. recorder may be nil, then only condition is set
+func SetCondition(recorder record.EventRecorder, obj runtime.Object, conditions *[]metav1.Condition, condition metav1.Condition) (changed bool) {
+	if accessor, err := meta.Accessor(obj); err == nil {
+		condition.ObservedGeneration = accessor.GetGeneration()
+	}
+
+	previous := meta.FindStatusCondition(*conditions, condition.Type)
+	changed = previous == nil || previous.Status != condition.Status || previous.Reason != condition.Reason
+	meta.SetStatusCondition(conditions, condition)
+
+	if changed {
+		eventType := corev1.EventTypeNormal
+		if isAbnormal(condition) {
+			eventType = corev1.EventTypeWarning
+		}
+		RecordEvent(recorder, obj, eventType, condition.Reason, condition.Message)
+	}
+	return changed
+}
+
+// RecordEvent emit event of CR, it is shown by kubectl describe. nothing is done when recorder is nil
+func RecordEvent(recorder record.EventRecorder, obj runtime.Object, eventType, reason, message string) {
+	if recorder == nil {
+		return
+	}
+	recorder.Event(obj, eventType, reason, message)
+}
+
+// SetStatefulSetConditions set Ready and Degraded conditions of CR by ready replicas of its statefulset, sts is nil when it is not created yet
+func SetStatefulSetConditions(recorder record.EventRecorder, obj runtime.Object, conditions *[]metav1.Condition, sts *appsv1.StatefulSet) (ready bool) {
+	var desired, readyReplicas int32 = 1, 0
+	if sts != nil {
+		readyReplicas = sts.Status.ReadyReplicas
+		if sts.Spec.Replicas != nil {
+			desired = *sts.Spec.Replicas
+		}
+	}
+	message := fmt.Sprintf("%d of %d replicas are ready", readyReplicas, desired)
+
+	ready = sts != nil && readyReplicas >= desired && sts.Status.UpdatedReplicas >= desired
+	if ready {
+		SetCondition(recorder, obj, conditions, metav1.Condition{Type: rdsv1alpha1.ConditionReady, Status: metav1.ConditionTrue, Reason: "ReplicasReady", Message: message})
+	} else {
+		SetCondition(recorder, obj, conditions, metav1.Condition{Type: rdsv1alpha1.ConditionReady, Status: metav1.ConditionFalse, Reason: "ReplicasNotReady", Message: message})
+	}
+
+	// degraded CR still has ready replicas to serve
+	if readyReplicas > 0 && readyReplicas < desired {
+		SetCondition(recorder, obj, conditions, metav1.Condition{Type: rdsv1alpha1.ConditionDegraded, Status: metav1.ConditionTrue, Reason: "ReplicasNotReady", Message: message})
+	} else if ready {
+		SetCondition(recorder, obj, conditions, metav1.Condition{Type: rdsv1alpha1.ConditionDegraded, Status: metav1.ConditionFalse, Reason: "ReplicasReady", Message: message})
+	}
+	return ready
+}
+
+// isAbnormal condition which needs attention of DBA
+func isAbnormal(condition metav1.Condition) bool {
+	switch condition.Type {
+	case rdsv1alpha1.ConditionDegraded, rdsv1alpha1.ConditionFailoverInProgress, rdsv1alpha1.MysqlConditionScaleInRefused:
+		return condition.Status == metav1.ConditionTrue
+	case rdsv1alpha1.ConditionReady, rdsv1alpha1.ConditionBootstrapped, rdsv1alpha1.ConditionBackupSucceeded, rdsv1alpha1.MysqlUserConditionProxySQLRegistered:
+		return condition.Status == metav1.ConditionFalse
+	}
+	return false
+}
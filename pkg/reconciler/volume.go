@@ -0,0 +1,216 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	"github.com/hakur/rds-operator/pkg/types"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ExpandStatefulSetVolumes expand pvc of existing pods when storage request of volume claim templates grows, it is called before ApplyStatefulSet.
+// volume claim templates are immutable, old statefulset is deleted with orphan propagation, pods are kept and adopted by statefulset created by ApplyStatefulSet.
+// types.ErrStatefulSetRecreating is returned until old statefulset is gone, caller saves returned status and reconciles again later instead of waiting.
+// storage request is never shrunk, and it is not changed when storage class of any pvc does not allow volume expansion. last is expansion status of CR, returned status replaces it
+func ExpandStatefulSetVolumes(c client.Client, ctx context.Context, recorder record.EventRecorder, obj runtime.Object, data *appsv1.StatefulSet, last *rdsv1alpha1.VolumeExpansionStatus) (expansion *rdsv1alpha1.VolumeExpansionStatus, err error) {
+	expansion = last
+
+	var oldData appsv1.StatefulSet
+	if err = c.Get(ctx, client.ObjectKeyFromObject(data), &oldData); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return expansion, nil
+		}
+		return expansion, err
+	}
+
+	if !oldData.DeletionTimestamp.IsZero() {
+		return expansion, fmt.Errorf("%w, [statefulset=%s] is deleting", types.ErrStatefulSetRecreating, oldData.Name)
+	}
+
+	var oldReplicas int32 = 1
+	if oldData.Spec.Replicas != nil {
+		oldReplicas = *oldData.Spec.Replicas
+	}
+
+	var expanded, allowed bool
+	var message string
+	for i, template := range data.Spec.VolumeClaimTemplates {
+		oldTemplate := findVolumeClaimTemplate(&oldData, template.Name)
+		if oldTemplate == nil {
+			continue
+		}
+
+		size := template.Spec.Resources.Requests[corev1.ResourceStorage]
+		oldSize := oldTemplate.Spec.Resources.Requests[corev1.ResourceStorage]
+		// new template keeps old size until pvc of existing pods are expanded, storage request is never shrunk
+		data.Spec.VolumeClaimTemplates[i].Spec.Resources.Requests = oldTemplate.Spec.Resources.Requests.DeepCopy()
+		if data.Spec.VolumeClaimTemplates[i].Spec.Resources.Requests == nil {
+			data.Spec.VolumeClaimTemplates[i].Spec.Resources.Requests = corev1.ResourceList{}
+		}
+		if size.Cmp(oldSize) <= 0 {
+			continue
+		}
+
+		var pvcs []*corev1.PersistentVolumeClaim
+		for ordinal := 0; ordinal < int(oldReplicas); ordinal++ {
+			pvc := new(corev1.PersistentVolumeClaim)
+			if err = c.Get(ctx, client.ObjectKey{Namespace: oldData.Namespace, Name: template.Name + "-" + oldData.Name + "-" + strconv.Itoa(ordinal)}, pvc); err != nil {
+				if client.IgnoreNotFound(err) == nil {
+					continue
+				}
+				return expansion, err
+			}
+			pvcs = append(pvcs, pvc)
+		}
+
+		if allowed, message, err = volumeExpansionAllowed(c, ctx, pvcs); err != nil {
+			return expansion, err
+		}
+
+		if !allowed {
+			if expansion == nil || expansion.Size != size.String() || expansion.Phase != rdsv1alpha1.VolumeExpansionPhaseFailed {
+				now := metav1.Now()
+				expansion = &rdsv1alpha1.VolumeExpansionStatus{Size: size.String(), Phase: rdsv1alpha1.VolumeExpansionPhaseFailed, Message: message, StartTime: now, CompletionTime: &now}
+				RecordEvent(recorder, obj, corev1.EventTypeWarning, "VolumeExpansionFailed", message)
+			}
+			continue
+		}
+
+		for _, pvc := range pvcs {
+			if pvc.Spec.Resources.Requests.Storage().Cmp(size) >= 0 {
+				continue
+			}
+			pvc.Spec.Resources.Requests[corev1.ResourceStorage] = size
+			if err = c.Update(ctx, pvc); err != nil {
+				return expansion, fmt.Errorf("%w, [pvc=%s] [size=%s] err -> %s", types.ErrPVCExpandFailed, pvc.Name, size.String(), err.Error())
+			}
+		}
+
+		data.Spec.VolumeClaimTemplates[i].Spec.Resources.Requests[corev1.ResourceStorage] = size
+		expansion = &rdsv1alpha1.VolumeExpansionStatus{Size: size.String(), Phase: rdsv1alpha1.VolumeExpansionPhaseResizing, StartTime: metav1.Now()}
+		expanded = true
+		RecordEvent(recorder, obj, corev1.EventTypeNormal, "VolumeExpansionStarted", fmt.Sprintf("expand pvc of statefulset %s to %s", oldData.Name, size.String()))
+	}
+
+	if expanded {
+		if err = recreateStatefulSet(c, ctx, &oldData); err != nil {
+			return expansion, err
+		}
+	}
+
+	if expansion != nil && expansion.Phase == rdsv1alpha1.VolumeExpansionPhaseResizing {
+		if err = checkVolumeExpansion(c, ctx, recorder, obj, data, expansion); err != nil {
+			return expansion, err
+		}
+	}
+
+	return expansion, nil
+}
+
+// findVolumeClaimTemplate find volume claim template of statefulset by name, nil is returned when it is not found
+func findVolumeClaimTemplate(sts *appsv1.StatefulSet, name string) *corev1.PersistentVolumeClaim {
+	for i := range sts.Spec.VolumeClaimTemplates {
+		if sts.Spec.VolumeClaimTemplates[i].Name == name {
+			return &sts.Spec.VolumeClaimTemplates[i]
+		}
+	}
+	return nil
+}
+
+// volumeExpansionAllowed check storage classes of pvc allow volume expansion, pvc without storage class is bound to static volume, it is never expanded
+func volumeExpansionAllowed(c client.Client, ctx context.Context, pvcs []*corev1.PersistentVolumeClaim) (allowed bool, message string, err error) {
+	checked := make(map[string]bool)
+	for _, pvc := range pvcs {
+		if pvc.Spec.StorageClassName == nil || *pvc.Spec.StorageClassName == "" {
+			return false, fmt.Sprintf("pvc %s has no storage class, volume expansion is not allowed", pvc.Name), nil
+		}
+
+		className := *pvc.Spec.StorageClassName
+		if checked[className] {
+			continue
+		}
+
+		var class storagev1.StorageClass
+		if err = c.Get(ctx, client.ObjectKey{Name: className}, &class); err != nil {
+			return false, "", err
+		}
+
+		if class.AllowVolumeExpansion == nil || !*class.AllowVolumeExpansion {
+			return false, fmt.Sprintf("storage class %s of pvc %s does not allow volume expansion", className, pvc.Name), nil
+		}
+		checked[className] = true
+	}
+	return true, "", nil
+}
+
+// recreateStatefulSet delete statefulset with orphan propagation, pods and pvc are kept. types.ErrStatefulSetRecreating is returned when it is deleted,
+// statefulset is created again by ApplyStatefulSet of later reconcile after it is gone
+func recreateStatefulSet(c client.Client, ctx context.Context, sts *appsv1.StatefulSet) (err error) {
+	if err = c.Delete(ctx, sts, client.PropagationPolicy(metav1.DeletePropagationOrphan)); err != nil && client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	return fmt.Errorf("%w, [statefulset=%s] is deleted with orphan propagation", types.ErrStatefulSetRecreating, sts.Name)
+}
+
+// checkVolumeExpansion record bound pvc whose capacity is less than desired size as pending, expansion is succeeded when no pvc is pending
+func checkVolumeExpansion(c client.Client, ctx context.Context, recorder record.EventRecorder, obj runtime.Object, data *appsv1.StatefulSet, expansion *rdsv1alpha1.VolumeExpansionStatus) (err error) {
+	size, err := resource.ParseQuantity(expansion.Size)
+	if err != nil {
+		return err
+	}
+
+	var replicas int32 = 1
+	if data.Spec.Replicas != nil {
+		replicas = *data.Spec.Replicas
+	}
+
+	var pending []string
+	var fileSystemPending bool
+	for _, template := range data.Spec.VolumeClaimTemplates {
+		for ordinal := 0; ordinal < int(replicas); ordinal++ {
+			var pvc corev1.PersistentVolumeClaim
+			if err = c.Get(ctx, client.ObjectKey{Namespace: data.Namespace, Name: template.Name + "-" + data.Name + "-" + strconv.Itoa(ordinal)}, &pvc); err != nil {
+				if client.IgnoreNotFound(err) == nil {
+					continue
+				}
+				return err
+			}
+
+			if pvc.Status.Phase != corev1.ClaimBound || pvc.Spec.Resources.Requests.Storage().Cmp(size) < 0 {
+				continue
+			}
+
+			if pvc.Status.Capacity.Storage().Cmp(size) < 0 {
+				pending = append(pending, pvc.Name)
+				for _, condition := range pvc.Status.Conditions {
+					if condition.Type == corev1.PersistentVolumeClaimFileSystemResizePending && condition.Status == corev1.ConditionTrue {
+						fileSystemPending = true
+					}
+				}
+			}
+		}
+	}
+
+	expansion.Pending = pending
+	expansion.Message = ""
+	if fileSystemPending {
+		expansion.Message = "volumes are expanded, file systems are resized when pods are restarted"
+	}
+
+	if len(pending) == 0 {
+		now := metav1.Now()
+		expansion.Phase = rdsv1alpha1.VolumeExpansionPhaseSucceeded
+		expansion.CompletionTime = &now
+		RecordEvent(recorder, obj, corev1.EventTypeNormal, "VolumeExpansionSucceeded", fmt.Sprintf("pvc of statefulset %s are expanded to %s", data.Name, expansion.Size))
+	}
+	return nil
+}
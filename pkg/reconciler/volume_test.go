@@ -0,0 +1,181 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	rdsv1alpha1 "github.com/hakur/rds-operator/apis/v1alpha1"
+	"github.com/hakur/rds-operator/pkg/types"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// failedUpdateClient client which fails update of pvc named failed
+type failedUpdateClient struct {
+	client.Client
+	failed string
+}
+
+func (t *failedUpdateClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	if _, ok := obj.(*corev1.PersistentVolumeClaim); ok && obj.GetName() == t.failed {
+		return errors.New("pvc update is refused")
+	}
+	return t.Client.Update(ctx, obj, opts...)
+}
+
+func buildVolumeSts(size string, replicas int32) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "yuxing-mysql", Namespace: "default"},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{{
+				ObjectMeta: metav1.ObjectMeta{Name: "data"},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}},
+				},
+			}},
+		},
+	}
+}
+
+func buildVolumePVC(name, size, className string) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			StorageClassName: &className,
+			Resources:        corev1.ResourceRequirements{Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}},
+		},
+		Status: corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound, Capacity: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)}},
+	}
+}
+
+func buildStorageClass(name string, allowExpansion bool) *storagev1.StorageClass {
+	return &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: name}, Provisioner: "csi.example.com", AllowVolumeExpansion: &allowExpansion}
+}
+
+func getPVCSize(t *testing.T, c client.Client, name string) string {
+	var pvc corev1.PersistentVolumeClaim
+	if err := c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: name}, &pvc); err != nil {
+		t.Fatal(err)
+	}
+	return pvc.Spec.Resources.Requests.Storage().String()
+}
+
+func TestExpandStatefulSetVolumesShrink(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithObjects(buildVolumeSts("10Gi", 1), buildVolumePVC("data-yuxing-mysql-0", "10Gi", "standard"), buildStorageClass("standard", true)).Build()
+
+	data := buildVolumeSts("5Gi", 1)
+	expansion, err := ExpandStatefulSetVolumes(c, ctx, nil, &rdsv1alpha1.Mysql{}, data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expansion != nil {
+		t.Fatalf("shrink is not ignored, got expansion %v", expansion)
+	}
+	if size := data.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests.Storage().String(); size != "10Gi" {
+		t.Fatalf("volume claim template is shrunk, got %s", size)
+	}
+	if size := getPVCSize(t, c, "data-yuxing-mysql-0"); size != "10Gi" {
+		t.Fatalf("pvc is shrunk, got %s", size)
+	}
+}
+
+func TestExpandStatefulSetVolumesNotAllowed(t *testing.T) {
+	ctx := context.Background()
+	c := fake.NewClientBuilder().WithObjects(buildVolumeSts("10Gi", 1), buildVolumePVC("data-yuxing-mysql-0", "10Gi", "standard"), buildStorageClass("standard", false)).Build()
+
+	data := buildVolumeSts("20Gi", 1)
+	expansion, err := ExpandStatefulSetVolumes(c, ctx, nil, &rdsv1alpha1.Mysql{}, data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expansion == nil || expansion.Phase != rdsv1alpha1.VolumeExpansionPhaseFailed || expansion.Size != "20Gi" {
+		t.Fatalf("expansion is not failed, got %v", expansion)
+	}
+	if size := data.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests.Storage().String(); size != "10Gi" {
+		t.Fatalf("volume claim template is changed, got %s", size)
+	}
+	if size := getPVCSize(t, c, "data-yuxing-mysql-0"); size != "10Gi" {
+		t.Fatalf("pvc is changed, got %s", size)
+	}
+
+	var sts appsv1.StatefulSet
+	if err = c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "yuxing-mysql"}, &sts); err != nil {
+		t.Fatalf("statefulset is deleted, err -> %s", err.Error())
+	}
+}
+
+func TestExpandStatefulSetVolumesRetry(t *testing.T) {
+	ctx := context.Background()
+	c := &failedUpdateClient{
+		Client: fake.NewClientBuilder().WithObjects(buildVolumeSts("10Gi", 2), buildVolumePVC("data-yuxing-mysql-0", "10Gi", "standard"),
+			buildVolumePVC("data-yuxing-mysql-1", "10Gi", "standard"), buildStorageClass("standard", true)).Build(),
+		failed: "data-yuxing-mysql-1",
+	}
+
+	expansion, err := ExpandStatefulSetVolumes(c, ctx, nil, &rdsv1alpha1.Mysql{}, buildVolumeSts("20Gi", 2), nil)
+	if !errors.Is(err, types.ErrPVCExpandFailed) {
+		t.Fatalf("partial pvc update is not reported, got err %v", err)
+	}
+	if expansion != nil {
+		t.Fatalf("expansion is started by partial pvc update, got %v", expansion)
+	}
+	if size := getPVCSize(t, c, "data-yuxing-mysql-0"); size != "20Gi" {
+		t.Fatalf("first pvc is not expanded, got %s", size)
+	}
+
+	var sts appsv1.StatefulSet
+	if err = c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "yuxing-mysql"}, &sts); err != nil {
+		t.Fatalf("statefulset is deleted by partial pvc update, err -> %s", err.Error())
+	}
+
+	// retry expands remaining pvc and deletes statefulset, it is created again after it is gone
+	c.failed = ""
+	expansion, err = ExpandStatefulSetVolumes(c, ctx, nil, &rdsv1alpha1.Mysql{}, buildVolumeSts("20Gi", 2), expansion)
+	if !errors.Is(err, types.ErrStatefulSetRecreating) {
+		t.Fatalf("statefulset is not recreating, got err %v", err)
+	}
+	if expansion == nil || expansion.Phase != rdsv1alpha1.VolumeExpansionPhaseResizing || expansion.Size != "20Gi" {
+		t.Fatalf("expansion is not resizing, got %v", expansion)
+	}
+	if size := getPVCSize(t, c, "data-yuxing-mysql-1"); size != "20Gi" {
+		t.Fatalf("second pvc is not expanded, got %s", size)
+	}
+
+	data := buildVolumeSts("20Gi", 2)
+	if expansion, err = ExpandStatefulSetVolumes(c, ctx, nil, &rdsv1alpha1.Mysql{}, data, expansion); err != nil {
+		t.Fatalf("statefulset which is gone is not created again, err -> %s", err.Error())
+	}
+	if expansion.Phase != rdsv1alpha1.VolumeExpansionPhaseResizing {
+		t.Fatalf("expansion status is not kept, got %v", expansion)
+	}
+	if size := data.Spec.VolumeClaimTemplates[0].Spec.Resources.Requests.Storage().String(); size != "20Gi" {
+		t.Fatalf("volume claim template of new statefulset is not expanded, got %s", size)
+	}
+}
+
+func TestExpandStatefulSetVolumesDeleting(t *testing.T) {
+	ctx := context.Background()
+	sts := buildVolumeSts("10Gi", 1)
+	sts.Finalizers = []string{"orphan"}
+	c := fake.NewClientBuilder().WithObjects(sts, buildVolumePVC("data-yuxing-mysql-0", "20Gi", "standard"), buildStorageClass("standard", true)).Build()
+	if err := c.Delete(ctx, sts); err != nil {
+		t.Fatal(err)
+	}
+
+	last := &rdsv1alpha1.VolumeExpansionStatus{Size: "20Gi", Phase: rdsv1alpha1.VolumeExpansionPhaseResizing, StartTime: metav1.Now()}
+	expansion, err := ExpandStatefulSetVolumes(c, ctx, nil, &rdsv1alpha1.Mysql{}, buildVolumeSts("20Gi", 1), last)
+	if !errors.Is(err, types.ErrStatefulSetRecreating) {
+		t.Fatalf("deleting statefulset is not reported as recreating, got err %v", err)
+	}
+	if expansion != last {
+		t.Fatalf("expansion status is changed while statefulset is deleting, got %v", expansion)
+	}
+}
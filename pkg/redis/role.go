@@ -0,0 +1,101 @@
+// Package redis query runtime state of redis servers by RESP protocol
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hakur/rds-operator/pkg/types"
+)
+
+const (
+	// RoleMaster redis server accepts writes of its slots
+	RoleMaster = "master"
+	// RoleSlave redis server replicates from master
+	RoleSlave = "slave"
+)
+
+// Role query replication role of redis server by ROLE command, values are [ master slave sentinel ]
+func Role(ctx context.Context, address string, password string) (role string, err error) {
+	dialer := net.Dialer{Timeout: time.Second * 5}
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return "", fmt.Errorf("%w, [host=%s] err -> %s", types.ErrRedisConnectFailed, address, err.Error())
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	reader := bufio.NewReader(conn)
+	if password != "" {
+		if _, err = conn.Write(encodeCommand("AUTH", password)); err != nil {
+			return "", fmt.Errorf("%w, auth [host=%s] err -> %s", types.ErrRedisQueryRoleFailed, address, err.Error())
+		}
+		if _, err = readReply(reader); err != nil {
+			return "", fmt.Errorf("%w, auth [host=%s] err -> %s", types.ErrRedisQueryRoleFailed, address, err.Error())
+		}
+	}
+
+	if _, err = conn.Write(encodeCommand("ROLE")); err != nil {
+		return "", fmt.Errorf("%w, [host=%s] err -> %s", types.ErrRedisQueryRoleFailed, address, err.Error())
+	}
+
+	if role, err = readReply(reader); err != nil {
+		return "", fmt.Errorf("%w, [host=%s] err -> %s", types.ErrRedisQueryRoleFailed, address, err.Error())
+	}
+	return role, nil
+}
+
+// encodeCommand encode command as RESP array of bulk strings
+func encodeCommand(args ...string) []byte {
+	var builder strings.Builder
+	builder.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		builder.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+	return []byte(builder.String())
+}
+
+// readReply read simple string, integer or bulk string reply, only first element of array reply is returned
+func readReply(reader *bufio.Reader) (reply string, err error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if len(line) < 1 {
+		return "", errors.New("empty reply")
+	}
+
+	switch line[0] {
+	case '+', ':':
+		return line[1:], nil
+	case '-':
+		return "", errors.New(line[1:])
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 {
+			return "", fmt.Errorf("invalid bulk string reply %s", line)
+		}
+		data := make([]byte, size+2)
+		if _, err = io.ReadFull(reader, data); err != nil {
+			return "", err
+		}
+		return string(data[:size]), nil
+	case '*':
+		if count, err := strconv.Atoi(line[1:]); err != nil || count < 1 {
+			return "", fmt.Errorf("invalid array reply %s", line)
+		}
+		return readReply(reader)
+	}
+	return "", fmt.Errorf("unknown reply %s", line)
+}
@@ -0,0 +1,39 @@
+package redis
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestEncodeCommand(t *testing.T) {
+	if got := string(encodeCommand("AUTH", "pass")); got != "*2\r\n$4\r\nAUTH\r\n$4\r\npass\r\n" {
+		t.Fatalf("unexpected command %q", got)
+	}
+}
+
+func TestReadReply(t *testing.T) {
+	cases := map[string]string{
+		"+OK\r\n":                            "OK",
+		":1\r\n":                             "1",
+		"$6\r\nmaster\r\n":                   "master",
+		"*3\r\n$6\r\nmaster\r\n:0\r\n*0\r\n": "master",
+		"*5\r\n$5\r\nslave\r\n$9\r\n127.0.0.1\r\n:6379\r\n$9\r\nconnected\r\n:10\r\n": "slave",
+	}
+
+	for input, want := range cases {
+		got, err := readReply(bufio.NewReader(strings.NewReader(input)))
+		if err != nil {
+			t.Fatalf("read %q err -> %s", input, err.Error())
+		}
+		if got != want {
+			t.Fatalf("read %q got %s, want %s", input, got, want)
+		}
+	}
+
+	for _, input := range []string{"-NOAUTH Authentication required.\r\n", "*0\r\n", "$-1\r\n", "?\r\n"} {
+		if _, err := readReply(bufio.NewReader(strings.NewReader(input))); err == nil {
+			t.Fatalf("read %q should fail", input)
+		}
+	}
+}
@@ -5,6 +5,18 @@ const (
 	PVCDeleteDateAnnotationName = "delete-time.pvc.hakurei.cn"
 	// PVCDeleteRetentionSeconds how many seconds of pvc retention
 	PVCDeleteRetentionSeconds = 180 * 24 * 60 * 60 // 180 days
-	ProxySQLWriterGroup       = 10
-	ProxySQLReaderGroup       = 20
+	// MysqlForceBootstrapAnnotationName set value to true on Mysql CR, group replication will be bootstrapped without quorum of members reachable
+	MysqlForceBootstrapAnnotationName = "force-bootstrap.mysql.hakurei.cn"
+	// MysqlAckErrantTransactionsAnnotationName set value to comma separated member pod names on Mysql CR, current errant transactions of these members are acknowledged
+	MysqlAckErrantTransactionsAnnotationName = "ack-errant-transactions.mysql.hakurei.cn"
+	// MysqlPromoteAnnotationName set value to true on standby Mysql CR, replication from source cluster is stopped and spec.replicaOf is removed
+	MysqlPromoteAnnotationName = "promote.mysql.hakurei.cn"
+	// MysqlForceMembersAnnotationName set value to true on Mysql CR, group replication blocked by loss of quorum is unblocked with group_replication_force_members
+	MysqlForceMembersAnnotationName = "force-members.mysql.hakurei.cn"
+	// PauseAnnotationName set value to true on Mysql, Redis or ProxySQL CR, operator stops changing sub resources and cluster topology, status is still reported
+	PauseAnnotationName = "pause.rds.hakurei.cn"
+	// MysqlMaintenanceAnnotationName set value to comma separated member pod names on Mysql CR, these members are removed from proxysql and failover candidates, their replication is not changed by operator
+	MysqlMaintenanceAnnotationName = "maintenance.mysql.hakurei.cn"
+	ProxySQLWriterGroup            = 10
+	ProxySQLReaderGroup            = 20
 )
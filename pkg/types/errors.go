@@ -13,7 +13,36 @@ var (
 	ErrMysqlStartSemiSyncSlaveFailed   = errors.New("mysql start semi sync slave failed")
 	ErrMysqlStartSemiSyncClusterFailed = errors.New("mysql start semi sync cluster failed")
 	ErrMysqlStartMGRSPClusterFailed    = errors.New("mysql start group relication cluster with single primary mode failed")
+	ErrMysqlStartMGRMPClusterFailed    = errors.New("mysql start group relication cluster with multi primary mode failed")
+	ErrMysqlUnsupportedClusterMode     = errors.New("mysql cluster mode is not supported")
 	ErrMysqlSemiSyncIsAlreadyRunning   = errors.New("mysql group relication is already running")
 	ErrMysqlMGRIsAlreadyRunning        = errors.New("mysql group relication is already running")
 	ErrMysqlFindMasterFromSalveFailed  = errors.New("mysql try to find master from query slave instance failed")
+	ErrMysqlSemiSyncFailoverFailed     = errors.New("mysql semi sync failover failed")
+	ErrMysqlMGRBootstrapRefused        = errors.New("mysql group relication bootstrap refused")
+	ErrMysqlSwitchoverFailed           = errors.New("mysql primary switchover failed")
+	ErrMysqlRemoveMemberFailed         = errors.New("mysql remove member failed")
+	ErrMysqlCloneFailed                = errors.New("mysql clone member from donor failed")
+	ErrMysqlStartAsyncMasterFailed     = errors.New("mysql start async master failed")
+	ErrMysqlStartAsyncReplicaFailed    = errors.New("mysql start async replica failed")
+	ErrMysqlAsyncFailoverFailed        = errors.New("mysql async failover failed")
+	ErrMysqlReplicaOfFailed            = errors.New("mysql standby master follow source cluster failed")
+	ErrMysqlReplicaOfPromoteFailed     = errors.New("mysql promote standby cluster failed")
+	ErrMysqlReplicaDelayFailed         = errors.New("mysql change replica delay failed")
+	ErrMysqlMGROptionsFailed           = errors.New("mysql apply group replication options failed")
+	ErrMysqlMGRForceMembersFailed      = errors.New("mysql group replication force members failed")
+	ErrMysqlMGRQuorumLost              = errors.New("mysql group replication quorum lost")
+	ErrMysqlApplyConfigFailed          = errors.New("mysql apply config to running members failed")
+	ErrMysqlConfigInvalid              = errors.New("mysql config variable is invalid")
+	ErrMysqlFenceFailed                = errors.New("mysql fence member which is not master failed")
+	ErrMysqlCorrectDriftFailed         = errors.New("mysql correct drifted runtime state failed")
+	ErrMysqlApplyUserFailed            = errors.New("mysql apply user account failed")
+	ErrMysqlDropUserFailed             = errors.New("mysql drop user account failed")
+	ErrMysqlInvalidGrant               = errors.New("mysql grant privileges or database target is invalid")
+	ErrMysqlTLSConfigInvalid           = errors.New("mysql tls config of connection is invalid")
+	ErrReferenceRefused                = errors.New("reference to CR of other namespace is refused")
+	ErrRedisConnectFailed              = errors.New("redis connect failed")
+	ErrRedisQueryRoleFailed            = errors.New("redis query role failed")
+	ErrPVCExpandFailed                 = errors.New("expand persistent volume claim failed")
+	ErrStatefulSetRecreating           = errors.New("statefulset is recreating")
 )